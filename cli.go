@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/processor"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/ORAITApps/document-uploader/internal/secrets"
+)
+
+const usage = `usage: document-uploader validate <documents-directory>
+       document-uploader upload [-list <file>]   (paths read from stdin without -list)
+       document-uploader secret set|delete client_secret`
+
+// runCLI handles subcommands given on the command line and returns the exit
+// code: 0 on success, 1 when problems were found or the command failed, 2
+// for usage errors.
+func runCLI(args []string) int {
+	switch args[0] {
+	case "validate":
+		return runValidate(args[1:])
+	case "upload":
+		return runUploadList(args[1:])
+	case "secret":
+		return runSecret(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s\n", args[0], usage)
+		return 2
+	}
+}
+
+// runValidate checks a documents tree without signing in or uploading and
+// prints the report as JSON on stdout.
+func runValidate(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	report, err := processor.ValidateDirectory(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+// runUploadList uploads the files named in a newline-delimited list, one
+// path per line, so other tools can choose the work set. Blank lines and
+// lines starting with # are ignored. Every path must exist and follow the
+// flat naming convention; nothing is uploaded otherwise.
+func runUploadList(args []string) int {
+	flags := flag.NewFlagSet("upload", flag.ContinueOnError)
+	listPath := flags.String("list", "", "file with one path per line, - or empty for stdin")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	var list io.Reader = os.Stdin
+	if *listPath != "" && *listPath != "-" {
+		file, err := os.Open(*listPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "upload: %v\n", err)
+			return 2
+		}
+		defer file.Close()
+		list = file
+	}
+
+	paths, err := readFileList(list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upload: %v\n", err)
+		return 2
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "upload: the list names no files")
+		return 2
+	}
+
+	invalid := 0
+	for _, path := range paths {
+		if _, err := processor.ParseFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		fmt.Fprintf(os.Stderr, "upload: %d of %d listed file(s) are invalid, nothing was uploaded\n", invalid, len(paths))
+		return 1
+	}
+
+	logger := logging.GetLogger()
+	setupClients(logger)
+
+	events := make(chan progress.Event, 64)
+	done := make(chan struct{})
+	go func() {
+		progress.Print(os.Stderr, events)
+		close(done)
+	}()
+
+	_, err = runUpload(uploadRequest{Files: paths}, progress.NewReporter(events), logger)
+	close(events)
+	<-done
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upload: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readFileList returns the paths in a newline-delimited list.
+func readFileList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file list: %v", err)
+	}
+	return paths, nil
+}
+
+// runSecret saves the client secret to the store selected by SECRET_STORE,
+// or removes it. The value is read from stdin so it stays out of the shell
+// history and the process list.
+func runSecret(args []string) int {
+	if len(args) != 2 || (args[0] != "set" && args[0] != "delete") || args[1] != secrets.ClientSecretName {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	store := openSecretStore(logging.GetLogger())
+	if store == nil {
+		fmt.Fprintln(os.Stderr, "secret: set SECRET_STORE to keychain or file first")
+		return 2
+	}
+
+	if args[0] == "delete" {
+		if err := store.Delete(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "secret: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	value, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "secret: %v\n", err)
+		return 1
+	}
+	secret := strings.TrimSpace(string(value))
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "secret: no value given on stdin")
+		return 2
+	}
+	if err := store.Set(args[1], secret); err != nil {
+		fmt.Fprintf(os.Stderr, "secret: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/processor"
+)
+
+// captureOutput returns what run writes to stream, os.Stdout or os.Stderr.
+func captureOutput(t *testing.T, stream **os.File, run func()) []byte {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := *stream
+	*stream = writer
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- data
+	}()
+
+	run()
+
+	*stream = previous
+	writer.Close()
+	return <-output
+}
+
+func TestRunValidate(t *testing.T) {
+	valid := t.TempDir()
+	invalid := t.TempDir()
+	for dir, name := range map[string]string{valid: "bl_front.jpg", invalid: "photo_front.jpg"} {
+		building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+		if err := os.MkdirAll(building, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(building, name), []byte("jpg"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantCode     int
+		wantProblems []string
+	}{
+		{name: "valid tree", args: []string{valid}, wantCode: 0},
+		{name: "invalid tree", args: []string{invalid}, wantCode: 1, wantProblems: []string{"Tower/P1/Z1/B1/photo_front.jpg"}},
+		{name: "missing directory", args: []string{filepath.Join(valid, "missing")}, wantCode: 2},
+		{name: "no directory", wantCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code int
+			output := captureOutput(t, &os.Stdout, func() { code = runValidate(tt.args) })
+			if code != tt.wantCode {
+				t.Fatalf("runValidate() = %d, want %d; output:\n%s", code, tt.wantCode, output)
+			}
+			if code == 2 {
+				return
+			}
+
+			var report processor.ValidationReport
+			if err := json.Unmarshal(output, &report); err != nil {
+				t.Fatalf("output is not a JSON report: %v\n%s", err, output)
+			}
+			if report.Valid != (tt.wantCode == 0) {
+				t.Errorf("Valid = %v with exit code %d", report.Valid, code)
+			}
+			var paths []string
+			for _, problem := range report.Problems {
+				paths = append(paths, problem.Path)
+			}
+			if len(paths) != len(tt.wantProblems) || (len(paths) > 0 && paths[0] != tt.wantProblems[0]) {
+				t.Errorf("problems = %+v, want %v", report.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestRunUploadList(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "bl_b_Tower_P1_Z1_B1.jpg")
+	badName := filepath.Join(dir, "photo.jpg")
+	for _, path := range []string{valid, badName} {
+		if err := os.WriteFile(path, []byte("jpg"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(dir, "bl_b_Tower_P1_Z1_B2.jpg")
+
+	// A valid list gets as far as signing in, which fails here.
+	previousSignIn := signIn
+	signIn = func() (*models.TokenResponse, error) { return nil, errors.New("no browser in tests") }
+	defer func() { signIn = previousSignIn }()
+
+	writeList := func(lines ...string) string {
+		path := filepath.Join(t.TempDir(), "list.txt")
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		want     []string
+		notWant  []string
+	}{
+		{name: "valid and invalid entries", args: []string{"-list", writeList(valid, badName, "", "# comment", missing)}, wantCode: 1,
+			want:    []string{badName + ": ", missing + ": ", "2 of 3 listed file(s) are invalid, nothing was uploaded"},
+			notWant: []string{valid + ": ", "no browser"}},
+		{name: "valid entries", args: []string{"-list", writeList("# generated", valid)}, wantCode: 1,
+			want: []string{"upload: ", "no browser in tests"}},
+		{name: "stdin", stdin: badName + "\n", wantCode: 1, want: []string{"1 of 1 listed file(s) are invalid"}},
+		{name: "empty list", args: []string{"-list", writeList("", "# nothing")}, wantCode: 2, want: []string{"the list names no files"}},
+		{name: "missing list", args: []string{"-list", filepath.Join(dir, "missing.txt")}, wantCode: 2},
+		{name: "extra argument", args: []string{dir}, wantCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stdin != "" {
+				stdin := filepath.Join(t.TempDir(), "stdin")
+				if err := os.WriteFile(stdin, []byte(tt.stdin), 0644); err != nil {
+					t.Fatal(err)
+				}
+				file, err := os.Open(stdin)
+				if err != nil {
+					t.Fatal(err)
+				}
+				previous := os.Stdin
+				os.Stdin = file
+				defer func() { os.Stdin = previous; file.Close() }()
+			}
+
+			var code int
+			output := string(captureOutput(t, &os.Stderr, func() { code = runUploadList(tt.args) }))
+			if code != tt.wantCode {
+				t.Fatalf("runUploadList() = %d, want %d; output:\n%s", code, tt.wantCode, output)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output does not contain %q:\n%s", want, output)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(output, notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, output)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	paths, err := readFileList(strings.NewReader("  a/bl_b_T_P_Z_B.jpg  \n\n# skipped\r\nb c/fp_b_T_P_Z_B.pdf\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a/bl_b_T_P_Z_B.jpg", "b c/fp_b_T_P_Z_B.pdf"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("readFileList() = %q, want %q", paths, want)
+	}
+}
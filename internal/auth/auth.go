@@ -6,17 +6,39 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/ORAITApps/document-uploader/internal/config"
 	"github.com/ORAITApps/document-uploader/internal/models"
 	"github.com/pkg/browser"
 )
 
+const (
+	tokenExchangeAttempts = 3
+	tokenExchangeBackoff  = 500 * time.Millisecond
+)
+
+const (
+	callbackMaxHeaderBytes = 8 << 10
+	callbackMaxBodyBytes   = 4 << 10
+)
+
+// callbackShutdownTimeout bounds how long stopping the callback server waits
+// for a handler that is still writing its page.
+const callbackShutdownTimeout = 5 * time.Second
+
 var (
+	httpClient = http.DefaultClient
+
 	server   *http.Server
 	serverMu sync.Mutex
 	mux      *http.ServeMux
@@ -24,10 +46,26 @@ var (
 
 func init() {
 	mux = http.NewServeMux()
-	server = &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+	server = newCallbackServer(mux, config.CallbackTimeout)
+}
+
+func newCallbackServer(handler http.Handler, timeout time.Duration) *http.Server {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
 	}
+	return &http.Server{
+		Handler:           http.MaxBytesHandler(handler, callbackMaxBodyBytes),
+		ReadHeaderTimeout: timeout,
+		ReadTimeout:       timeout,
+		WriteTimeout:      timeout,
+		IdleTimeout:       timeout,
+		MaxHeaderBytes:    callbackMaxHeaderBytes,
+	}
+}
+
+// SetHTTPClient replaces the client used for the token exchange.
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
 }
 
 func Authenticate() (*models.TokenResponse, error) {
@@ -36,45 +74,87 @@ func Authenticate() (*models.TokenResponse, error) {
 
 	// Stop any existing server
 	if server != nil {
-		server.Shutdown(context.Background())
+		shutdownCallbackServer()
+	}
+
+	redirectHost, port, callbackPath, err := callbackEndpoint(config.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+	successPage, err := loadSuccessPage()
+	if err != nil {
+		return nil, err
+	}
+	hosts := append([]string{redirectHost}, config.CallbackHosts...)
+
+	listeners, err := listenLoopback(loopbackAddrs(hosts, port))
+	if err != nil {
+		return nil, err
 	}
 
 	// Create new server and mux
 	mux = http.NewServeMux()
-	server = &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
-	}
+	server = newCallbackServer(hostFilter(hosts, mux), config.CallbackTimeout)
 
-	codeChan := make(chan string)
+	// A second callback, e.g. the browser reloading the page or another
+	// listener receiving the same redirect, is dropped instead of blocking
+	// its handler.
+	codeChan := make(chan string, 1)
 
 	codeVerifier := generateCodeVerifier(64)
 	codeChallenge := generateCodeChallenge(codeVerifier)
 
-	mux.HandleFunc("/oauth/callback", createCallbackHandler(codeChan))
+	mux.HandleFunc(callbackPath, createCallbackHandler(codeChan, successPage))
 
-	go func() {
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			fmt.Printf("HTTP server error: %v\n", err)
-		}
-	}()
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			if err := server.Serve(listener); err != http.ErrServerClosed {
+				fmt.Printf("HTTP server error: %v\n", err)
+			}
+		}(listener)
+	}
 
-	authURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&code_challenge=%s&code_challenge_method=S256",
-		config.AuthURL, config.ClientID, config.RedirectURI, codeChallenge)
+	authURL := config.AuthURL + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {config.RedirectURI},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}.Encode()
 
 	if err := browser.OpenURL(authURL); err != nil {
+		shutdownCallbackServer()
 		return nil, fmt.Errorf("failed to open browser: %v", err)
 	}
 
-	code := <-codeChan
+	var timeout <-chan time.Time
+	if config.LoginTimeout > 0 {
+		timer := time.NewTimer(config.LoginTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 
-	if err := server.Shutdown(context.Background()); err != nil {
-		fmt.Printf("Error shutting down server: %v\n", err)
+	var code string
+	select {
+	case code = <-codeChan:
+	case <-timeout:
+		shutdownCallbackServer()
+		return nil, fmt.Errorf("login not completed within %s", config.LoginTimeout)
 	}
 
+	shutdownCallbackServer()
+
 	return exchangeCodeForToken(code, codeVerifier)
 }
 
+func shutdownCallbackServer() {
+	ctx, cancel := context.WithTimeout(context.Background(), callbackShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Error shutting down server: %v\n", err)
+	}
+}
+
 func generateCodeVerifier(length int) string {
 	bytes := make([]byte, length)
 	rand.Read(bytes)
@@ -86,50 +166,112 @@ func generateCodeChallenge(verifier string) string {
 	return base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(hash[:])
 }
 
-func createCallbackHandler(codeChan chan string) http.HandlerFunc {
+func createCallbackHandler(codeChan chan string, page *template.Template) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		if code == "" {
 			w.Write([]byte("Error: No authorization code received"))
 			return
 		}
-		codeChan <- code
-
-		html := `
-        <html>
-            <head><title>Authentication Successful</title></head>
-            <body>
-                <h3>Authentication successful! This window will close automatically.</h3>
-                <script>setTimeout(function() { window.close(); }, 2000);</script>
-            </body>
-        </html>
-        `
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(html))
+		select {
+		case codeChan <- code:
+		default:
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := page.Execute(w, currentSuccessPageData()); err != nil {
+			fmt.Printf("Error rendering callback page: %v\n", err)
+		}
 	}
 }
 
 func exchangeCodeForToken(code, codeVerifier string) (*models.TokenResponse, error) {
-	data := fmt.Sprintf("grant_type=authorization_code&code=%s&client_id=%s&redirect_uri=%s&code_verifier=%s",
-		code, config.ClientID, config.RedirectURI, codeVerifier)
+	data := buildTokenRequestBody(code, codeVerifier)
 
-	req, err := http.NewRequest("POST", config.TokenURL, strings.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
+	var resp *http.Response
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("POST", config.TokenURL, strings.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+		resp, err = httpClient.Do(req)
+		if err == nil {
+			break
+		}
+
+		// Authorization codes are single-use, so only retry when the request
+		// could not have reached Salesforce.
+		if attempt >= tokenExchangeAttempts || !isConnectionError(err) {
+			return nil, err
+		}
+
+		backoff := tokenExchangeBackoff * time.Duration(1<<(attempt-1))
+		fmt.Printf("Token exchange attempt %d failed: %v, retrying in %s\n", attempt, err, backoff)
+		time.Sleep(backoff)
 	}
 	defer resp.Body.Close()
 
-	var tokenResp models.TokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return nil, err
+	return decodeTokenResponse(resp)
+}
+
+// decodeTokenResponse reads a token endpoint response. Fields missing from
+// a response would otherwise decode to an empty access token and fail later
+// with a confusing 401, so the token is checked here and Salesforce's OAuth
+// error is reported when there is one.
+func decodeTokenResponse(resp *http.Response) (*models.TokenResponse, error) {
+	var body struct {
+		models.TokenResponse
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+
+	if body.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s: %s", body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("error decoding token response: %v", decodeErr)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return &body.TokenResponse, nil
+}
+
+// buildTokenRequestBody encodes the token exchange form. The client secret is
+// only sent for confidential connected apps; public apps rely on PKCE alone.
+func buildTokenRequestBody(code, codeVerifier string) string {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("client_id", config.ClientID)
+	form.Set("redirect_uri", config.RedirectURI)
+	form.Set("code_verifier", codeVerifier)
+	if secret := clientSecret(); secret != "" {
+		form.Set("client_secret", secret)
+	}
+	return form.Encode()
+}
+
+// isConnectionError reports whether a request failed before reaching the
+// server. A reset connection is not one: it can come after the request,
+// and with it the single-use authorization code, was already sent.
+func isConnectionError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
 	}
 
-	return &tokenResp, nil
+	return errors.Is(err, syscall.ECONNREFUSED)
 }
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/secrets"
+)
+
+func TestCallbackHandlerDoesNotBlockOnSecondCode(t *testing.T) {
+	codeChan := make(chan string, 1)
+	handler := createCallbackHandler(codeChan, template.Must(template.New("page").Parse("ok")))
+
+	done := make(chan struct{})
+	go func() {
+		for _, code := range []string{"first", "second"} {
+			recorder := httptest.NewRecorder()
+			handler(recorder, httptest.NewRequest(http.MethodGet, "/oauth/callback?code="+code, nil))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("callback %s: status %d", code, recorder.Code)
+			}
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("second callback blocked its handler")
+	}
+	if code := <-codeChan; code != "first" {
+		t.Errorf("code = %q, want first", code)
+	}
+}
+
+func TestCallbackHandlerRequiresCode(t *testing.T) {
+	codeChan := make(chan string, 1)
+	handler := createCallbackHandler(codeChan, template.Must(template.New("page").Parse("ok")))
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/oauth/callback", nil))
+
+	select {
+	case code := <-codeChan:
+		t.Errorf("unexpected code %q", code)
+	default:
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.invalid"}, true},
+		{"dial", &net.OpError{Op: "dial", Err: errors.New("i/o timeout")}, true},
+		{"refused", &url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: syscall.ECONNREFUSED}}, true},
+		{"reset", &url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, false},
+		{"read", &net.OpError{Op: "read", Err: errors.New("unexpected EOF")}, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchangeCodeForTokenRetriesReset(t *testing.T) {
+	tests := []struct {
+		name string
+		// resetDial fails the first connection before the request is sent;
+		// otherwise the server resets it after reading the request.
+		resetDial bool
+		requests  int32
+		wantErr   bool
+	}{
+		{name: "reset while connecting", resetDial: true, requests: 1},
+		{name: "reset after the code was sent", requests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 && !tt.resetDial {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					conn.(*net.TCPConn).SetLinger(0)
+					conn.Close()
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"access_token": "token", "instance_url": "https://acme.my.salesforce.com"})
+			}))
+			defer server.Close()
+
+			var dials atomic.Int32
+			dialer := &net.Dialer{}
+			transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if dials.Add(1) == 1 && tt.resetDial {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNRESET}
+				}
+				return dialer.DialContext(ctx, network, addr)
+			}}
+			defer transport.CloseIdleConnections()
+
+			previousClient, previousURL := httpClient, config.TokenURL
+			httpClient, config.TokenURL = &http.Client{Transport: transport}, server.URL
+			defer func() { httpClient, config.TokenURL = previousClient, previousURL }()
+
+			token, err := exchangeCodeForToken("code", "verifier")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("exchangeCodeForToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && token.AccessToken != "token" {
+				t.Errorf("AccessToken = %q, want token", token.AccessToken)
+			}
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("%d token requests reached the server, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestCallbackServerLimits(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newCallbackServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}), timeout)
+	go server.Serve(listener)
+	defer server.Close()
+	addr := "http://" + listener.Addr().String()
+
+	t.Run("slow headers", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET /oauth/callback HTTP/1.1\r\nHost: localhost\r\n"))
+
+		conn.SetReadDeadline(time.Now().Add(10 * timeout))
+		start := time.Now()
+		if _, err := io.ReadAll(conn); err != nil {
+			t.Fatalf("connection left open: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*timeout {
+			t.Errorf("connection closed after %s, want about %s", elapsed, timeout)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		want   int
+	}{
+		{name: "small request", body: "code=abc", want: http.StatusOK},
+		{name: "large body", body: strings.Repeat("x", 2*callbackMaxBodyBytes), want: http.StatusRequestEntityTooLarge},
+		{name: "large header", header: strings.Repeat("x", 8*callbackMaxHeaderBytes), want: http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, addr+"/oauth/callback", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Padding", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTokenRequestBodyClientSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		stored string
+		want   string
+	}{
+		{name: "public app"},
+		{name: "secret in .env", env: "from-env", want: "from-env"},
+		{name: "secret in store", stored: "from-store", want: "from-store"},
+		{name: ".env before store", env: "from-env", stored: "from-store", want: "from-env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := secrets.NewFileStore(filepath.Join(t.TempDir(), "secrets.json"))
+			if tt.stored != "" {
+				if err := store.Set(secrets.ClientSecretName, tt.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			previousSecret, previousStore := config.ClientSecret, secretStore
+			config.ClientSecret, secretStore = tt.env, store
+			defer func() { config.ClientSecret, secretStore = previousSecret, previousStore }()
+
+			form, err := url.ParseQuery(buildTokenRequestBody("code", "verifier"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := form.Get("client_secret"); got != tt.want {
+				t.Errorf("client_secret = %q, want %q", got, tt.want)
+			}
+			if _, sent := form["client_secret"]; sent != (tt.want != "") {
+				t.Errorf("client_secret sent = %v, want %v", sent, tt.want != "")
+			}
+			if form.Get("code_verifier") != "verifier" {
+				t.Errorf("code_verifier = %q, want the PKCE verifier", form.Get("code_verifier"))
+			}
+		})
+	}
+}
+
+func TestDecodeTokenResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", status: 200, body: `{"access_token":"00D!abc","instance_url":"https://acme.my.salesforce.com"}`, want: "00D!abc"},
+		{name: "extra fields", status: 200, body: `{"access_token":"00D!abc","signature":"x","is_readonly":"false","api_instance_url":"https://api.salesforce.com"}`, want: "00D!abc"},
+		{name: "missing access_token", status: 200, body: `{"instance_url":"https://acme.my.salesforce.com","token_type":"Bearer"}`, wantErr: "token response has no access_token"},
+		{name: "empty access_token", status: 200, body: `{"access_token":""}`, wantErr: "token response has no access_token"},
+		{name: "oauth error", status: 400, body: `{"error":"invalid_grant","error_description":"expired authorization code"}`, wantErr: "token request failed: invalid_grant: expired authorization code"},
+		{name: "server error", status: 503, body: `<html>down</html>`, wantErr: "token request failed: status 503"},
+		{name: "not json", status: 200, body: `<html>login</html>`, wantErr: "error decoding token response: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(tt.status)
+			recorder.WriteString(tt.body)
+
+			token, err := decodeTokenResponse(recorder.Result())
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeTokenResponse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.AccessToken != tt.want {
+				t.Errorf("AccessToken = %q, want %q", token.AccessToken, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// callbackEndpoint returns the host, port and path the OAuth redirect
+// arrives on.
+func callbackEndpoint(redirectURI string) (host, port, path string, err error) {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid redirect URI %q: %v", redirectURI, err)
+	}
+	port = parsed.Port()
+	if port == "" {
+		return "", "", "", fmt.Errorf("redirect URI %q has no port", redirectURI)
+	}
+	path = parsed.Path
+	if path == "" {
+		path = "/"
+	}
+	return parsed.Hostname(), port, path, nil
+}
+
+// loopbackAddrs returns the addresses to listen on so the redirect arrives
+// whichever loopback form the browser uses. localhost may resolve to either
+// the IPv4 or the IPv6 loopback address.
+func loopbackAddrs(hosts []string, port string) []string {
+	seen := make(map[string]bool)
+	var addrs []string
+	add := func(ip string) {
+		addr := net.JoinHostPort(ip, port)
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	for _, host := range hosts {
+		host = strings.Trim(host, "[]")
+		if strings.EqualFold(host, "localhost") {
+			add("127.0.0.1")
+			add("::1")
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			add(ip.String())
+			continue
+		}
+		fmt.Printf("Ignoring callback host %s: only loopback hosts are supported\n", host)
+	}
+	return addrs
+}
+
+// listenLoopback listens on every address it can. IPv6 is not available on
+// every machine, so only failing on all of them is an error.
+func listenLoopback(addrs []string) ([]net.Listener, error) {
+	var listeners []net.Listener
+	var firstErr error
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no loopback callback hosts configured")
+		}
+		return nil, fmt.Errorf("failed to start callback server: %v", firstErr)
+	}
+	return listeners, nil
+}
+
+// hostFilter only serves requests addressed to one of the configured hosts,
+// so a page loaded from another site cannot reach the callback by rebinding
+// its DNS name to the loopback address.
+func hostFilter(hosts []string, next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		allowed[strings.ToLower(strings.Trim(host, "[]"))] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if !allowed[strings.ToLower(strings.Trim(host, "[]"))] {
+			http.Error(w, "Unexpected host", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
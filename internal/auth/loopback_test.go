@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoopbackAddrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		want  []string
+	}{
+		{name: "localhost", hosts: []string{"localhost"}, want: []string{"127.0.0.1:1717", "[::1]:1717"}},
+		{name: "IPv4", hosts: []string{"127.0.0.1"}, want: []string{"127.0.0.1:1717"}},
+		{name: "IPv6", hosts: []string{"[::1]"}, want: []string{"[::1]:1717"}},
+		{name: "duplicates", hosts: []string{"127.0.0.1", "localhost", "::1"}, want: []string{"127.0.0.1:1717", "[::1]:1717"}},
+		{name: "not loopback", hosts: []string{"example.com", "10.0.0.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loopbackAddrs(tt.hosts, "1717"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loopbackAddrs(%v) = %v, want %v", tt.hosts, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCallbackLoopbackForms sends the redirect to the callback server the way
+// a browser would for each loopback form of the redirect URI.
+func TestCallbackLoopbackForms(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(free.Addr().String())
+	free.Close()
+
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	listeners, err := listenLoopback(loopbackAddrs(hosts, port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipv6 := false
+	for _, listener := range listeners {
+		if host, _, _ := net.SplitHostPort(listener.Addr().String()); host == "::1" {
+			ipv6 = true
+		}
+	}
+
+	codeChan := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/callback", createCallbackHandler(codeChan, template.Must(template.New("page").Parse("ok"))))
+	server := newCallbackServer(hostFilter(hosts, mux), time.Second)
+	for _, listener := range listeners {
+		go server.Serve(listener)
+	}
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		// dial is the address connected to; host is the Host header the
+		// browser sends for the redirect URI.
+		dial   string
+		host   string
+		ipv6   bool
+		want   int
+		wantOK bool
+	}{
+		{name: "localhost", dial: "127.0.0.1", host: "localhost", want: http.StatusOK, wantOK: true},
+		{name: "localhost over IPv6", dial: "::1", host: "localhost", ipv6: true, want: http.StatusOK, wantOK: true},
+		{name: "IPv4 loopback", dial: "127.0.0.1", host: "127.0.0.1", want: http.StatusOK, wantOK: true},
+		{name: "IPv6 loopback", dial: "::1", host: "[::1]", ipv6: true, want: http.StatusOK, wantOK: true},
+		{name: "rebound DNS name", dial: "127.0.0.1", host: "attacker.example.com", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ipv6 && !ipv6 {
+				t.Skip("IPv6 loopback unavailable")
+			}
+			req, err := http.NewRequest(http.MethodGet, "http://"+net.JoinHostPort(tt.dial, port)+"/oauth/callback?code="+url.QueryEscape(tt.name), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = tt.host + ":" + port
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+
+			select {
+			case code := <-codeChan:
+				if !tt.wantOK || code != tt.name {
+					t.Errorf("code = %q, want %v", code, tt.wantOK)
+				}
+			default:
+				if tt.wantOK {
+					t.Error("callback did not receive the code")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/secrets"
+)
+
+// secretStore holds the client secret and refresh tokens when SECRET_STORE
+// is set. Without one, nothing outlives the process.
+var secretStore secrets.Store
+
+// SetSecretStore sets where the client secret and refresh tokens are kept;
+// nil disables both.
+func SetSecretStore(store secrets.Store) {
+	secretStore = store
+}
+
+// clientSecret prefers CLIENT_SECRET from .env and falls back to the secret
+// store, so the secret can be removed from the file.
+func clientSecret() string {
+	if config.ClientSecret != "" || secretStore == nil {
+		return config.ClientSecret
+	}
+	secret, err := secretStore.Get(secrets.ClientSecretName)
+	if err != nil {
+		if !errors.Is(err, secrets.ErrNotFound) {
+			fmt.Printf("Could not read the client secret: %v\n", err)
+		}
+		return ""
+	}
+	return secret
+}
+
+// saveRefreshToken keeps the refresh token from a browser sign-in, if the
+// connected app issued one, so the next start can sign in without it.
+func saveRefreshToken(token *models.TokenResponse) {
+	if secretStore == nil || token.RefreshToken == "" {
+		return
+	}
+	if err := secretStore.Set(secrets.RefreshTokenName(config.SFInstanceURL), token.RefreshToken); err != nil {
+		fmt.Printf("Could not save the refresh token: %v\n", err)
+	}
+}
+
+// refreshedToken trades the stored refresh token for a new access token. It
+// returns nil when there is none or it no longer works, and forgets a token
+// Salesforce rejected.
+func refreshedToken() *models.TokenResponse {
+	if secretStore == nil {
+		return nil
+	}
+	name := secrets.RefreshTokenName(config.SFInstanceURL)
+	refreshToken, err := secretStore.Get(name)
+	if err != nil {
+		if !errors.Is(err, secrets.ErrNotFound) {
+			fmt.Printf("Could not read the refresh token: %v\n", err)
+		}
+		return nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", config.ClientID)
+	form.Set("refresh_token", refreshToken)
+	if secret := clientSecret(); secret != "" {
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequest("POST", config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("Token refresh failed: %v\n", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	// Salesforce answers a revoked or expired refresh token with 400
+	// invalid_grant; anything else may be temporary, so the token is kept.
+	if resp.StatusCode == http.StatusBadRequest {
+		fmt.Println("Stored sign-in is no longer valid, signing in again")
+		secretStore.Delete(name)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("Token refresh failed: status %d\n", resp.StatusCode)
+		return nil
+	}
+
+	token, err := decodeTokenResponse(resp)
+	if err != nil {
+		fmt.Printf("Token refresh failed: %v\n", err)
+		return nil
+	}
+	return token
+}
+
+// forgetRefreshToken removes the stored refresh token for an org and
+// returns it, so that it can be revoked.
+func forgetRefreshToken(instanceURL string) string {
+	if secretStore == nil {
+		return ""
+	}
+	name := secrets.RefreshTokenName(instanceURL)
+	refreshToken, err := secretStore.Get(name)
+	if err != nil {
+		return ""
+	}
+	if err := secretStore.Delete(name); err != nil {
+		fmt.Printf("Could not remove the refresh token: %v\n", err)
+	}
+	return refreshToken
+}
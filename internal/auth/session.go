@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// sessionExpiryMargin keeps a token from being reused when it would expire
+// partway through a run.
+const sessionExpiryMargin = 5 * time.Minute
+
+// session holds the token from the last sign-in so later runs do not reopen
+// the browser. It only lives in memory and is tied to the org it came from.
+type session struct {
+	token       *models.TokenResponse
+	instanceURL string
+	expiresAt   time.Time
+}
+
+var (
+	current   session
+	sessionMu sync.Mutex
+)
+
+func (s session) valid(now time.Time) bool {
+	return s.token != nil &&
+		s.instanceURL == config.SFInstanceURL &&
+		now.Before(s.expiresAt.Add(-sessionExpiryMargin))
+}
+
+// Token returns the signed-in session's token while it is still valid for
+// the selected org. Otherwise it tries the stored refresh token, if any, and
+// authenticates in the browser when that fails.
+func Token() (*models.TokenResponse, error) {
+	sessionMu.Lock()
+	if current.valid(time.Now()) {
+		token := current.token
+		sessionMu.Unlock()
+		return token, nil
+	}
+	sessionMu.Unlock()
+
+	token := refreshedToken()
+	if token == nil {
+		var err error
+		token, err = Authenticate()
+		if err != nil {
+			return nil, err
+		}
+		saveRefreshToken(token)
+	}
+
+	expiresAt := sessionExpiry(token, time.Now())
+
+	sessionMu.Lock()
+	current = session{
+		token:       token,
+		instanceURL: config.SFInstanceURL,
+		expiresAt:   expiresAt,
+	}
+	sessionMu.Unlock()
+
+	return token, nil
+}
+
+// sessionExpiry returns when token stops working: expires_in when the token
+// response has one, otherwise the expiry Salesforce reports through token
+// introspection, and SESSION_TIMEOUT from now when neither is available.
+func sessionExpiry(token *models.TokenResponse, now time.Time) time.Time {
+	if token.ExpiresIn > 0 {
+		return now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	expiry, err := introspectExpiry(token.AccessToken)
+	if err != nil {
+		fmt.Printf("Could not read the token expiry, reusing it for %s: %v\n", config.SessionTimeout, err)
+		return now.Add(config.SessionTimeout)
+	}
+	return expiry
+}
+
+// introspectExpiry asks Salesforce when an access token expires. The
+// connected app must allow introspection, which public apps without a client
+// secret usually cannot.
+func introspectExpiry(accessToken string) (time.Time, error) {
+	form := url.Values{}
+	form.Set("token", accessToken)
+	form.Set("token_type_hint", "access_token")
+	form.Set("client_id", config.ClientID)
+	if secret := clientSecret(); secret != "" {
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequest("POST", config.SFInstanceURL+"/services/oauth2/introspect", strings.NewReader(form.Encode()))
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("token introspection failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("token introspection failed: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Active bool  `json:"active"`
+		Exp    int64 `json:"exp"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return time.Time{}, fmt.Errorf("error decoding introspection response: %v", err)
+	}
+	if !result.Active || result.Exp == 0 {
+		return time.Time{}, fmt.Errorf("token introspection reported no expiry")
+	}
+	return time.Unix(result.Exp, 0), nil
+}
+
+func SignedIn() bool {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return current.valid(time.Now())
+}
+
+// Invalidate forgets the session without revoking its token, for when
+// Salesforce has already expired it.
+func Invalidate() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	current = session{}
+}
+
+// SignOut forgets the session and any stored refresh token and asks
+// Salesforce to revoke them. The session is cleared even if the revoke
+// request fails.
+func SignOut() error {
+	sessionMu.Lock()
+	signedOut := current
+	current = session{}
+	sessionMu.Unlock()
+
+	instanceURL := signedOut.instanceURL
+	if instanceURL == "" {
+		instanceURL = config.SFInstanceURL
+	}
+	// Revoking a refresh token also revokes the access tokens issued from it.
+	if refreshToken := forgetRefreshToken(instanceURL); refreshToken != "" {
+		return revokeToken(instanceURL, refreshToken)
+	}
+	if signedOut.token == nil {
+		return nil
+	}
+	return revokeToken(signedOut.instanceURL, signedOut.token.AccessToken)
+}
+
+func revokeToken(instanceURL, token string) error {
+	form := url.Values{}
+	form.Set("token", token)
+
+	req, err := http.NewRequest("POST", instanceURL+"/services/oauth2/revoke", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to revoke token: status %d", resp.StatusCode)
+	}
+	return nil
+}
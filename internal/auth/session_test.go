@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// useTestOrg points the session's requests at handler for one test.
+func useTestOrg(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	previousClient, previousURL, previousTimeout := httpClient, config.SFInstanceURL, config.SessionTimeout
+	httpClient, config.SFInstanceURL, config.SessionTimeout = server.Client(), server.URL, 2*time.Hour
+	t.Cleanup(func() {
+		server.Close()
+		httpClient, config.SFInstanceURL, config.SessionTimeout = previousClient, previousURL, previousTimeout
+		current = session{}
+	})
+}
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name       string
+		expiresIn  int64
+		introspect func(w http.ResponseWriter)
+		want       time.Time
+		inspected  bool
+	}{
+		{
+			name:      "token response",
+			expiresIn: 900,
+			want:      now.Add(15 * time.Minute),
+		},
+		{
+			name: "introspection",
+			introspect: func(w http.ResponseWriter) {
+				json.NewEncoder(w).Encode(map[string]any{"active": true, "exp": now.Add(30 * time.Minute).Unix()})
+			},
+			want:      now.Add(30 * time.Minute),
+			inspected: true,
+		},
+		{
+			name:       "introspection not allowed",
+			introspect: func(w http.ResponseWriter) { w.WriteHeader(http.StatusUnauthorized) },
+			want:       now.Add(2 * time.Hour),
+			inspected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspected := false
+			useTestOrg(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/services/oauth2/introspect" || r.FormValue("token") != "access" {
+					http.NotFound(w, r)
+					return
+				}
+				inspected = true
+				tt.introspect(w)
+			})
+
+			token := &models.TokenResponse{AccessToken: "access", ExpiresIn: tt.expiresIn}
+			if got := sessionExpiry(token, now); !got.Equal(tt.want) {
+				t.Errorf("sessionExpiry() = %v, want %v", got, tt.want)
+			}
+			if inspected != tt.inspected {
+				t.Errorf("introspected = %v, want %v", inspected, tt.inspected)
+			}
+		})
+	}
+}
+
+func TestTokenReusesSessionUntilExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		reused    bool
+	}{
+		{name: "valid", expiresIn: time.Hour, reused: true},
+		{name: "expiring during a run", expiresIn: sessionExpiryMargin / 2},
+		{name: "expired", expiresIn: -time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestOrg(t, http.NotFound)
+
+			token := &models.TokenResponse{AccessToken: "access"}
+			current = session{token: token, instanceURL: config.SFInstanceURL, expiresAt: time.Now().Add(tt.expiresIn)}
+
+			if SignedIn() != tt.reused {
+				t.Fatalf("SignedIn() = %v, want %v", !tt.reused, tt.reused)
+			}
+			if !tt.reused {
+				return
+			}
+			got, err := Token()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != token {
+				t.Errorf("Token() = %+v, want the session's token", got)
+			}
+		})
+	}
+}
+
+func TestInstanceURLChangeRequiresSignIn(t *testing.T) {
+	useTestOrg(t, http.NotFound)
+	current = session{token: &models.TokenResponse{AccessToken: "access"}, instanceURL: config.SFInstanceURL, expiresAt: time.Now().Add(time.Hour)}
+	if !SignedIn() {
+		t.Fatal("SignedIn() = false for a fresh session")
+	}
+
+	previous := config.SFInstanceURL
+	defer func() { config.SFInstanceURL = previous }()
+	if err := config.SetInstanceURL("https://acme--uat.sandbox.my.salesforce.com"); err != nil {
+		t.Fatal(err)
+	}
+	if SignedIn() {
+		t.Error("SignedIn() = true after switching to another org")
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+//go:embed success.html
+var defaultSuccessPage string
+
+type successPageData struct {
+	Title     string
+	Message   string
+	AutoClose bool
+}
+
+// loadSuccessPage returns the page shown in the browser once the redirect
+// arrives: the template in CALLBACK_PAGE_TEMPLATE if set, otherwise the
+// built-in one. Both receive the same successPageData.
+func loadSuccessPage() (*template.Template, error) {
+	source := defaultSuccessPage
+	if config.CallbackPageTemplate != "" {
+		data, err := os.ReadFile(config.CallbackPageTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read callback page template: %v", err)
+		}
+		source = string(data)
+	}
+
+	page, err := template.New("success").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback page template: %v", err)
+	}
+	return page, nil
+}
+
+func currentSuccessPageData() successPageData {
+	return successPageData{
+		Title:     config.CallbackPageTitle,
+		Message:   config.CallbackPageMessage,
+		AutoClose: config.CallbackAutoClose,
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestCallbackServesSuccessPage(t *testing.T) {
+	custom := filepath.Join(t.TempDir(), "success.html")
+	if err := os.WriteFile(custom, []byte(`<h1>{{.Title}}</h1><p>{{.Message}}</p>{{if .AutoClose}}closing{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		autoClose bool
+		want      []string
+		wantNot   []string
+	}{
+		{
+			name:      "built-in page",
+			autoClose: true,
+			want:      []string{"<title>Acme Sign-in</title>", "<h3>Signed in to Acme</h3>", "window.close()", "You can close this tab"},
+		},
+		{
+			name:    "built-in page without auto-close",
+			want:    []string{"<h3>Signed in to Acme</h3>", "You can close this tab"},
+			wantNot: []string{"window.close()"},
+		},
+		{
+			name:      "custom template",
+			template:  custom,
+			autoClose: true,
+			want:      []string{"<h1>Acme Sign-in</h1><p>Signed in to Acme</p>closing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousTemplate, previousTitle := config.CallbackPageTemplate, config.CallbackPageTitle
+			previousMessage, previousAutoClose := config.CallbackPageMessage, config.CallbackAutoClose
+			config.CallbackPageTemplate, config.CallbackPageTitle = tt.template, "Acme Sign-in"
+			config.CallbackPageMessage, config.CallbackAutoClose = "Signed in to Acme", tt.autoClose
+			defer func() {
+				config.CallbackPageTemplate, config.CallbackPageTitle = previousTemplate, previousTitle
+				config.CallbackPageMessage, config.CallbackAutoClose = previousMessage, previousAutoClose
+			}()
+
+			page, err := loadSuccessPage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			createCallbackHandler(make(chan string, 1), page)(recorder, httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc", nil))
+
+			body := recorder.Body.String()
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("page does not contain %q:\n%s", want, body)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("page contains %q:\n%s", unwanted, body)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSuccessPageErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.html")
+	if err := os.WriteFile(invalid, []byte(`{{.Title`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{invalid, filepath.Join(t.TempDir(), "missing.html")} {
+		previous := config.CallbackPageTemplate
+		config.CallbackPageTemplate = path
+		if _, err := loadSuccessPage(); err == nil {
+			t.Errorf("loadSuccessPage() accepted %s", filepath.Base(path))
+		}
+		config.CallbackPageTemplate = previous
+	}
+}
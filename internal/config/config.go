@@ -2,19 +2,180 @@ package config
 
 import (
 	"embed"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
+type OrgEnvironment struct {
+	Name        string
+	InstanceURL string
+	ClientID    string
+	APIVersion  string
+}
+
+const DefaultEnvironmentName = "Default"
+
 var (
 	SFInstanceURL string
 	AuthURL       string
 	TokenURL      string
 	BulkLookupURL string
 	ClientID      string
+	ClientSecret  string
 	RedirectURI   string
 	Environment   string
+	APIVersion    string
 	envMap        map[string]string
+	// loadProblems collects what LoadEnv found wrong with the embedded .env,
+	// reported by Validate.
+	loadProblems []string
+
+	OrgEnvironments    []OrgEnvironment
+	CurrentEnvironment string
+
+	// DeriveAPIDomain rewrites a setup, Lightning or Visualforce instance
+	// URL to the org's my.salesforce.com API domain instead of only warning.
+	DeriveAPIDomain bool
+
+	CallbackTimeout time.Duration
+	CallbackHosts   []string
+	SessionTimeout  time.Duration
+	LoginTimeout    time.Duration
+
+	CallbackPageTemplate string
+	CallbackPageTitle    string
+	CallbackPageMessage  string
+	CallbackAutoClose    bool
+
+	ContentVersionDescription string
+	ContentVersionTags        string
+	ContentVersionFields      map[string]string
+	ContentVersionOrigin      string
+	SharingPrivacy            string
+
+	// ContentVersionExternalIDField names an external ID field on
+	// ContentVersion that identifies each upload, so re-runs reuse files
+	// already in the org instead of duplicating them.
+	ContentVersionExternalIDField string
+
+	ContentTypeValues map[string]string
+
+	MetadataCSV string
+
+	RunMode string
+
+	ValidateOrg bool
+
+	ContentLibraryID string
+
+	// RecordOwnerID is the user that owns the records a run creates, such
+	// as an integration user, instead of the signed-in user.
+	RecordOwnerID string
+
+	// RunIDField is an Attachments_Uploader__c text field that records the
+	// run that created each record. ResultsReportID is a report whose first
+	// filter is on that field, opened after a run to show its records.
+	RunIDField      string
+	ResultsReportID string
+
+	AttachmentMode string
+	LinkShareType  string
+	LinkVisibility string
+
+	AttachmentNameSource string
+
+	CollisionPolicy string
+
+	DuplicateTitlePolicy string
+
+	FollowSymlinks bool
+
+	ExcludePatterns string
+
+	LenientDocumentTypes bool
+
+	// MaxParseErrors is how many unparseable files validation reports before
+	// it gives up on the directory; 0 reports them all.
+	MaxParseErrors int
+
+	PreviewMaxDimension int
+
+	RollbackOnFailure bool
+
+	TruncateLongTitles bool
+
+	MaxFileSize        int64
+	SkipOversizedFiles bool
+
+	BinaryUploadThreshold int64
+
+	// TempDir is where large uploads are staged before they are sent; it
+	// defaults to the OS temp directory.
+	TempDir string
+
+	LookupCacheTTL     time.Duration
+	LookupCacheRefresh bool
+
+	VerifyHierarchy bool
+
+	// VerifyAfterUpload queries the attachment records of a run once it
+	// ends and reports any that are missing or unexpected.
+	VerifyAfterUpload bool
+
+	ParallelRequests int
+
+	AttachmentParallelRequests int
+	AttachmentOrdered          bool
+
+	HTTPTimeout time.Duration
+	ProxyURL    string
+	CACertFile  string
+
+	GzipRequests bool
+
+	// RedirectHosts are other hosts API calls may be redirected to. The
+	// Authorization header is not forwarded to them.
+	RedirectHosts []string
+
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// AutoResume resends a batch once a lost connection comes back. It is
+	// off by default: a batch whose response was lost after Salesforce
+	// committed it is created a second time, unless it can be found by
+	// ContentVersionExternalIDField first.
+	AutoResume        bool
+	AutoResumeTimeout time.Duration
+
+	// Per-phase timeouts end a run whose phase stalls; 0 means no limit.
+	// UploadTimeout includes linking content and DistributeTimeout.
+	LookupTimeout     time.Duration
+	UploadTimeout     time.Duration
+	DistributeTimeout time.Duration
+	AttachTimeout     time.Duration
+
+	LogRetentionDays  int
+	LogRetentionBytes int64
+
+	ControlAPIEnabled bool
+	ControlAPIAddr    string
+	ControlAPIToken   string
+
+	TracingEnabled bool
+	OTLPEndpoint   string
+
+	SecretStore string
+	SecretFile  string
+
+	UndetectedContentPolicy string
+
+	CompositeMaxBytes int64
 )
 
 const (
@@ -28,31 +189,420 @@ const (
 )
 
 const (
-	ContentTypeImage = "Image"
-	ContentTypePDF   = "PDF"
-	ContentTypeVideo = "Video"
+	RunModeUpload = "upload"
+	RunModeCSV    = "csv"
+)
+
+const (
+	AttachmentModeUploader = "uploader"
+	AttachmentModeLink     = "link"
+)
+
+const (
+	AttachmentNameEntityID     = "entity_id"
+	AttachmentNameDisplayValue = "display_value"
+	AttachmentNameFilename     = "filename"
+)
+
+const (
+	CollisionKeepBoth   = "keep_both"
+	CollisionKeepNewest = "keep_newest"
+	CollisionError      = "error"
+)
+
+const (
+	DuplicateTitleUploadAnyway = "upload_anyway"
+	DuplicateTitleWarn         = "warn"
+	DuplicateTitleSkip         = "skip"
+)
+
+const (
+	SecretStoreKeychain = "keychain"
+	SecretStoreFile     = "file"
+)
+
+const (
+	ContentTypeImage    = "Image"
+	ContentTypePDF      = "PDF"
+	ContentTypeVideo    = "Video"
+	ContentTypeDocument = "Document"
+)
+
+const (
+	UndetectedContentImage    = "image"
+	UndetectedContentDocument = "document"
+	UndetectedContentFail     = "fail"
 )
 
 func LoadEnv(configFS embed.FS) {
 	envMap = make(map[string]string)
+	loadProblems = nil
 
 	embeddedEnv, err := configFS.ReadFile(".env")
 	if err != nil {
-		log.Fatal("Error loading embedded .env file", err)
+		loadProblems = append(loadProblems, fmt.Sprintf("the embedded .env file could not be read: %v", err))
 	}
 	parseEnvFile(string(embeddedEnv), envMap)
 
 	SFInstanceURL = getEnv("SF_INSTANCE_URL")
 	ClientID = getEnv("CLIENT_ID")
+	ClientSecret = getEnvOrDefault("CLIENT_SECRET", "")
 	RedirectURI = getEnv("REDIRECT_URI")
 	Environment = getEnv("ENV")
+	APIVersion = getEnvOrDefault("API_VERSION", "v57.0")
+	DeriveAPIDomain = getBoolEnv("DERIVE_API_DOMAIN", false)
+	SFInstanceURL = checkAPIDomain(SFInstanceURL)
+
+	CallbackTimeout = getDurationEnv("CALLBACK_TIMEOUT", 10*time.Second)
+	CallbackHosts = getListEnv("CALLBACK_HOSTS", "localhost,127.0.0.1,::1")
+	LoginTimeout = getDurationEnv("LOGIN_TIMEOUT", 5*time.Minute)
+	SessionTimeout = getDurationEnv("SESSION_TIMEOUT", 2*time.Hour)
+
+	CallbackPageTemplate = getEnvOrDefault("CALLBACK_PAGE_TEMPLATE", "")
+	CallbackPageTitle = getEnvOrDefault("CALLBACK_PAGE_TITLE", "Authentication Successful")
+	CallbackPageMessage = getEnvOrDefault("CALLBACK_PAGE_MESSAGE", "Authentication successful!")
+	CallbackAutoClose = getBoolEnv("CALLBACK_AUTO_CLOSE", true)
+
+	ContentVersionDescription = getEnvOrDefault("CONTENT_VERSION_DESCRIPTION", "")
+	ContentVersionTags = getEnvOrDefault("CONTENT_VERSION_TAGS", "")
+	ContentVersionFields = getMapEnv("CONTENT_VERSION_FIELDS")
+	ContentVersionExternalIDField = getEnvOrDefault("CONTENT_VERSION_EXTERNAL_ID_FIELD", "")
+	ContentVersionOrigin = strings.ToUpper(getEnvOrDefault("CONTENT_VERSION_ORIGIN", ""))
+	SharingPrivacy = strings.ToUpper(getEnvOrDefault("CONTENT_VERSION_SHARING_PRIVACY", ""))
+
+	// e.g. "Image=Photo;PDF=Document" when Content_Type__c uses other labels
+	ContentTypeValues = getMapEnv("CONTENT_TYPE_VALUES")
+
+	MetadataCSV = getEnvOrDefault("METADATA_CSV", "")
+
+	RunMode = strings.ToLower(getEnvOrDefault("RUN_MODE", RunModeUpload))
+
+	ValidateOrg = getBoolEnv("VALIDATE_ORG", false)
+
+	ContentLibraryID = getEnvOrDefault("CONTENT_LIBRARY_ID", "")
+
+	RecordOwnerID = getEnvOrDefault("RECORD_OWNER_ID", "")
+
+	RunIDField = getEnvOrDefault("RUN_ID_FIELD", "")
+	ResultsReportID = getEnvOrDefault("RESULTS_REPORT_ID", "")
+
+	AttachmentMode = strings.ToLower(getEnvOrDefault("ATTACHMENT_MODE", AttachmentModeUploader))
+	AttachmentNameSource = strings.ToLower(getEnvOrDefault("ATTACHMENT_NAME_SOURCE", AttachmentNameEntityID))
+	LinkShareType = getEnvOrDefault("LINK_SHARE_TYPE", "V")
+	LinkVisibility = getEnvOrDefault("LINK_VISIBILITY", "AllUsers")
+
+	CollisionPolicy = strings.ToLower(getEnvOrDefault("COLLISION_POLICY", CollisionKeepBoth))
+
+	DuplicateTitlePolicy = strings.ToLower(getEnvOrDefault("DUPLICATE_TITLE_POLICY", DuplicateTitleUploadAnyway))
+
+	FollowSymlinks = getBoolEnv("FOLLOW_SYMLINKS", false)
+
+	ExcludePatterns = getEnvOrDefault("EXCLUDE_PATTERNS", "")
+
+	LenientDocumentTypes = getBoolEnv("LENIENT_DOCUMENT_TYPES", false)
+
+	MaxParseErrors = getIntEnv("MAX_PARSE_ERRORS", 100)
+
+	PreviewMaxDimension = getIntEnv("PREVIEW_MAX_DIMENSION", 0)
+
+	RollbackOnFailure = getBoolEnv("ROLLBACK_ON_FAILURE", false)
+
+	TruncateLongTitles = getBoolEnv("TRUNCATE_LONG_TITLES", false)
+
+	MaxFileSize = int64(getIntEnv("MAX_FILE_SIZE_MB", 2048)) << 20
+	SkipOversizedFiles = getBoolEnv("SKIP_OVERSIZED_FILES", false)
+
+	BinaryUploadThreshold = int64(getIntEnv("BINARY_UPLOAD_THRESHOLD_MB", 10)) << 20
+	CompositeMaxBytes = int64(getIntEnv("COMPOSITE_MAX_MB", 30)) << 20
+	TempDir = getEnvOrDefault("TEMP_DIR", os.TempDir())
+
+	LookupCacheTTL = getDurationEnv("LOOKUP_CACHE_TTL", 0)
+	LookupCacheRefresh = getBoolEnv("LOOKUP_CACHE_REFRESH", false)
+
+	VerifyHierarchy = getBoolEnv("VERIFY_HIERARCHY", false)
+	VerifyAfterUpload = getBoolEnv("VERIFY_AFTER_UPLOAD", false)
+
+	ParallelRequests = getIntEnv("PARALLEL_REQUESTS", 4)
+
+	AttachmentParallelRequests = getIntEnv("ATTACHMENT_PARALLEL_REQUESTS", 0)
+	AttachmentOrdered = getBoolEnv("ATTACHMENT_ORDERED", false)
+
+	HTTPTimeout = getDurationEnv("HTTP_TIMEOUT", 5*time.Minute)
+	ProxyURL = getEnvOrDefault("PROXY_URL", "")
+	CACertFile = getEnvOrDefault("CA_CERT_FILE", "")
+
+	GzipRequests = getBoolEnv("GZIP_REQUESTS", false)
+	RedirectHosts = getListEnv("REDIRECT_ALLOWED_HOSTS", "")
+
+	CircuitBreakerThreshold = getIntEnv("CIRCUIT_BREAKER_THRESHOLD", 5)
+	CircuitBreakerCooldown = getDurationEnv("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second)
+
+	AutoResume = getBoolEnv("AUTO_RESUME", false)
+	AutoResumeTimeout = getDurationEnv("AUTO_RESUME_TIMEOUT", 15*time.Minute)
+
+	LookupTimeout = getDurationEnv("LOOKUP_TIMEOUT", 0)
+	UploadTimeout = getDurationEnv("UPLOAD_TIMEOUT", 0)
+	DistributeTimeout = getDurationEnv("DISTRIBUTE_TIMEOUT", 0)
+	AttachTimeout = getDurationEnv("ATTACH_TIMEOUT", 0)
+
+	LogRetentionDays = getIntEnv("LOG_RETENTION_DAYS", 90)
+	LogRetentionBytes = int64(getIntEnv("LOG_RETENTION_MB", 1024)) << 20
+
+	ControlAPIEnabled = getBoolEnv("CONTROL_API_ENABLED", false)
+	ControlAPIAddr = getEnvOrDefault("CONTROL_API_ADDR", "127.0.0.1:8765")
+	ControlAPIToken = getEnvOrDefault("CONTROL_API_TOKEN", "")
 
+	TracingEnabled = getBoolEnv("TRACING_ENABLED", false)
+	OTLPEndpoint = getEnvOrDefault("OTLP_ENDPOINT", "http://localhost:4318")
+
+	SecretStore = strings.ToLower(getEnvOrDefault("SECRET_STORE", ""))
+	SecretFile = getEnvOrDefault("SECRET_FILE", defaultSecretFile())
+
+	UndetectedContentPolicy = strings.ToLower(getEnvOrDefault("UNDETECTED_CONTENT_POLICY", UndetectedContentImage))
+
+	OrgEnvironments = loadOrgEnvironments()
+	CurrentEnvironment = DefaultEnvironmentName
+	deriveURLs()
+}
+
+// defaultSecretFile keeps the file-backed secret store in the user's config
+// directory, away from the documents and the app's own files.
+func defaultSecretFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "secrets.json"
+	}
+	return filepath.Join(dir, "document-uploader", "secrets.json")
+}
+
+func deriveURLs() {
 	AuthURL = SFInstanceURL + "/services/oauth2/authorize"
 	TokenURL = SFInstanceURL + "/services/oauth2/token"
 	BulkLookupURL = SFInstanceURL + "/services/apexrest/admin/bulk-lookup"
 }
 
+// Validate reports required settings that are missing, empty or malformed
+// in the embedded .env, all at once, so a bad build can be diagnosed without
+// a console.
+func Validate() error {
+	problems := append([]string(nil), loadProblems...)
+	if SFInstanceURL != "" {
+		if _, err := normalizeInstanceURL(SFInstanceURL); err != nil {
+			problems = append(problems, "SF_INSTANCE_URL: "+err.Error())
+		}
+	}
+	if RedirectURI != "" {
+		parsed, err := url.Parse(RedirectURI)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("REDIRECT_URI %q is not an http(s) URL, e.g. http://localhost:8080/oauth/callback", RedirectURI))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("the configuration built into this copy is incomplete:\n- %s\nRebuild it with a complete .env file",
+			strings.Join(problems, "\n- "))
+	}
+	return nil
+}
+
+// SetInstanceURL points the current session at another org, such as a
+// sandbox, without editing .env. The override is not saved; selecting an
+// environment or restarting the app replaces it.
+func SetInstanceURL(raw string) error {
+	instanceURL, err := normalizeInstanceURL(raw)
+	if err != nil {
+		return err
+	}
+	SFInstanceURL = checkAPIDomain(instanceURL)
+	deriveURLs()
+	return nil
+}
+
+// normalizeInstanceURL accepts an https origin such as
+// https://acme--uat.sandbox.my.salesforce.com, with or without a trailing
+// slash, and rejects anything carrying a path, query or credentials.
+func normalizeInstanceURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid instance URL %q: %v", raw, err)
+	}
+	if parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return "", fmt.Errorf("invalid instance URL %q: expected https://<my-domain>.my.salesforce.com", raw)
+	}
+	if strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+		return "", fmt.Errorf("invalid instance URL %q: it must not include a path, query or credentials", raw)
+	}
+	return "https://" + parsed.Host, nil
+}
+
+// checkAPIDomain warns when an instance URL points at a domain meant for the
+// browser rather than the API, such as the salesforce-setup.com domain
+// Setup links to, and rewrites it to the my.salesforce.com domain when
+// DeriveAPIDomain is set. API calls against those domains are redirected or
+// rejected.
+func checkAPIDomain(instanceURL string) string {
+	parsed, err := url.Parse(instanceURL)
+	if err != nil || parsed.Host == "" {
+		return instanceURL
+	}
+	host, ok := apiDomain(parsed.Hostname())
+	if !ok {
+		return instanceURL
+	}
+	if !DeriveAPIDomain {
+		log.Printf("Warning: %s is not an API domain; use https://%s as the instance URL", parsed.Hostname(), host)
+		return instanceURL
+	}
+	log.Printf("Using the API domain %s instead of %s", host, parsed.Hostname())
+	if port := parsed.Port(); port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+	return parsed.String()
+}
+
+// browserDomains maps the suffixes of My Domain hostnames used by the
+// browser to the API suffix, longest first so sandbox forms match before
+// production ones.
+var browserDomains = []struct {
+	suffix    string
+	apiSuffix string
+}{
+	{".sandbox.my.salesforce-setup.com", ".sandbox.my.salesforce.com"},
+	{".sandbox.lightning.force.com", ".sandbox.my.salesforce.com"},
+	{".sandbox.vf.force.com", ".sandbox.my.salesforce.com"},
+	{".my.salesforce-setup.com", ".my.salesforce.com"},
+	{".lightning.force.com", ".my.salesforce.com"},
+	{".vf.force.com", ".my.salesforce.com"},
+	{".visualforce.com", ".my.salesforce.com"},
+}
+
+// apiDomain returns the my.salesforce.com host for a setup, Lightning or
+// Visualforce host of the same My Domain, e.g. acme.my.salesforce.com for
+// acme.my.salesforce-setup.com or acme--c.vf.force.com. It reports false for
+// any other host.
+func apiDomain(host string) (string, bool) {
+	host = strings.ToLower(host)
+	for _, domain := range browserDomains {
+		name, ok := strings.CutSuffix(host, domain.suffix)
+		if !ok || name == "" || strings.Contains(name, ".") {
+			continue
+		}
+		// Visualforce hosts add a package namespace, "--c" for the org's
+		// own pages.
+		if strings.Contains(domain.suffix, "vf.") || strings.Contains(domain.suffix, "visualforce") {
+			if i := strings.LastIndex(name, "--"); i > 0 {
+				name = name[:i]
+			}
+		}
+		return name + domain.apiSuffix, true
+	}
+	return "", false
+}
+
+// DataPath returns a REST API path for the configured API version, in the
+// form expected by composite subrequests.
+func DataPath(path string) string {
+	return "/services/data/" + APIVersion + path
+}
+
+func DataURL(path string) string {
+	return SFInstanceURL + DataPath(path)
+}
+
+// loadOrgEnvironments reads the named environments listed in ENVIRONMENTS.
+// Each name maps to ENV_<NAME>_SF_INSTANCE_URL, ENV_<NAME>_CLIENT_ID and
+// ENV_<NAME>_API_VERSION, falling back to the top-level values.
+func loadOrgEnvironments() []OrgEnvironment {
+	environments := []OrgEnvironment{{
+		Name:        DefaultEnvironmentName,
+		InstanceURL: SFInstanceURL,
+		ClientID:    ClientID,
+		APIVersion:  APIVersion,
+	}}
+
+	for _, name := range strings.Split(getEnvOrDefault("ENVIRONMENTS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == DefaultEnvironmentName {
+			continue
+		}
+
+		prefix := "ENV_" + strings.ToUpper(name) + "_"
+		instanceURL := getEnvOrDefault(prefix+"SF_INSTANCE_URL", "")
+		if instanceURL == "" {
+			log.Printf("Skipping environment %s: %sSF_INSTANCE_URL is not set", name, prefix)
+			continue
+		}
+
+		environments = append(environments, OrgEnvironment{
+			Name:        name,
+			InstanceURL: checkAPIDomain(instanceURL),
+			ClientID:    getEnvOrDefault(prefix+"CLIENT_ID", ClientID),
+			APIVersion:  getEnvOrDefault(prefix+"API_VERSION", APIVersion),
+		})
+	}
+
+	return environments
+}
+
+func SelectEnvironment(name string) error {
+	for _, environment := range OrgEnvironments {
+		if environment.Name != name {
+			continue
+		}
+		SFInstanceURL = environment.InstanceURL
+		ClientID = environment.ClientID
+		APIVersion = environment.APIVersion
+		CurrentEnvironment = environment.Name
+		deriveURLs()
+		return nil
+	}
+	return fmt.Errorf("unknown environment: %s", name)
+}
+
+func EnvironmentNames() []string {
+	names := make([]string, 0, len(OrgEnvironments))
+	for _, environment := range OrgEnvironments {
+		names = append(names, environment.Name)
+	}
+	return names
+}
+
+// RedactedSettings returns the .env settings with the selected org filled
+// in, for a diagnostics bundle. Values of keys naming a secret, token,
+// password or key are replaced, as are credentials inside URLs.
+func RedactedSettings() map[string]string {
+	settings := make(map[string]string, len(envMap)+2)
+	for key, value := range envMap {
+		settings[key] = redactSetting(key, value)
+	}
+	settings["SF_INSTANCE_URL"] = SFInstanceURL
+	settings["SELECTED_ENVIRONMENT"] = CurrentEnvironment
+	return settings
+}
+
+func redactSetting(key, value string) string {
+	if value == "" {
+		return value
+	}
+	upper := strings.ToUpper(key)
+	for _, marker := range []string{"SECRET", "TOKEN", "PASSWORD", "KEY"} {
+		if strings.Contains(upper, marker) {
+			return "[redacted]"
+		}
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		parsed.User = url.User("redacted")
+		return parsed.String()
+	}
+	return value
+}
+
 func parseEnvFile(content string, envMap map[string]string) {
+	// Notepad saves UTF-8 files with a BOM, which would otherwise end up in
+	// the first key's name.
+	content = strings.TrimPrefix(content, "\uFEFF")
+
 	lines := strings.Split(content, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -74,14 +624,83 @@ func parseEnvFile(content string, envMap map[string]string) {
 	}
 }
 
+// getEnv returns a required setting. A missing or empty one is recorded for
+// Validate rather than ending the process, so the GUI can say what is wrong.
 func getEnv(key string) string {
-	if value, exists := envMap[key]; exists {
+	if value := envMap[key]; value != "" {
 		return value
 	}
-	log.Fatal("Error loading env: ", key)
+	loadProblems = append(loadProblems, key+" is missing or empty")
 	return ""
 }
 
+func getEnvOrDefault(key, fallback string) string {
+	if value, exists := envMap[key]; exists && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value := getEnvOrDefault(key, "")
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid duration for %s: %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return duration
+}
+
+func getIntEnv(key string, fallback int) int {
+	value := getEnvOrDefault(key, "")
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid number for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return number
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	switch strings.ToLower(getEnvOrDefault(key, "")) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return fallback
+	}
+}
+
+func getListEnv(key, fallback string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnvOrDefault(key, fallback), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getMapEnv parses values of the form "Field__c=value;Other__c=value".
+func getMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(getEnvOrDefault(key, ""), ";") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return result
+}
+
 func IsDevelopment() bool {
 	return Environment == "development"
 }
@@ -0,0 +1,212 @@
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+// Runs are started in the background, so every request is short; the
+// limits only keep a stuck or idle client from holding a connection.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = time.Minute
+	idleTimeout       = 2 * time.Minute
+	maxHeaderBytes    = 16 << 10
+)
+
+const (
+	StateIdle      = "idle"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// RunRequest is the body of POST /runs.
+type RunRequest struct {
+	Directory string `json:"directory"`
+	Subtree   string `json:"subtree,omitempty"`
+	Exclude   string `json:"exclude,omitempty"`
+	// DocumentType restricts the run to one type, e.g. "Gallery".
+	DocumentType string `json:"documentType,omitempty"`
+}
+
+// Runner performs an upload and returns the URL to view its records.
+type Runner func(request RunRequest) (resultsURL string, err error)
+
+// Status is what GET /status returns. Progress follows whichever run is
+// active, including one started from the GUI.
+type Status struct {
+	State      string         `json:"state"`
+	Directory  string         `json:"directory,omitempty"`
+	StartedAt  *time.Time     `json:"startedAt,omitempty"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	ResultsURL string         `json:"resultsUrl,omitempty"`
+	Progress   progress.Event `json:"progress"`
+}
+
+// Server is a local HTTP API that lets other tools on the machine start
+// uploads and follow their progress. Every request must carry the configured
+// token as a bearer token.
+type Server struct {
+	token      string
+	run        Runner
+	lastReport func() (string, error)
+
+	mutex  sync.Mutex
+	status Status
+}
+
+// NewServer returns a server that starts runs with run and serves the file
+// returned by lastReport from GET /report.
+func NewServer(token string, run Runner, lastReport func() (string, error)) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("CONTROL_API_TOKEN must be set to enable the control API")
+	}
+	return &Server{
+		token:      token,
+		run:        run,
+		lastReport: lastReport,
+		status:     Status{State: StateIdle},
+	}, nil
+}
+
+// Track keeps the latest progress event for GET /status until the channel
+// is closed.
+func (s *Server) Track(events <-chan progress.Event) {
+	for event := range events {
+		s.mutex.Lock()
+		s.status.Progress = event
+		s.mutex.Unlock()
+	}
+}
+
+// ListenAndServe serves the API on addr, which must be a loopback address.
+func (s *Server) ListenAndServe(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid control API address %q: %v", addr, err)
+	}
+	if ip := net.ParseIP(host); !strings.EqualFold(host, "localhost") && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("control API address %q is not a loopback address", addr)
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+	return server.ListenAndServe()
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /runs", s.handleStartRun)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /report", s.handleReport)
+	return s.authorize(mux)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var request RunRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(request.Directory) == "" {
+		writeError(w, http.StatusBadRequest, "directory is required")
+		return
+	}
+
+	s.mutex.Lock()
+	if s.status.State == StateRunning {
+		s.mutex.Unlock()
+		writeError(w, http.StatusConflict, "a run is already in progress")
+		return
+	}
+	started := time.Now()
+	s.status = Status{State: StateRunning, Directory: request.Directory, StartedAt: &started}
+	status := s.status
+	s.mutex.Unlock()
+
+	go s.execute(request)
+
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+func (s *Server) execute(request RunRequest) {
+	resultsURL, err := s.run(request)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	finished := time.Now()
+	s.status.FinishedAt = &finished
+	if err != nil {
+		s.status.State = StateFailed
+		s.status.Error = err.Error()
+		return
+	}
+	s.status.State = StateSucceeded
+	s.status.ResultsURL = resultsURL
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	status := s.status
+	s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	path, err := s.lastReport()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if path == "" {
+		writeError(w, http.StatusNotFound, "no run report yet")
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read run report: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,106 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerRequiresToken(t *testing.T) {
+	server, err := NewServer("secret", func(RunRequest) (string, error) { return "", nil }, func() (string, error) { return "", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", "secret", http.StatusUnauthorized},
+		{"valid", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, req)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerRunLifecycle(t *testing.T) {
+	release := make(chan struct{})
+	server, _ := NewServer("secret", func(request RunRequest) (string, error) {
+		<-release
+		if request.Directory == "/fail" {
+			return "", errors.New("upload failed")
+		}
+		return "https://acme.my.salesforce.com/lightning/r/Report/00O/view", nil
+	}, func() (string, error) { return "", nil })
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+	status := func() Status {
+		var status Status
+		json.NewDecoder(send(http.MethodGet, "/status", "").Body).Decode(&status)
+		return status
+	}
+
+	if got := send(http.MethodPost, "/runs", `{}`).Code; got != http.StatusBadRequest {
+		t.Errorf("run without directory: status %d, want 400", got)
+	}
+	if got := send(http.MethodPost, "/runs", `{"directory":"/docs"}`).Code; got != http.StatusAccepted {
+		t.Fatalf("start run: status %d, want 202", got)
+	}
+	if got := send(http.MethodPost, "/runs", `{"directory":"/docs"}`).Code; got != http.StatusConflict {
+		t.Errorf("second run: status %d, want 409", got)
+	}
+	if got := status().State; got != StateRunning {
+		t.Errorf("state = %s, want %s", got, StateRunning)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for status().State == StateRunning && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := status(); got.State != StateSucceeded || got.ResultsURL == "" {
+		t.Errorf("status = %+v, want succeeded with a results URL", got)
+	}
+
+	send(http.MethodPost, "/runs", `{"directory":"/fail"}`)
+	deadline = time.Now().Add(time.Second)
+	for status().State == StateRunning && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := status(); got.State != StateFailed || got.Error != "upload failed" {
+		t.Errorf("status = %+v, want failed", got)
+	}
+}
+
+func TestListenAndServeRejectsNonLoopback(t *testing.T) {
+	server, _ := NewServer("secret", nil, nil)
+	for _, addr := range []string{"0.0.0.0:8765", "192.168.1.10:8765", "example.com:8765", "no-port"} {
+		if err := server.ListenAndServe(addr); err == nil {
+			t.Errorf("ListenAndServe(%q) accepted a non-loopback address", addr)
+		}
+	}
+}
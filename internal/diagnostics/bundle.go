@@ -0,0 +1,113 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"time"
+)
+
+// redactions match secrets that can end up in a log or report: bearer
+// tokens and Salesforce session IDs, which start with the 15 or 18 character
+// org ID followed by "!".
+var redactions = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)\S+`),
+	regexp.MustCompile(`\b00D[0-9A-Za-z]{12,15}![0-9A-Za-z._-]+`),
+	regexp.MustCompile(`(?i)("?(?:access_token|refresh_token|client_secret)"?\s*[:=]\s*"?)[^"&\s,]+`),
+}
+
+const redacted = "[redacted]"
+
+// Bundle collects what support needs to look into a problem. Empty paths are
+// left out of the archive.
+type Bundle struct {
+	LogPath    string
+	ReportPath string
+	// Settings is the effective configuration, already redacted.
+	Settings map[string]string
+	Version  string
+}
+
+// Write zips the bundle to path: the log, the run report, the settings as
+// settings.json and the environment as environment.json. Secrets in the log
+// and report are redacted on the way in.
+func (b *Bundle) Write(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating diagnostics bundle: %v", err)
+	}
+	archive := zip.NewWriter(file)
+
+	err = b.writeEntries(archive)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("error writing diagnostics bundle: %v", err)
+	}
+	return nil
+}
+
+func (b *Bundle) writeEntries(archive *zip.Writer) error {
+	for _, source := range []string{b.LogPath, b.ReportPath} {
+		if source == "" {
+			continue
+		}
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		if err := addEntry(archive, filepath.Base(source), Redact(data)); err != nil {
+			return err
+		}
+	}
+
+	settings, err := json.MarshalIndent(b.Settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := addEntry(archive, "settings.json", settings); err != nil {
+		return err
+	}
+
+	environment, err := json.MarshalIndent(map[string]string{
+		"version":   b.Version,
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+		"goVersion": runtime.Version(),
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return addEntry(archive, "environment.json", environment)
+}
+
+func addEntry(archive *zip.Writer, name string, data []byte) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
+// Redact blanks out tokens and secrets in text.
+func Redact(data []byte) []byte {
+	for _, pattern := range redactions {
+		if pattern.NumSubexp() > 0 {
+			data = pattern.ReplaceAll(data, []byte("${1}"+redacted))
+		} else {
+			data = pattern.ReplaceAll(data, []byte(redacted))
+		}
+	}
+	return data
+}
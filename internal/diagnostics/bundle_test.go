@@ -0,0 +1,122 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBundleWrite(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app_2026-10-15.log")
+	reportPath := filepath.Join(dir, "run_20261015.json")
+	log := "[INFO] Authorization: Bearer 00D5g000004ABCD!AQ8AQ.token\n" +
+		"[DEBUG] token response {\"access_token\":\"abc123\",\"refresh_token\":\"5Aep861\"}\n" +
+		"[INFO] Uploaded bl_front.jpg\n"
+	report := `{"runId":"20261015","error":"session 00D5g000004ABCDEFG!AQ8AQ.expired rejected"}`
+	if err := os.WriteFile(logPath, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &Bundle{
+		LogPath:    logPath,
+		ReportPath: reportPath,
+		Settings:   map[string]string{"SF_CLIENT_SECRET": "[redacted]", "SF_CLIENT_ID": "3MVG9public"},
+		Version:    "1.4.0",
+	}
+	path := filepath.Join(dir, "diagnostics.zip")
+	if err := bundle.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readBundle(t, path)
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"app_2026-10-15.log", "environment.json", "run_20261015.json", "settings.json"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("bundle entries = %v, want %v", names, want)
+	}
+
+	for name, data := range entries {
+		for _, secret := range []string{"00D5g000004ABCD", "abc123", "5Aep861", "AQ8AQ"} {
+			if strings.Contains(data, secret) {
+				t.Errorf("%s contains %q:\n%s", name, secret, data)
+			}
+		}
+	}
+	if !strings.Contains(entries["app_2026-10-15.log"], "Uploaded bl_front.jpg") {
+		t.Errorf("log lost its other lines:\n%s", entries["app_2026-10-15.log"])
+	}
+
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(entries["settings.json"]), &settings); err != nil || settings["SF_CLIENT_ID"] != "3MVG9public" {
+		t.Errorf("settings.json = %s, %v", entries["settings.json"], err)
+	}
+	var environment map[string]string
+	if err := json.Unmarshal([]byte(entries["environment.json"]), &environment); err != nil {
+		t.Fatal(err)
+	}
+	if environment["version"] != "1.4.0" || environment["os"] != runtime.GOOS {
+		t.Errorf("environment.json = %v", environment)
+	}
+}
+
+func TestBundleWithoutReport(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("[INFO] started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "diagnostics.zip")
+	if err := (&Bundle{LogPath: logPath}).Write(path); err != nil {
+		t.Fatal(err)
+	}
+	if entries := readBundle(t, path); len(entries) != 3 {
+		t.Errorf("bundle has %d entries, want the log, settings and environment", len(entries))
+	}
+
+	missing := filepath.Join(dir, "missing.zip")
+	if err := (&Bundle{LogPath: filepath.Join(dir, "gone.log")}).Write(missing); err == nil {
+		t.Error("Write() with a missing log succeeded")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("a failed Write() left a partial bundle behind")
+	}
+}
+
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	entries := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = string(data)
+	}
+	return entries
+}
@@ -0,0 +1,51 @@
+package filestructure
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// appDirs are the folders the uploader creates in its working directory.
+var appDirs = []string{"logs", "reports", "cache", "exports"}
+
+// AppPaths returns the uploader's own folders and executable, which must never
+// be treated as documents.
+func AppPaths() []string {
+	var paths []string
+	if cwd, err := os.Getwd(); err == nil {
+		for _, dir := range appDirs {
+			paths = append(paths, filepath.Join(cwd, dir))
+		}
+	}
+	if executable, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+			executable = resolved
+		}
+		paths = append(paths, executable)
+	}
+	return paths
+}
+
+// CheckAppDirectory returns a warning when the selected directory is, or
+// contains, the uploader's own files.
+func CheckAppDirectory(documentsDir string, appPaths []string) string {
+	documentsDir = filepath.Clean(documentsDir)
+
+	var found []string
+	for _, path := range appPaths {
+		if path == documentsDir || isWithin(documentsDir, path) {
+			found = append(found, path)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	return "the selected folder contains the uploader's own files (" + strings.Join(found, ", ") +
+		"); they will be skipped, but this is probably the wrong folder"
+}
+
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,52 @@
+package filestructure
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelectingAppDirectory(t *testing.T) {
+	dir := t.TempDir()
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	logs := filepath.Join(dir, "logs")
+	executable := filepath.Join(dir, "Tower", "document-uploader")
+	for _, path := range []string{building, logs} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(building, "bl_front.jpg"): "jpg",
+		filepath.Join(logs, "uploader.log"):     "log",
+		executable:                              "binary",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appPaths := []string{logs, filepath.Join(dir, "reports"), executable}
+
+	warning := CheckAppDirectory(dir, appPaths)
+	if !strings.Contains(warning, logs) || !strings.Contains(warning, executable) {
+		t.Errorf("CheckAppDirectory() = %q, want the logs folder and executable named", warning)
+	}
+	if warning := CheckAppDirectory(building, appPaths); warning != "" {
+		t.Errorf("CheckAppDirectory() for a documents folder = %q, want none", warning)
+	}
+	if warning := CheckAppDirectory(logs, appPaths); warning == "" {
+		t.Error("CheckAppDirectory() for the logs folder itself gave no warning")
+	}
+
+	walker := NewDocumentWalker(dir)
+	walker.SetSkipPaths(appPaths)
+	documents, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if len(documents) != 1 || documents[0].RelativePath != filepath.Join("Tower", "P1", "Z1", "B1", "bl_front.jpg") {
+		t.Errorf("Walk() = %+v, want only the building file", documents)
+	}
+}
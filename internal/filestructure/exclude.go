@@ -0,0 +1,49 @@
+package filestructure
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ParseExcludePatterns splits a comma-separated list of glob patterns such as
+// "*_draft.*, WIP/*" and rejects malformed ones up front.
+func ParseExcludePatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.Trim(strings.ReplaceAll(strings.TrimSpace(pattern), "\\", "/"), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// excluded reports whether a file matches one of the patterns. A pattern
+// without a slash matches the name of the file or of any folder above it; a
+// pattern with a slash matches from the selected directory, so "WIP/*"
+// excludes everything under the top-level WIP folder.
+func excluded(patterns []string, relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			for _, part := range parts {
+				if matched, _ := path.Match(pattern, part); matched {
+					return true
+				}
+			}
+			continue
+		}
+		for i := range parts {
+			if matched, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package filestructure
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExcludePatterns(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{list: "", want: nil},
+		{list: "*_draft.*, WIP/*", want: []string{"*_draft.*", "WIP/*"}},
+		{list: ` /WIP/ ,\Old\*, ,`, want: []string{"WIP", "Old/*"}},
+		{list: "[a-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := ParseExcludePatterns(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExcludePatterns(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseExcludePatterns(%q) = %q, want %q", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	patterns := []string{"*_draft.*", "WIP/*", "Archive"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"P/Ph/Z/B1/bl_front.jpg", false},
+		{"P/Ph/Z/B1/bl_front_draft.jpg", true},
+		{"WIP/Ph/bl_front.jpg", true},
+		{"P/WIP/bl_front.jpg", false},
+		{"P/Archive/Z/bl_front.jpg", true},
+		{"P/Archived/Z/bl_front.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := excluded(patterns, tt.path); got != tt.want {
+			t.Errorf("excluded(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
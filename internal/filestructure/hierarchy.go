@@ -0,0 +1,174 @@
+package filestructure
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EntityHierarchy describes one level of the entity tree. The walker's
+// folder layout, lookups, parent checks, attachment fields, display values,
+// entity paths and flat file names are all derived from Hierarchy, so
+// supporting a new level only needs a new entry. SObject and ParentField
+// name the level's record type and its lookup to the parent record, used to
+// verify resolved IDs.
+type EntityHierarchy struct {
+	Type        string
+	Parent      string
+	IDKey       string
+	NameKey     string
+	Label       string
+	LookupField string
+	FileCode    string
+	SObject     string
+	ParentField string
+	// Folder, when set, is the folder below the parent's folder that holds
+	// the level's files, each named after its entity, as in
+	// units/up_A101.jpg. Otherwise each entity is a folder of its own named
+	// after it; a level can have only one such child.
+	Folder string
+	// DocumentTypes are the type prefixes accepted for files placed directly
+	// in the level's folder; empty accepts any.
+	DocumentTypes []string
+	// Display describes an entity in attachment display values, with $key
+	// replaced by the name path value of key. Empty lists the levels from
+	// the entity up to the root.
+	Display string
+}
+
+// Hierarchy lists the levels parents first; the level without a parent is
+// the root folder of the tree.
+var Hierarchy = []EntityHierarchy{
+	{Type: "PROJECT", Parent: "", IDKey: "project", NameKey: "project", Label: "Project", SObject: "Project__c"},
+	{Type: "PHASE", Parent: "PROJECT", IDKey: "phase", NameKey: "phase", Label: "Phase", LookupField: "Phase__c", FileCode: "p", SObject: "Phase__c", ParentField: "Project__c",
+		DocumentTypes: []string{"pp"}, Display: "Phase $phase of $project"},
+	{Type: "ZONE", Parent: "PHASE", IDKey: "zone", NameKey: "zone", Label: "Zone", LookupField: "Zone__c", FileCode: "z", SObject: "Zone__c", ParentField: "Phase__c",
+		DocumentTypes: []string{"f"}, Display: "Zone $zone in Phase $phase of $project"},
+	{Type: "BUILDING", Parent: "ZONE", IDKey: "building", NameKey: "building", Label: "Building", LookupField: "Building__c", FileCode: "b", SObject: "Building__c", ParentField: "Zone__c",
+		Display: "Building $building in Zone $zone of Phase $phase - $project"},
+	{Type: "UNIT", Parent: "BUILDING", IDKey: "unit", NameKey: "unit", Label: "Unit", LookupField: "Unit__c", FileCode: "u", SObject: "Unit__c", ParentField: "Building__c",
+		Folder: "units", Display: "Unit $unit of Building $building in Phase $phase of $project"},
+	{Type: "DESIGN_TYPE", Parent: "PHASE", IDKey: "design_type", NameKey: "designType", Label: "Design Type", LookupField: "Design_Type__c", FileCode: "dt", SObject: "Design_Type__c", ParentField: "Phase__c",
+		Folder: "design_types", Display: "Design Type $designType in Phase $phase of $project"},
+}
+
+func HierarchyLevel(entityType string) (EntityHierarchy, bool) {
+	for _, level := range Hierarchy {
+		if level.Type == entityType {
+			return level, true
+		}
+	}
+	return EntityHierarchy{}, false
+}
+
+// Ancestry returns the chain of levels from the root down to entityType, or
+// nil for an unknown type.
+func Ancestry(entityType string) []EntityHierarchy {
+	var chain []EntityHierarchy
+	for entityType != "" {
+		level, ok := HierarchyLevel(entityType)
+		if !ok {
+			return nil
+		}
+		chain = append([]EntityHierarchy{level}, chain...)
+		entityType = level.Parent
+	}
+	return chain
+}
+
+// LookupLevels lists the levels resolved through the bulk lookup, parents
+// before children. The root level is only part of the name path.
+func LookupLevels() []string {
+	var levels []string
+	for _, level := range Hierarchy {
+		if level.Parent != "" {
+			levels = append(levels, level.Type)
+		}
+	}
+	return levels
+}
+
+func LevelForFileCode(code string) (EntityHierarchy, bool) {
+	for _, level := range Hierarchy {
+		if level.FileCode != "" && level.FileCode == code {
+			return level, true
+		}
+	}
+	return EntityHierarchy{}, false
+}
+
+// LevelNamePath restricts a name path to the keys of entityType and its
+// ancestors.
+func LevelNamePath(entityType string, namePath map[string]string) map[string]string {
+	levelPath := make(map[string]string)
+	for _, level := range Ancestry(entityType) {
+		levelPath[level.NameKey] = namePath[level.NameKey]
+	}
+	return levelPath
+}
+
+func EntityPath(entityType string, namePath map[string]string) string {
+	if entityType == RecordEntityType {
+		return fmt.Sprintf("%s %s=%s", namePath["sobject"], namePath["field"], namePath["value"])
+	}
+
+	chain := Ancestry(entityType)
+	if len(chain) == 0 {
+		return ""
+	}
+
+	parts := []string{namePath[chain[0].NameKey]}
+	for _, level := range chain[1:] {
+		parts = append(parts, level.Label+" "+namePath[level.NameKey])
+	}
+	return strings.Join(parts, "/")
+}
+
+// DisplayPath describes an entity for attachment display values, e.g. "Zone
+// Z1 in Phase P1 of Tower", or "" for an unknown type.
+func DisplayPath(entityType string, namePath map[string]string) string {
+	chain := Ancestry(entityType)
+	if len(chain) == 0 {
+		return ""
+	}
+	if display := chain[len(chain)-1].Display; display != "" {
+		return os.Expand(display, func(key string) string { return namePath[key] })
+	}
+
+	var parts []string
+	for i := len(chain) - 1; i > 0; i-- {
+		parts = append(parts, chain[i].Label+" "+namePath[chain[i].NameKey])
+	}
+	return strings.Join(parts, " in ") + " - " + namePath[chain[0].NameKey]
+}
+
+func rootLevel() (EntityHierarchy, bool) {
+	for _, level := range Hierarchy {
+		if level.Parent == "" {
+			return level, true
+		}
+	}
+	return EntityHierarchy{}, false
+}
+
+// folderLevel returns the child of parentType whose entities are folders
+// named after them, such as the zones of a phase.
+func folderLevel(parentType string) (EntityHierarchy, bool) {
+	for _, level := range Hierarchy {
+		if level.Parent == parentType && level.Folder == "" {
+			return level, true
+		}
+	}
+	return EntityHierarchy{}, false
+}
+
+// namedFolderLevel returns the child of parentType whose files are kept in
+// the folder called folder, such as the units of a building.
+func namedFolderLevel(parentType, folder string) (EntityHierarchy, bool) {
+	for _, level := range Hierarchy {
+		if level.Parent == parentType && level.Folder != "" && level.Folder == folder {
+			return level, true
+		}
+	}
+	return EntityHierarchy{}, false
+}
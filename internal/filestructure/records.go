@@ -0,0 +1,48 @@
+package filestructure
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// RecordFolderPrefix marks a top-level folder that attaches its files to a
+// record of any sObject instead of the project hierarchy. The folder names
+// the sObject and the one below it the field and value that identify the
+// record, e.g. @Account/AccountNumber=A-1001/contract.pdf.
+const RecordFolderPrefix = "@"
+
+// RecordEntityType is the entity type of documents in a record folder.
+const RecordEntityType = "RECORD"
+
+var apiNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+func isRecordPath(pathComponents []string) bool {
+	return len(pathComponents) > 0 && strings.HasPrefix(pathComponents[0], RecordFolderPrefix)
+}
+
+func processRecordPath(docInfo *models.DocumentInfo, pathComponents []string) (*models.DocumentInfo, error) {
+	if len(pathComponents) != 2 {
+		return nil, fmt.Errorf("invalid record path %q: expected @<SObject>/<Field>=<value>", strings.Join(pathComponents, "/"))
+	}
+
+	sobject := strings.TrimPrefix(pathComponents[0], RecordFolderPrefix)
+	field, value, ok := strings.Cut(pathComponents[1], "=")
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("invalid record folder %q: expected <Field>=<value>", pathComponents[1])
+	}
+	if !apiNamePattern.MatchString(sobject) {
+		return nil, fmt.Errorf("invalid sObject name %q in record path", sobject)
+	}
+	if !apiNamePattern.MatchString(field) {
+		return nil, fmt.Errorf("invalid field name %q in record path", field)
+	}
+
+	docInfo.EntityType = RecordEntityType
+	docInfo.NamePath["sobject"] = sobject
+	docInfo.NamePath["field"] = field
+	docInfo.NamePath["value"] = value
+	return docInfo, nil
+}
@@ -0,0 +1,56 @@
+package filestructure
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestParseRecordPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		namePath map[string]string
+		docType  string
+		wantErr  string
+	}{
+		{name: "unprefixed file", path: "@Account/AccountNumber=A-1001/contract.pdf",
+			namePath: map[string]string{"sobject": "Account", "field": "AccountNumber", "value": "A-1001"}, docType: config.DocTypeGeneric},
+		{name: "custom object", path: "@Site__c/Code__c=S=1/fp_plan.pdf",
+			namePath: map[string]string{"sobject": "Site__c", "field": "Code__c", "value": "S=1"}, docType: config.DocTypeFloorPlan},
+		{name: "no record folder", path: "@Account/contract.pdf", wantErr: "invalid record path"},
+		{name: "nested folder", path: "@Account/AccountNumber=A-1001/old/contract.pdf", wantErr: "invalid record path"},
+		{name: "empty value", path: "@Account/AccountNumber= /contract.pdf", wantErr: "invalid record folder"},
+		{name: "bad sObject", path: "@Acc-ount/AccountNumber=A-1001/contract.pdf", wantErr: "invalid sObject name"},
+		{name: "bad field", path: "@Account/Account Number=A-1001/contract.pdf", wantErr: "invalid field name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := strings.Split(tt.path, "/")
+			fileName := components[len(components)-1]
+
+			doc, err := parseDocument(fileName, components[:len(components)-1], false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("parseDocument() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.EntityType != RecordEntityType {
+				t.Errorf("EntityType = %s, want %s", doc.EntityType, RecordEntityType)
+			}
+			if !reflect.DeepEqual(doc.NamePath, tt.namePath) {
+				t.Errorf("NamePath = %v, want %v", doc.NamePath, tt.namePath)
+			}
+			if doc.DocumentType != tt.docType {
+				t.Errorf("DocumentType = %s, want %s", doc.DocumentType, tt.docType)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package filestructure
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// levelMarkers maps the Folder of each Hierarchy level to its fixed depth
+// below the documents root, e.g. <root>/<project>/<phase>/design_types and
+// <root>/<project>/<phase>/<zone>/<building>/units.
+func levelMarkers() map[string]int {
+	markers := make(map[string]int)
+	for _, level := range Hierarchy {
+		if level.Folder != "" {
+			markers[level.Folder] = len(Ancestry(level.Parent)) + 1
+		}
+	}
+	return markers
+}
+
+const maxRootCheckDepth = 7
+
+// CheckRootLevel looks for marker folders and returns a hint when their depth
+// suggests the user selected a folder above or below the documents root. An
+// empty string means the selection looks right or there was nothing to judge.
+func CheckRootLevel(documentsDir string) string {
+	documentsDir = filepath.Clean(documentsDir)
+	markers := levelMarkers()
+	candidates := make(map[string]int)
+
+	filepath.WalkDir(documentsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == documentsDir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(documentsDir, path)
+		if err != nil {
+			return nil
+		}
+		depth := len(strings.Split(rel, string(os.PathSeparator)))
+		if depth > maxRootCheckDepth {
+			return filepath.SkipDir
+		}
+
+		if expected, ok := markers[d.Name()]; ok {
+			root := path
+			for i := 0; i < expected; i++ {
+				root = filepath.Dir(root)
+			}
+			candidates[root]++
+		}
+		return nil
+	})
+
+	bestRoot, bestCount := "", 0
+	for root, count := range candidates {
+		if count > bestCount {
+			bestRoot, bestCount = root, count
+		}
+	}
+
+	if bestRoot == "" || bestRoot == documentsDir {
+		return ""
+	}
+
+	if rel, err := filepath.Rel(bestRoot, documentsDir); err == nil && !strings.HasPrefix(rel, "..") {
+		levels := len(strings.Split(rel, string(os.PathSeparator)))
+		return fmt.Sprintf("the selected folder looks %d level(s) too deep (it may be a project or phase folder); "+
+			"select the folder that contains the project folders, e.g. %s", levels, bestRoot)
+	}
+
+	return fmt.Sprintf("the selected folder looks too high; the project folders appear to be inside %s", bestRoot)
+}
@@ -0,0 +1,50 @@
+package filestructure
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRootLevel(t *testing.T) {
+	top := t.TempDir()
+	root := filepath.Join(top, "Documents")
+	for _, dir := range []string{
+		"Tower/P1/design_types",
+		"Tower/P1/Z1/B1/units",
+		"Tower/P1/Z1/B2/units",
+		"Villas/P1/Z1/B1/units",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		selected string
+		want     string
+	}{
+		{name: "documents root", selected: root},
+		{name: "project folder", selected: filepath.Join(root, "Tower"), want: "looks 1 level(s) too deep"},
+		{name: "phase folder", selected: filepath.Join(root, "Tower", "P1"), want: "looks 2 level(s) too deep"},
+		{name: "parent folder", selected: top, want: "looks too high"},
+		{name: "no markers", selected: filepath.Join(root, "Tower", "P1", "Z1", "B1", "units")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint := CheckRootLevel(tt.selected)
+			if tt.want == "" {
+				if hint != "" {
+					t.Errorf("CheckRootLevel() = %q, want no hint", hint)
+				}
+				return
+			}
+			if !strings.Contains(hint, tt.want) || !strings.Contains(hint, root) {
+				t.Errorf("CheckRootLevel() = %q, want %q naming %s", hint, tt.want, root)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package filestructure
+
+import (
+	"os"
+	"strings"
+)
+
+// SidecarExt is appended to a document's file name to name its metadata
+// sidecar, e.g. "g_front.jpg.json" for "g_front.jpg".
+const SidecarExt = ".json"
+
+func SidecarPath(documentPath string) string {
+	return documentPath + SidecarExt
+}
+
+// IsSidecar reports whether path is the metadata sidecar of a document that
+// sits next to it.
+func IsSidecar(path string) bool {
+	if !strings.HasSuffix(path, SidecarExt) {
+		return false
+	}
+	info, err := os.Stat(strings.TrimSuffix(path, SidecarExt))
+	return err == nil && !info.IsDir()
+}
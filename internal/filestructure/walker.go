@@ -1,17 +1,42 @@
 package filestructure
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
 	"github.com/ORAITApps/document-uploader/internal/models"
 )
 
+// ErrTooManyProblems stops a walk that has collected as many problems as
+// SetMaxProblems allows.
+var ErrTooManyProblems = errors.New("directory structure appears incorrect")
+
+// Problem is a file the walker could not turn into a document.
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
 type DocumentWalker struct {
-	documentsDir string
-	documents    []models.DocumentInfo
+	documentsDir   string
+	documents      []models.DocumentInfo
+	followSymlinks bool
+	lenientTypes   bool
+	skipPaths      map[string]bool
+	exclude        []string
+	excludedCount  int
+	collect        bool
+	maxProblems    int
+	problems       []Problem
+	visitedDirs    []os.FileInfo
+	scanned        int
+	progressEvery  int
+	progress       func(scanned int)
 }
 
 func NewDocumentWalker(documentsDir string) *DocumentWalker {
@@ -21,6 +46,63 @@ func NewDocumentWalker(documentsDir string) *DocumentWalker {
 	}
 }
 
+// SetFollowSymlinks controls whether symlinked files and directories are
+// walked. Symlinks are skipped by default.
+func (w *DocumentWalker) SetFollowSymlinks(follow bool) {
+	w.followSymlinks = follow
+}
+
+// SetLenientDocumentTypes makes files with an unknown document type prefix
+// upload as generic documents with a warning instead of failing the walk.
+func (w *DocumentWalker) SetLenientDocumentTypes(lenient bool) {
+	w.lenientTypes = lenient
+}
+
+// SetSkipPaths excludes files and whole directories, such as the uploader's
+// own logs and executable, from the walk.
+func (w *DocumentWalker) SetSkipPaths(paths []string) {
+	w.skipPaths = make(map[string]bool, len(paths))
+	for _, path := range paths {
+		w.skipPaths[filepath.Clean(path)] = true
+	}
+}
+
+// SetExcludePatterns skips files matching any of the glob patterns, as
+// parsed by ParseExcludePatterns.
+func (w *DocumentWalker) SetExcludePatterns(patterns []string) {
+	w.exclude = patterns
+}
+
+// ExcludedCount returns how many files the exclude patterns skipped.
+func (w *DocumentWalker) ExcludedCount() int {
+	return w.excludedCount
+}
+
+// SetCollectProblems makes the walk record files that fail to parse and
+// carry on, instead of stopping at the first one.
+func (w *DocumentWalker) SetCollectProblems(collect bool) {
+	w.collect = collect
+}
+
+// SetMaxProblems stops a collecting walk with ErrTooManyProblems once max
+// problems have been found, so a wrong directory fails fast instead of
+// listing every file in it. 0 means no limit.
+func (w *DocumentWalker) SetMaxProblems(max int) {
+	w.maxProblems = max
+}
+
+// SetProgressHandler calls handler with the number of files scanned so far
+// after every `every` files, so huge trees show signs of life while they are
+// walked.
+func (w *DocumentWalker) SetProgressHandler(every int, handler func(scanned int)) {
+	w.progressEvery = every
+	w.progress = handler
+}
+
+func (w *DocumentWalker) Problems() []Problem {
+	return w.problems
+}
+
 func (w *DocumentWalker) Walk() ([]models.DocumentInfo, error) {
 	err := filepath.Walk(w.documentsDir, w.processPath)
 	if err != nil {
@@ -34,21 +116,66 @@ func (w *DocumentWalker) processPath(path string, info os.FileInfo, err error) e
 		return err
 	}
 
-	if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+	if w.skipPaths[filepath.Clean(path)] && path != w.documentsDir {
+		logging.GetLogger().Warning("Skipping application file %s", path)
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
 		return nil
 	}
 
+	if info.Mode()&os.ModeSymlink != 0 {
+		return w.processSymlink(path, info)
+	}
+
+	if info.IsDir() {
+		if w.followSymlinks {
+			// A symlink sorting before its target, such as a_link -> b, has
+			// already walked this directory under the link's path.
+			if dirInfo, err := os.Stat(path); err == nil {
+				if w.visited(dirInfo) {
+					logging.GetLogger().Warning("Skipping %s: it was already walked through a symlink", path)
+					return filepath.SkipDir
+				}
+				w.visitedDirs = append(w.visitedDirs, dirInfo)
+			}
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(info.Name(), ".") || IsSidecar(path) {
+		return nil
+	}
+
+	w.scanned++
+	if w.progress != nil && w.progressEvery > 0 && w.scanned%w.progressEvery == 0 {
+		w.progress(w.scanned)
+	}
+
 	// Get relative path from documents directory
 	relPath, err := filepath.Rel(w.documentsDir, path)
 	if err != nil {
 		return err
 	}
 
+	if excluded(w.exclude, relPath) {
+		logging.GetLogger().Debug("Excluding %s", relPath)
+		w.excludedCount++
+		return nil
+	}
+
 	pathComponents := strings.Split(filepath.Dir(relPath), string(os.PathSeparator))
 	fileName := info.Name()
 
-	docInfo, err := parseDocument(fileName, pathComponents)
+	docInfo, err := parseDocument(fileName, pathComponents, w.lenientTypes)
 	if err != nil {
+		if w.collect {
+			w.problems = append(w.problems, Problem{Path: filepath.ToSlash(relPath), Message: err.Error()})
+			if w.maxProblems > 0 && len(w.problems) >= w.maxProblems {
+				return ErrTooManyProblems
+			}
+			return nil
+		}
 		return err
 	}
 
@@ -61,7 +188,60 @@ func (w *DocumentWalker) processPath(path string, info os.FileInfo, err error) e
 	return nil
 }
 
-func parseDocument(fileName string, pathComponents []string) (*models.DocumentInfo, error) {
+func (w *DocumentWalker) processSymlink(path string, info os.FileInfo) error {
+	logger := logging.GetLogger()
+
+	// The selected directory itself is always followed.
+	if !w.followSymlinks && path != w.documentsDir {
+		logger.Warning("Skipping symlink %s (following symlinks is disabled)", path)
+		return nil
+	}
+
+	target, err := os.Stat(path)
+	if err != nil {
+		logger.Warning("Skipping broken symlink %s: %v", path, err)
+		return nil
+	}
+
+	if !target.IsDir() {
+		logger.Debug("Following symlinked file %s", path)
+		return w.processPath(path, target, nil)
+	}
+
+	if w.visited(target) {
+		logger.Warning("Skipping symlink %s: it points to an already visited directory", path)
+		return nil
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		logger.Warning("Skipping symlink %s: %v", path, err)
+		return nil
+	}
+
+	logger.Info("Following symlinked directory %s -> %s", path, realPath)
+
+	// Walk the target but report paths under the link, so the folder
+	// structure still reflects where the link sits in the tree.
+	return filepath.Walk(realPath, func(targetPath string, targetInfo os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(realPath, targetPath)
+		if relErr != nil {
+			return relErr
+		}
+		return w.processPath(filepath.Join(path, rel), targetInfo, err)
+	})
+}
+
+func (w *DocumentWalker) visited(dir os.FileInfo) bool {
+	for _, visited := range w.visitedDirs {
+		if os.SameFile(visited, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDocument(fileName string, pathComponents []string, lenientTypes bool) (*models.DocumentInfo, error) {
 	parts := strings.Split(strings.TrimSuffix(fileName, filepath.Ext(fileName)), "_")
 	if len(parts) < 1 {
 		return nil, nil
@@ -74,76 +254,168 @@ func parseDocument(fileName string, pathComponents []string) (*models.DocumentIn
 	}
 
 	prefix := parts[0]
+	// Files attached to arbitrary records need no type prefix.
+	if isRecordPath(pathComponents) {
+		if setDocumentType(prefix, docInfo) != nil {
+			docInfo.DocumentType = config.DocTypeGeneric
+		}
+		return processRecordPath(docInfo, pathComponents)
+	}
+
 	if err := setDocumentType(prefix, docInfo); err != nil {
-		return nil, err
+		if !lenientTypes {
+			return nil, err
+		}
+		docInfo.DocumentType = config.DocTypeGeneric
+		docInfo.Warnings = append(docInfo.Warnings, fmt.Sprintf("%v, uploading as %s", err, config.DocTypeGeneric))
 	}
 
 	return processPathComponents(docInfo, pathComponents, parts)
 }
 
+// processPathComponents reads the entity a file belongs to from its folders,
+// following Hierarchy from the root level: each folder names an entity of
+// the level below, except for a level's Folder, whose files each name an
+// entity. Folders below a level without folder children are subfolders.
 func processPathComponents(docInfo *models.DocumentInfo, pathComponents []string, fileNameParts []string) (*models.DocumentInfo, error) {
 	if len(pathComponents) < 1 {
 		return nil, fmt.Errorf("invalid path structure: missing project name")
 	}
-	docInfo.NamePath["project"] = pathComponents[0]
-
-	if len(pathComponents) >= 3 && pathComponents[2] == "design_types" {
-		docInfo.EntityType = "DESIGN_TYPE"
-		docInfo.NamePath["phase"] = pathComponents[1]
-		if len(fileNameParts) > 1 {
-			docInfo.NamePath["designType"] = strings.Join(fileNameParts[1:], "_")
+	for i, component := range pathComponents {
+		if strings.TrimSpace(component) == "" {
+			return nil, fmt.Errorf("invalid path structure: folder %d of %q has an empty name",
+				i+1, strings.Join(pathComponents, "/"))
 		}
-		return docInfo, nil
 	}
 
-	prefix := fileNameParts[0]
-	if err := setDocumentType(prefix, docInfo); err != nil {
-		return nil, err
+	level, ok := rootLevel()
+	if !ok {
+		return nil, fmt.Errorf("invalid hierarchy: no root level")
 	}
+	docInfo.NamePath[level.NameKey] = pathComponents[0]
 
-	switch {
-	case len(pathComponents) == 2 && prefix == "pp":
-		docInfo.EntityType = "PHASE"
-		docInfo.NamePath["phase"] = pathComponents[1]
-
-	case len(pathComponents) == 3 && prefix == "f":
-		docInfo.EntityType = "ZONE"
-		docInfo.NamePath["phase"] = pathComponents[1]
-		docInfo.NamePath["zone"] = pathComponents[2]
-
-	case len(pathComponents) >= 4:
-		docInfo.NamePath["phase"] = pathComponents[1]
-		docInfo.NamePath["zone"] = pathComponents[2]
-		docInfo.NamePath["building"] = pathComponents[3]
-
-		if len(pathComponents) >= 5 && pathComponents[4] == "units" {
-			docInfo.EntityType = "UNIT"
-			if len(fileNameParts) > 1 {
-				unitName := strings.Join(fileNameParts[1:], "_")
-				unitName = strings.TrimSuffix(unitName, filepath.Ext(unitName))
-				docInfo.NamePath["unit"] = unitName
-			} else {
-				return nil, fmt.Errorf("invalid unit filename format: %v", fileNameParts)
+	depth := 1
+	for ; depth < len(pathComponents); depth++ {
+		if child, ok := namedFolderLevel(level.Type, pathComponents[depth]); ok {
+			if warning := ambiguousFolder(level, pathComponents, depth); warning != "" {
+				docInfo.Warnings = append(docInfo.Warnings, warning)
 			}
-		} else {
-			docInfo.EntityType = "BUILDING"
+			return processFolderFile(docInfo, level, child, fileNameParts)
+		}
+		child, ok := folderLevel(level.Type)
+		if !ok {
+			break
 		}
+		docInfo.NamePath[child.NameKey] = pathComponents[depth]
+		level = child
+	}
 
-	default:
+	if depth == len(pathComponents) {
+		if warning := ambiguousName(level, pathComponents); warning != "" {
+			docInfo.Warnings = append(docInfo.Warnings, warning)
+		}
+	}
+
+	if level.Parent == "" || !acceptsDocumentType(level, fileNameParts[0]) {
 		return nil, fmt.Errorf("invalid path structure: %v", pathComponents)
 	}
+	docInfo.EntityType = level.Type
 
-	if docInfo.EntityType == "" {
-		return nil, fmt.Errorf("could not determine entity type for path: %v", pathComponents)
+	logging.GetLogger().Debug("Processed document: entity type %s, name path %+v", docInfo.EntityType, docInfo.NamePath)
+
+	return docInfo, nil
+}
+
+// processFolderFile reads a file in the Folder of level, such as
+// units/up_A101.jpg, whose name after the type prefix names the entity.
+func processFolderFile(docInfo *models.DocumentInfo, parent, level EntityHierarchy, fileNameParts []string) (*models.DocumentInfo, error) {
+	docInfo.EntityType = level.Type
+	if reason := ambiguousFolderFile(docInfo, parent, level, fileNameParts); reason != "" {
+		docInfo.Rejected = "ambiguous path: " + reason
+		return docInfo, nil
 	}
 
-	fmt.Printf("Processed document:\n")
-	fmt.Printf("  Entity Type: %s\n", docInfo.EntityType)
-	fmt.Printf("  Name Path: %+v\n", docInfo.NamePath)
+	name := strings.Join(fileNameParts[1:], "_")
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("invalid %s filename format: empty %s name in %v",
+			strings.ToLower(level.Label), strings.ToLower(level.Label), fileNameParts)
+	}
+	docInfo.NamePath[level.NameKey] = name
+
+	logging.GetLogger().Debug("Processed document: entity type %s, name path %+v", docInfo.EntityType, docInfo.NamePath)
 
 	return docInfo, nil
 }
 
+func acceptsDocumentType(level EntityHierarchy, prefix string) bool {
+	if len(level.DocumentTypes) == 0 {
+		return true
+	}
+	for _, accepted := range level.DocumentTypes {
+		if accepted == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// ambiguousFolderFile explains why a file in a level's Folder could belong
+// to the parent entity as well as to one named by the file, or returns ""
+// when its name clearly names one. Such files are rejected rather than
+// attached to a guessed entity; adding the type prefix and entity name, or
+// moving the file up to the parent, resolves them.
+func ambiguousFolderFile(docInfo *models.DocumentInfo, parent, level EntityHierarchy, fileNameParts []string) string {
+	fileName := strings.Join(fileNameParts, "_")
+	parentLabel, label := strings.ToLower(parent.Label), strings.ToLower(level.Label)
+	switch {
+	case docInfo.DocumentType == config.DocTypeGeneric:
+		return fmt.Sprintf("%q in the %s folder of %s %q has no document type prefix, so it is unclear whether it names a %s or belongs to the %s",
+			fileName, level.Folder, parentLabel, docInfo.NamePath[parent.NameKey], label, parentLabel)
+	case len(fileNameParts) < 2:
+		return fmt.Sprintf("%q in the %s folder of %s %q names no %s; it could belong to the %s or to one of its %ss",
+			fileName, level.Folder, parentLabel, docInfo.NamePath[parent.NameKey], label, parentLabel, label)
+	}
+	return ""
+}
+
+// ambiguousFolder and ambiguousName describe a folder path that is read one
+// way but looks like it was meant another, or return "" for an ordinary
+// path. These files are still uploaded as before; the warning lands in the
+// parse report so the folder can be renamed if the guess is wrong.
+//
+// ambiguousFolder covers a level's Folder at pathComponents[depth] with more
+// folders below it, which could instead name an entity with a child of its
+// own, as in design_types/X read as a building X in a zone named
+// design_types.
+func ambiguousFolder(parent EntityHierarchy, pathComponents []string, depth int) string {
+	if depth+1 >= len(pathComponents) {
+		return ""
+	}
+	child, ok := folderLevel(parent.Type)
+	if !ok {
+		return ""
+	}
+	grandchild, ok := folderLevel(child.Type)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%q is read as the %s folder, but %s could also be a %s in a %s named %s",
+		strings.Join(pathComponents[:depth+1], "/"), pathComponents[depth], strings.Join(pathComponents, "/"),
+		strings.ToLower(grandchild.Label), strings.ToLower(child.Label), pathComponents[depth])
+}
+
+// ambiguousName covers an entity named after one of its own level's
+// Folders, as in a building named units.
+func ambiguousName(level EntityHierarchy, pathComponents []string) string {
+	name := pathComponents[len(pathComponents)-1]
+	if _, ok := namedFolderLevel(level.Type, name); !ok || level.Parent == "" {
+		return ""
+	}
+	label := strings.ToLower(level.Label)
+	return fmt.Sprintf("%q is read as a %s named %s, but it could be a %s folder missing its %s",
+		strings.Join(pathComponents, "/"), label, name, name, label)
+}
+
 func setDocumentType(prefix string, docInfo *models.DocumentInfo) error {
 	switch prefix {
 	case "bl":
@@ -0,0 +1,337 @@
+package filestructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestParseDocumentAmbiguousPaths(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		lenient    bool
+		entityType string
+		unit       string
+		rejected   bool
+		warning    bool
+	}{
+		{name: "building file", path: "P/Ph/Z/B1/bl_front.jpg", entityType: "BUILDING"},
+		{name: "building subfolder", path: "P/Ph/Z/B1/photos/bl_front.jpg", entityType: "BUILDING"},
+		{name: "unit file", path: "P/Ph/Z/B1/units/up_A1.jpg", entityType: "UNIT", unit: "A1"},
+		{name: "unit subfolder", path: "P/Ph/Z/B1/units/floor1/up_A1.jpg", entityType: "UNIT", unit: "A1"},
+		{name: "unit file without unit name", path: "P/Ph/Z/B1/units/up.jpg", entityType: "UNIT", rejected: true},
+		{name: "unprefixed file in units", path: "P/Ph/Z/B1/units/plan_A1.jpg", lenient: true, entityType: "UNIT", rejected: true},
+		{name: "building named units", path: "P/Ph/Z/units/up_A1.jpg", entityType: "BUILDING", warning: true},
+		{name: "design_types subfolder", path: "P/Ph/design_types/X/fp_loft.jpg", entityType: "DESIGN_TYPE", warning: true},
+		{name: "design_types file without name", path: "P/Ph/design_types/fp.jpg", entityType: "DESIGN_TYPE", rejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := strings.Split(tt.path, "/")
+			fileName := components[len(components)-1]
+
+			doc, err := parseDocument(fileName, components[:len(components)-1], tt.lenient)
+			if err != nil {
+				t.Fatalf("parseDocument() error = %v", err)
+			}
+			if doc.EntityType != tt.entityType {
+				t.Errorf("EntityType = %s, want %s", doc.EntityType, tt.entityType)
+			}
+			if tt.unit != "" && doc.NamePath["unit"] != tt.unit {
+				t.Errorf("unit = %q, want %q", doc.NamePath["unit"], tt.unit)
+			}
+			if rejected := doc.Rejected != ""; rejected != tt.rejected {
+				t.Errorf("Rejected = %q, want rejected %v", doc.Rejected, tt.rejected)
+			}
+			if tt.rejected && !strings.HasPrefix(doc.Rejected, "ambiguous path: ") {
+				t.Errorf("Rejected = %q, want an ambiguous path", doc.Rejected)
+			}
+
+			warned := false
+			for _, warning := range doc.Warnings {
+				if strings.Contains(warning, "is read as") {
+					warned = true
+				}
+			}
+			if warned != tt.warning {
+				t.Errorf("Warnings = %q, want an ambiguity warning %v", doc.Warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestParseDocumentLenientTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		lenient  bool
+		fileName string
+		docType  string
+		wantErr  bool
+		warnings int
+	}{
+		{name: "known prefix", fileName: "bl_front.jpg", docType: config.DocTypeBuildingLocation},
+		{name: "unknown prefix strict", fileName: "site_front.jpg", wantErr: true},
+		{name: "unknown prefix lenient", lenient: true, fileName: "site_front.jpg", docType: config.DocTypeGeneric, warnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseDocument(tt.fileName, []string{"P", "Ph", "Z", "B1"}, tt.lenient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if doc.DocumentType != tt.docType || doc.EntityType != "BUILDING" {
+				t.Errorf("parseDocument() = %s %s, want %s BUILDING", doc.DocumentType, doc.EntityType, tt.docType)
+			}
+			if len(doc.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %q, want %d", doc.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+// withClusterLevel inserts a CLUSTER level between zones and buildings for
+// the duration of the test.
+func withClusterLevel(t *testing.T) {
+	original := Hierarchy
+	t.Cleanup(func() { Hierarchy = original })
+
+	var levels []EntityHierarchy
+	for _, level := range original {
+		if level.Type == "BUILDING" {
+			levels = append(levels, EntityHierarchy{Type: "CLUSTER", Parent: "ZONE", IDKey: "cluster", NameKey: "cluster", Label: "Cluster",
+				LookupField: "Cluster__c", FileCode: "c", SObject: "Cluster__c", ParentField: "Zone__c", DocumentTypes: []string{"g"}})
+			level.Parent = "CLUSTER"
+			level.ParentField = "Cluster__c"
+			level.Display = ""
+		}
+		levels = append(levels, level)
+	}
+	Hierarchy = levels
+}
+
+func TestParseDocumentCustomLevel(t *testing.T) {
+	withClusterLevel(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		entityType string
+		namePath   map[string]string
+		entityPath string
+		display    string
+		wantErr    bool
+	}{
+		{
+			name:       "cluster file",
+			path:       "P/Ph/Z/C1/g_view.jpg",
+			entityType: "CLUSTER",
+			namePath:   map[string]string{"project": "P", "phase": "Ph", "zone": "Z", "cluster": "C1"},
+			entityPath: "P/Phase Ph/Zone Z/Cluster C1",
+			display:    "Cluster C1 in Zone Z in Phase Ph - P",
+		},
+		{
+			name:    "cluster file with another type",
+			path:    "P/Ph/Z/C1/bl_front.jpg",
+			wantErr: true,
+		},
+		{
+			name:       "building file",
+			path:       "P/Ph/Z/C1/B1/bl_front.jpg",
+			entityType: "BUILDING",
+			namePath:   map[string]string{"project": "P", "phase": "Ph", "zone": "Z", "cluster": "C1", "building": "B1"},
+			entityPath: "P/Phase Ph/Zone Z/Cluster C1/Building B1",
+			display:    "Building B1 in Cluster C1 in Zone Z in Phase Ph - P",
+		},
+		{
+			name:       "unit file",
+			path:       "P/Ph/Z/C1/B1/units/up_A1.jpg",
+			entityType: "UNIT",
+			namePath:   map[string]string{"project": "P", "phase": "Ph", "zone": "Z", "cluster": "C1", "building": "B1", "unit": "A1"},
+			entityPath: "P/Phase Ph/Zone Z/Cluster C1/Building B1/Unit A1",
+			display:    "Unit A1 of Building B1 in Phase Ph of P",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := strings.Split(tt.path, "/")
+			fileName := components[len(components)-1]
+
+			doc, err := parseDocument(fileName, components[:len(components)-1], false)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDocument() = %+v, want an error", doc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDocument() error = %v", err)
+			}
+			if doc.EntityType != tt.entityType {
+				t.Errorf("EntityType = %s, want %s", doc.EntityType, tt.entityType)
+			}
+			if !reflect.DeepEqual(doc.NamePath, tt.namePath) {
+				t.Errorf("NamePath = %v, want %v", doc.NamePath, tt.namePath)
+			}
+			if got := EntityPath(doc.EntityType, doc.NamePath); got != tt.entityPath {
+				t.Errorf("EntityPath() = %q, want %q", got, tt.entityPath)
+			}
+			if got := DisplayPath(doc.EntityType, doc.NamePath); got != tt.display {
+				t.Errorf("DisplayPath() = %q, want %q", got, tt.display)
+			}
+		})
+	}
+}
+
+func TestWalkFollowsSiblingSymlinkOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+	}{
+		{name: "link before target", link: "a_link"},
+		{name: "link after target", link: "z_link"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			zone := filepath.Join(dir, "P", "Ph", "Z")
+			if err := os.MkdirAll(filepath.Join(zone, "b"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(zone, "b", "bl_front.jpg"), []byte("jpg"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(filepath.Join(zone, "b"), filepath.Join(zone, tt.link)); err != nil {
+				t.Skipf("symlinks unavailable: %v", err)
+			}
+
+			walker := NewDocumentWalker(dir)
+			walker.SetFollowSymlinks(true)
+			documents, err := walker.Walk()
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+			if len(documents) != 1 {
+				var paths []string
+				for _, doc := range documents {
+					paths = append(paths, doc.RelativePath)
+				}
+				t.Fatalf("Walk() found %v, want the building file once", paths)
+			}
+		})
+	}
+}
+
+func TestParseDocumentEmptyNames(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"double separator", "P//Z/B1/bl_front.jpg", `folder 2 of "P//Z/B1" has an empty name`},
+		{"whitespace project", "  /Ph/Z/B1/bl_front.jpg", `folder 1 of "  /Ph/Z/B1" has an empty name`},
+		{"whitespace building", "P/Ph/Z/ /bl_front.jpg", `folder 4 of "P/Ph/Z/ " has an empty name`},
+		{"whitespace unit name", "P/Ph/Z/B1/units/up_ .jpg", "empty unit name"},
+		{"whitespace design type", "P/Ph/design_types/fp_ .jpg", "empty design type name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := strings.Split(tt.path, "/")
+			fileName := components[len(components)-1]
+
+			_, err := parseDocument(fileName, components[:len(components)-1], false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("parseDocument() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkStopsAtMaxProblems(t *testing.T) {
+	dir := t.TempDir()
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		name := filepath.Join(building, "photo_"+strings.Repeat("x", i+1)+".jpg")
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		max      int
+		problems int
+		wantErr  error
+	}{
+		{name: "capped", max: 5, problems: 5, wantErr: ErrTooManyProblems},
+		{name: "under the cap", max: 100, problems: 50},
+		{name: "no cap", max: 0, problems: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walker := NewDocumentWalker(dir)
+			walker.SetCollectProblems(true)
+			walker.SetMaxProblems(tt.max)
+			scanned := 0
+			walker.SetProgressHandler(1, func(n int) { scanned = n })
+
+			_, err := walker.Walk()
+			if err != tt.wantErr {
+				t.Fatalf("Walk() error = %v, want %v", err, tt.wantErr)
+			}
+			if n := len(walker.Problems()); n != tt.problems {
+				t.Errorf("%d problems collected, want %d", n, tt.problems)
+			}
+			if scanned != tt.problems {
+				t.Errorf("%d files scanned, want the walk to stop after %d", scanned, tt.problems)
+			}
+		})
+	}
+}
+
+func TestWalkReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2500; i++ {
+		if err := os.WriteFile(filepath.Join(building, fmt.Sprintf("bl_%04d.jpg", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Hidden files and sidecars are not counted as scanned.
+	if err := os.WriteFile(filepath.Join(building, ".DS_Store"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	walker := NewDocumentWalker(dir)
+	var reports []int
+	walker.SetProgressHandler(1000, func(scanned int) { reports = append(reports, scanned) })
+	documents, err := walker.Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2500 {
+		t.Errorf("walked %d documents, want 2500", len(documents))
+	}
+	if want := []int{1000, 2000}; !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress reports = %v, want %v", reports, want)
+	}
+}
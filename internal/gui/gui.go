@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -11,20 +12,41 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
 	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/pkg/browser"
 )
 
 type App struct {
 	fyneApp           fyne.App
 	window            fyne.Window
 	logView           *widget.TextGrid
+	logLines          *logRing
+	updates           *uiUpdater
 	progress          *widget.ProgressBar
 	status            *widget.Label
 	pathLabel         *widget.Label
+	subtreeEntry      *widget.Entry
+	excludeEntry      *widget.Entry
+	docTypeSelect     *widget.Select
+	documentTypes     []string
+	instanceEntry     *widget.Entry
 	startBtn          *widget.Button
+	openResultsBtn    *widget.Button
+	resultsURL        string
 	documentsPath     string
-	processStarted    bool
+	selectedFiles     []string
+	resumeReport      string
+	state             runState
 	processingHandler func()
+	signOutHandler    func()
+	diagnostics       func() (string, error)
+	fileParser        func(path string) (*models.DocumentInfo, error)
+	uploadEstimator   func(run models.DirectoryRun) (string, error)
+	resumePreviewer   func(reportPath string) (string, error)
 }
 
 func NewApp() *App {
@@ -35,13 +57,16 @@ func NewApp() *App {
 		fyneApp:   a,
 		window:    w,
 		logView:   widget.NewTextGrid(),
+		logLines:  newLogRing(maxLogLines),
 		progress:  widget.NewProgressBar(),
 		status:    widget.NewLabel("Select documents directory to begin"),
 		pathLabel: widget.NewLabel("No directory selected"),
+		updates:   newUIUpdater(),
 	}
+	go app.updates.run(app.applyUpdates)
 
 	logger := logging.GetLogger()
-	logger.SetGuiLogView(app.logView)
+	logger.SetGuiSink(app.updates.AppendLog)
 
 	return app
 }
@@ -54,16 +79,45 @@ func (a *App) AddLog(message string) {
 func (a *App) Run() {
 
 	selectBtn := widget.NewButton("Select Directory", a.handleDirectorySelection)
+	selectFileBtn := widget.NewButton("Select File(s)", a.handleFileSelection)
+	resumeBtn := widget.NewButton("Resume from Report", a.handleReportSelection)
 	a.startBtn = widget.NewButton("Start Processing", a.handleStartProcessing)
-	a.startBtn.Disable()
+	a.syncStartButton()
 
-	buttons := container.NewHBox(selectBtn, a.startBtn)
+	a.openResultsBtn = widget.NewButton("Open in Salesforce", a.handleOpenResults)
+	a.openResultsBtn.Disable()
+	signOutBtn := widget.NewButton("Sign out", a.handleSignOut)
+	diagnosticsBtn := widget.NewButton("Create diagnostics bundle", a.handleDiagnostics)
+
+	a.instanceEntry = widget.NewEntry()
+	a.instanceEntry.SetPlaceHolder("https://<my-domain>.my.salesforce.com")
+	instanceInfo := container.NewBorder(nil, nil, widget.NewLabel("Instance URL:"), nil, a.instanceEntry)
+
+	buttons := container.NewHBox(selectBtn, selectFileBtn, resumeBtn, a.startBtn, a.openResultsBtn, signOutBtn, diagnosticsBtn)
+	if envSelect := a.newEnvironmentSelect(); envSelect != nil {
+		buttons.Add(widget.NewLabel("Org:"))
+		buttons.Add(envSelect)
+	}
+	a.instanceEntry.SetText(config.SFInstanceURL)
 
 	pathInfo := container.NewHBox(
 		widget.NewLabel("Selected Directory:"),
 		a.pathLabel,
 	)
 
+	a.subtreeEntry = widget.NewEntry()
+	a.subtreeEntry.SetPlaceHolder("Project/Phase/Zone/... (optional, directory runs only)")
+	subtreeInfo := container.NewBorder(nil, nil, widget.NewLabel("Only upload:"), nil, a.subtreeEntry)
+
+	a.excludeEntry = widget.NewEntry()
+	a.excludeEntry.SetPlaceHolder("*_draft.*, WIP/* (optional, directory runs only)")
+	a.excludeEntry.SetText(config.ExcludePatterns)
+	excludeInfo := container.NewBorder(nil, nil, widget.NewLabel("Exclude:"), nil, a.excludeEntry)
+
+	a.docTypeSelect = widget.NewSelect(append([]string{allDocumentTypes}, a.documentTypes...), nil)
+	a.docTypeSelect.SetSelected(allDocumentTypes)
+	docTypeInfo := container.NewBorder(nil, nil, widget.NewLabel("Document type:"), nil, a.docTypeSelect)
+
 	progressSection := container.NewVBox(
 		a.status,
 		a.progress,
@@ -74,7 +128,11 @@ func (a *App) Run() {
 
 	content := container.NewVBox(
 		buttons,
+		instanceInfo,
 		pathInfo,
+		subtreeInfo,
+		excludeInfo,
+		docTypeInfo,
 		progressSection,
 		logScroll,
 	)
@@ -84,38 +142,247 @@ func (a *App) Run() {
 	a.window.ShowAndRun()
 }
 
+// RenderProgress shows progress events in the status label and progress bar
+// until the channel is closed.
+func (a *App) RenderProgress(events <-chan progress.Event) {
+	for event := range events {
+		status := event.Message
+		if event.Total > 0 {
+			status = fmt.Sprintf("%s (%d/%d)", event.Message, event.Current, event.Total)
+		}
+		a.SetStatus(status)
+		a.SetProgress(event.Fraction)
+	}
+}
+
 func (a *App) SetStatus(status string) {
-	a.status.SetText(status)
+	a.updates.Status(status)
 }
 
 func (a *App) SetProgress(value float64) {
-	a.progress.SetValue(value)
+	a.updates.Progress(value)
+}
+
+// applyUpdates draws a batch of changes collected by the uiUpdater.
+func (a *App) applyUpdates(state uiState) {
+	if state.clearLog || len(state.lines) > 0 {
+		if state.clearLog {
+			a.logLines.clear()
+		}
+		for _, line := range state.lines {
+			a.logLines.add(line)
+		}
+		a.logView.SetText(a.logLines.String())
+	}
+	if state.status != nil {
+		a.status.SetText(*state.status)
+	}
+	if state.progress != nil {
+		a.progress.SetValue(*state.progress)
+	}
+	for _, call := range state.calls {
+		call()
+	}
 }
 
 func (a *App) GetLogView() *widget.TextGrid {
 	return a.logView
 }
 
+const errorLogLines = 20
+
+// ShowError shows the message with the most recent log lines in a selectable
+// text box, plus a button to copy everything for a support ticket.
 func (a *App) ShowError(title, message string) {
-	dialog.ShowError(fmt.Errorf(message), a.window)
+	details := errorDetails(message, logging.GetLogger().RecentLines(errorLogLines))
+	a.updates.Do(func() { a.showErrorDialog(title, details) })
+}
+
+// errorDetails is the text shown and copied for an error.
+func errorDetails(message string, recentLog []string) string {
+	if len(recentLog) == 0 {
+		return message
+	}
+	return message + "\n\nRecent log:\n" + strings.Join(recentLog, "\n")
+}
+
+func (a *App) showErrorDialog(title, details string) {
+	detailsEntry := widget.NewMultiLineEntry()
+	detailsEntry.SetText(details)
+	detailsEntry.Wrapping = fyne.TextWrapWord
+
+	copyBtn := widget.NewButton("Copy details", func() {
+		a.window.Clipboard().SetContent(details)
+	})
+
+	content := container.NewBorder(nil, copyBtn, nil, nil, detailsEntry)
+	errorDialog := dialog.NewCustom(title, "Close", content, a.window)
+	errorDialog.Resize(fyne.NewSize(600, 400))
+	errorDialog.Show()
+}
+
+// ShowStartupError shows a problem that prevents the app from starting, such
+// as an incomplete build configuration, in a window of its own and returns
+// once the user closes it.
+func ShowStartupError(title, message string) {
+	a := app.NewWithID("com.orait.document-uploader")
+	w := a.NewWindow("Document Uploader - " + title)
+
+	messageEntry := widget.NewMultiLineEntry()
+	messageEntry.SetText(message)
+	messageEntry.Wrapping = fyne.TextWrapWord
+
+	buttons := container.NewHBox(
+		widget.NewButton("Copy details", func() {
+			w.Clipboard().SetContent(message)
+		}),
+		widget.NewButton("Quit", a.Quit),
+	)
+
+	w.SetContent(container.NewBorder(widget.NewLabel(title), buttons, nil, nil, messageEntry))
+	w.Resize(fyne.NewSize(600, 300))
+	w.ShowAndRun()
 }
 
 func (a *App) SetProcessingHandler(handler func()) {
 	a.processingHandler = handler
 }
 
+// SetResultsURL enables the Open in Salesforce button for the records of the
+// last run. An empty URL disables it.
+func (a *App) SetResultsURL(url string) {
+	a.updates.Do(func() {
+		a.resultsURL = url
+		if url == "" {
+			a.openResultsBtn.Disable()
+			return
+		}
+		a.openResultsBtn.Enable()
+	})
+}
+
+// handleOpenResults reads resultsURL on the updater, which is the only place
+// it is set.
+func (a *App) handleOpenResults() {
+	a.updates.Do(func() {
+		if a.resultsURL == "" {
+			return
+		}
+		if err := browser.OpenURL(a.resultsURL); err != nil {
+			a.ShowError("Error", fmt.Sprintf("Failed to open browser: %v", err))
+		}
+	})
+}
+
+// SetSignOutHandler sets the action run by the Sign out button.
+func (a *App) SetSignOutHandler(handler func()) {
+	a.signOutHandler = handler
+}
+
+func (a *App) handleSignOut() {
+	if a.state.running() {
+		logging.GetLogger().Warning("Cannot sign out while processing")
+		return
+	}
+	if a.signOutHandler != nil {
+		go a.signOutHandler()
+	}
+}
+
+// SetDiagnosticsHandler sets how the diagnostics bundle is created. The
+// handler returns the path of the bundle.
+func (a *App) SetDiagnosticsHandler(handler func() (string, error)) {
+	a.diagnostics = handler
+}
+
+func (a *App) handleDiagnostics() {
+	if a.diagnostics == nil {
+		return
+	}
+	go func() {
+		path, err := a.diagnostics()
+		if err != nil {
+			a.ShowError("Diagnostics Error", err.Error())
+			return
+		}
+		logging.GetLogger().Success("🧰 Diagnostics bundle saved to %s", path)
+		a.updates.Do(func() {
+			dialog.ShowInformation("Diagnostics bundle",
+				"Saved to "+path+"\nAttach this file to your support ticket.", a.window)
+		})
+	}()
+}
+
+func (a *App) SetFileParser(parser func(path string) (*models.DocumentInfo, error)) {
+	a.fileParser = parser
+}
+
 func (a *App) Reset() {
-	a.processStarted = false
-	a.progress.SetValue(0)
+	a.state.reset()
+	a.SetProgress(0)
 	a.SetStatus("Ready to start")
-	a.startBtn.Enable()
-	a.logView.SetText("")
+	a.syncStartButton()
+	a.SetResultsURL("")
+	a.updates.ClearLog()
+}
+
+// Complete marks the run as finished. Start Processing stays disabled until
+// something new is selected.
+func (a *App) Complete() {
+	a.state.complete()
+	a.syncStartButton()
+}
+
+// syncStartButton enables Start Processing only when a run can start. The
+// button follows the state as it is when the change is drawn, so callers on
+// any goroutine can use it.
+func (a *App) syncStartButton() {
+	a.updates.Do(func() {
+		if a.state.canStart() {
+			a.startBtn.Enable()
+		} else {
+			a.startBtn.Disable()
+		}
+	})
 }
 
 func (a *App) handleStartProcessing() {
 	logger := logging.GetLogger()
 
-	if a.processStarted {
+	if a.resumeReport != "" {
+		if !a.state.start() {
+			return
+		}
+		a.syncStartButton()
+		if a.resumePreviewer == nil {
+			a.startResumeRun()
+			return
+		}
+
+		a.SetStatus("Comparing the report with the files on disk...")
+		go func() {
+			summary, err := a.resumePreviewer(a.resumeReport)
+			if err != nil {
+				logger.Warning("Could not preview the resume: %v", err)
+				a.startResumeRun()
+				return
+			}
+			a.confirmStart("Resume run?", summary, a.startResumeRun)
+		}()
+		return
+	}
+
+	if len(a.selectedFiles) > 0 {
+		if !a.state.start() {
+			return
+		}
+		a.syncStartButton()
+		a.SetProgress(0)
+		logger.Info("🚀 Starting processing of %d selected files...", len(a.selectedFiles))
+
+		if a.processingHandler != nil {
+			go a.processingHandler()
+		}
 		return
 	}
 
@@ -130,20 +397,81 @@ func (a *App) handleStartProcessing() {
 		a.ShowError("Error", fmt.Sprintf("Selected directory no longer exists: %s", a.documentsPath))
 		a.documentsPath = ""
 		a.pathLabel.SetText("No directory selected")
-		a.startBtn.Disable()
+		a.state.cleared()
+		a.syncStartButton()
 		return
 	}
 
-	a.processStarted = true
-	a.startBtn.Disable()
-	a.progress.SetValue(0)
-	logger.Info("🚀 Starting processing...")
+	if !a.state.start() {
+		return
+	}
+	a.syncStartButton()
+	if a.uploadEstimator == nil {
+		a.startDirectoryRun()
+		return
+	}
+
+	a.SetStatus("Estimating upload size...")
+	go func() {
+		summary, err := a.uploadEstimator(a.GetDirectoryRun())
+		if err != nil {
+			logger.Warning("Could not estimate the upload: %v", err)
+			a.startDirectoryRun()
+			return
+		}
+
+		a.confirmStart("Start upload?", summary, a.startDirectoryRun)
+	}()
+}
+
+// confirmStart asks before a run starts, releasing the run state again when
+// the user declines. It is called from the estimate and resume preview
+// goroutines, so the dialog is opened through the updater.
+func (a *App) confirmStart(title, summary string, start func()) {
+	a.updates.Do(func() {
+		dialog.ShowConfirm(title, summary, func(confirmed bool) {
+			if !confirmed {
+				logging.GetLogger().Info("Upload cancelled")
+				a.state.cancel()
+				a.syncStartButton()
+				a.SetStatus("Ready to start")
+				return
+			}
+			start()
+		}, a.window)
+	})
+}
+
+func (a *App) startResumeRun() {
+	a.SetProgress(0)
+	logging.GetLogger().Info("🚀 Resuming from report %s...", filepath.Base(a.resumeReport))
 
 	if a.processingHandler != nil {
 		go a.processingHandler()
 	}
 }
 
+func (a *App) startDirectoryRun() {
+	a.SetProgress(0)
+	logging.GetLogger().Info("🚀 Starting processing...")
+
+	if a.processingHandler != nil {
+		go a.processingHandler()
+	}
+}
+
+// SetUploadEstimator sets the function that summarizes a directory run's
+// size and duration for the confirmation shown before it starts.
+func (a *App) SetUploadEstimator(estimator func(run models.DirectoryRun) (string, error)) {
+	a.uploadEstimator = estimator
+}
+
+// SetResumePreviewer sets the function that lists what resuming a run
+// report will upload, shown for confirmation before the resume starts.
+func (a *App) SetResumePreviewer(previewer func(reportPath string) (string, error)) {
+	a.resumePreviewer = previewer
+}
+
 func (a *App) handleDirectorySelection() {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -164,38 +492,269 @@ func (a *App) handleDirectorySelection() {
 			return
 		}
 
-		a.Reset()
-
-		path := uri.Path()
+		path := normalizeDir(uri.Path())
 		if _, err := os.Stat(path); os.IsNotExist(err) {
 			logger.Error("Selected directory does not exist: %s", path)
 			a.ShowError("Error", "Selected directory does not exist")
 			return
 		}
 
+		a.Reset()
+		a.selectedFiles = nil
+		a.resumeReport = ""
 		a.documentsPath = path
 		a.pathLabel.SetText(filepath.Base(path))
 		logger.Success("📁 Selected directory: %s", path)
-		a.startBtn.Enable()
+		if hint := filestructure.CheckRootLevel(path); hint != "" {
+			logger.Warning("Check your selection: %s", hint)
+		}
+		if warning := filestructure.CheckAppDirectory(path, filestructure.AppPaths()); warning != "" {
+			logger.Warning("Check your selection: %s", warning)
+		}
+		a.state.selected()
+		a.syncStartButton()
 	}, a.window)
 
-	startURI, err := storage.ParseURI("file://" + cwd)
-	if err == nil {
-		listURI, err := storage.ListerForURI(startURI)
-		if err == nil && listURI != nil {
-			folderDialog.SetLocation(listURI)
+	setDialogLocation(folderDialog, cwd)
+	folderDialog.Show()
+}
+
+func (a *App) handleFileSelection() {
+	logger := logging.GetLogger()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		logger.Error("Failed to get current directory: %v", err)
+		a.ShowError("Error", "Failed to get current directory: "+err.Error())
+		return
+	}
+
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			logger.Error("File selection failed: %v", err)
+			a.ShowError("File Selection Error", err.Error())
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		for _, selected := range a.selectedFiles {
+			if selected == path {
+				logger.Warning("File already selected: %s", filepath.Base(path))
+				return
+			}
 		}
+
+		if a.fileParser != nil {
+			doc, err := a.fileParser(path)
+			if err != nil {
+				logger.Error("Cannot use %s: %v", filepath.Base(path), err)
+				a.ShowError("Invalid File", err.Error())
+				return
+			}
+			logger.Info("📄 %s → %s %s (%s)", filepath.Base(path), doc.EntityType, doc.DocumentType, formatNamePath(doc.NamePath))
+		}
+
+		// The previous directory or report is only dropped once the file is
+		// accepted, so a rejected file leaves the old selection intact.
+		if a.documentsPath != "" || a.resumeReport != "" {
+			a.Reset()
+			a.documentsPath = ""
+			a.resumeReport = ""
+		}
+
+		a.selectedFiles = append(a.selectedFiles, path)
+		a.pathLabel.SetText(fmt.Sprintf("%d file(s) selected", len(a.selectedFiles)))
+		a.state.selected()
+		a.syncStartButton()
+	}, a.window)
+
+	setDialogLocation(fileDialog, cwd)
+	fileDialog.Show()
+}
+
+func (a *App) handleReportSelection() {
+	logger := logging.GetLogger()
+
+	reportsDir, err := os.Getwd()
+	if err != nil {
+		logger.Error("Failed to get current directory: %v", err)
+		a.ShowError("Error", "Failed to get current directory: "+err.Error())
+		return
+	}
+	if _, err := os.Stat(filepath.Join(reportsDir, "reports")); err == nil {
+		reportsDir = filepath.Join(reportsDir, "reports")
 	}
 
-	folderDialog.Show()
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			logger.Error("Report selection failed: %v", err)
+			a.ShowError("Report Selection Error", err.Error())
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		a.Reset()
+		a.documentsPath = ""
+		a.selectedFiles = nil
+		a.resumeReport = path
+		a.pathLabel.SetText("Resume: " + filepath.Base(path))
+		logger.Success("📋 Selected run report: %s", path)
+		a.state.selected()
+		a.syncStartButton()
+	}, a.window)
+
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	setDialogLocation(fileDialog, reportsDir)
+	fileDialog.Show()
+}
+
+func setDialogLocation(fileDialog *dialog.FileDialog, dir string) {
+	startURI, err := storage.ParseURI("file://" + dir)
+	if err != nil {
+		return
+	}
+	listURI, err := storage.ListerForURI(startURI)
+	if err == nil && listURI != nil {
+		fileDialog.SetLocation(listURI)
+	}
+}
+
+func formatNamePath(namePath map[string]string) string {
+	var parts []string
+	for _, key := range []string{"project", "phase", "zone", "building", "unit", "designType"} {
+		if value, ok := namePath[key]; ok {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " / ")
 }
 
 func (a *App) GetDocumentsPath() string {
 	if a.documentsPath == "" {
 		return ""
 	}
-	if _, err := os.Stat(a.documentsPath); os.IsNotExist(err) {
+	path := normalizeDir(a.documentsPath)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ""
+	}
+	return path
+}
+
+// normalizeDir makes a selected directory absolute and clean, so a trailing
+// separator or relative path never leaks into the relative paths of files.
+func normalizeDir(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return absPath
+}
+
+func (a *App) GetSelectedFiles() []string {
+	return a.selectedFiles
+}
+
+// GetDirectoryRun returns the selected directory with the filters entered
+// for it.
+func (a *App) GetDirectoryRun() models.DirectoryRun {
+	return models.DirectoryRun{
+		DocumentsDir:    a.GetDocumentsPath(),
+		SubtreeFilter:   a.GetSubtreeFilter(),
+		ExcludePatterns: a.GetExcludePatterns(),
+		DocumentType:    a.GetDocumentTypeFilter(),
+	}
+}
+
+// GetSubtreeFilter returns the entity path the user restricted the run to,
+// or an empty string to process the whole directory.
+func (a *App) GetSubtreeFilter() string {
+	if a.subtreeEntry == nil {
 		return ""
 	}
-	return a.documentsPath
+	return strings.TrimSpace(a.subtreeEntry.Text)
+}
+
+// GetExcludePatterns returns the comma-separated glob patterns of files to
+// leave out of a directory run.
+func (a *App) GetExcludePatterns() string {
+	if a.excludeEntry == nil {
+		return ""
+	}
+	return strings.TrimSpace(a.excludeEntry.Text)
+}
+
+// allDocumentTypes is the document type choice that applies no filter.
+const allDocumentTypes = "All types"
+
+// SetDocumentTypes sets the types offered for restricting a directory run to
+// one type, e.g. to re-upload all galleries. It must be called before Run.
+func (a *App) SetDocumentTypes(types []string) {
+	a.documentTypes = types
+}
+
+// GetDocumentTypeFilter returns the document type the user restricted the
+// run to, or an empty string to process every type.
+func (a *App) GetDocumentTypeFilter() string {
+	if a.docTypeSelect == nil || a.docTypeSelect.Selected == allDocumentTypes {
+		return ""
+	}
+	return a.docTypeSelect.Selected
+}
+
+// GetInstanceURL returns the instance URL entered for this session, which
+// starts out as the one from the configuration.
+func (a *App) GetInstanceURL() string {
+	if a.instanceEntry == nil {
+		return ""
+	}
+	return strings.TrimSpace(a.instanceEntry.Text)
+}
+
+func (a *App) GetResumeReport() string {
+	return a.resumeReport
+}
+
+const environmentPreferenceKey = "environment"
+
+func (a *App) newEnvironmentSelect() *widget.Select {
+	if len(config.OrgEnvironments) < 2 {
+		return nil
+	}
+
+	logger := logging.GetLogger()
+	saved := a.fyneApp.Preferences().StringWithFallback(environmentPreferenceKey, config.DefaultEnvironmentName)
+	if err := config.SelectEnvironment(saved); err != nil {
+		logger.Warning("Saved environment %q is no longer configured, using %s", saved, config.DefaultEnvironmentName)
+		config.SelectEnvironment(config.DefaultEnvironmentName)
+	}
+
+	var envSelect *widget.Select
+	envSelect = widget.NewSelect(config.EnvironmentNames(), func(name string) {
+		if name == config.CurrentEnvironment {
+			return
+		}
+		if a.state.running() {
+			logger.Warning("Cannot switch environments while processing")
+			envSelect.SetSelected(config.CurrentEnvironment)
+			return
+		}
+		if err := config.SelectEnvironment(name); err != nil {
+			a.ShowError("Environment Error", err.Error())
+			return
+		}
+		a.fyneApp.Preferences().SetString(environmentPreferenceKey, name)
+		a.instanceEntry.SetText(config.SFInstanceURL)
+		logger.Info("🌐 Target org: %s (%s)", name, config.SFInstanceURL)
+	})
+	envSelect.SetSelected(config.CurrentEnvironment)
+	logger.Info("🌐 Target org: %s (%s)", config.CurrentEnvironment, config.SFInstanceURL)
+	return envSelect
 }
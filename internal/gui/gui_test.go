@@ -0,0 +1,94 @@
+package gui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+)
+
+func TestDocumentsPathNormalized(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "docs")
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(building, "bl_front.jpg"), []byte("jpg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(parent); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previous)
+	// The temporary directory may be reached through a symlink, so compare
+	// against the path the working directory resolves to.
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(cwd, "docs")
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "absolute", path: want},
+		{name: "trailing separator", path: want + string(filepath.Separator)},
+		{name: "relative", path: "docs"},
+		{name: "relative with dot", path: "./docs/"},
+		{name: "parent references", path: "docs/Tower/../"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{documentsPath: tt.path}
+			got := a.GetDocumentsPath()
+			if got != want {
+				t.Fatalf("GetDocumentsPath() = %q, want %q", got, want)
+			}
+
+			documents, err := filestructure.NewDocumentWalker(got).Walk()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRel := filepath.Join("Tower", "P1", "Z1", "B1", "bl_front.jpg")
+			if len(documents) != 1 || documents[0].RelativePath != wantRel {
+				t.Errorf("Walk() = %+v, want one document at %s", documents, wantRel)
+			}
+		})
+	}
+
+	if got := (&App{documentsPath: "missing"}).GetDocumentsPath(); got != "" {
+		t.Errorf("GetDocumentsPath() for a missing directory = %q, want empty", got)
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		recentLog []string
+		want      string
+	}{
+		{name: "no log", message: "Upload failed", want: "Upload failed"},
+		{
+			name:      "with log",
+			message:   "Upload failed",
+			recentLog: []string{"[INFO] Uploading 3 files", "[ERROR] composite request failed"},
+			want:      "Upload failed\n\nRecent log:\n[INFO] Uploading 3 files\n[ERROR] composite request failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorDetails(tt.message, tt.recentLog); got != tt.want {
+				t.Errorf("errorDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
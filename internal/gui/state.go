@@ -0,0 +1,97 @@
+package gui
+
+import "sync"
+
+// runPhase is where the window is in a run. The transitions live here,
+// apart from the widgets, so the buttons only mirror the current phase.
+type runPhase int
+
+const (
+	// phaseIdle has nothing selected to upload.
+	phaseIdle runPhase = iota
+	// phaseReady has a selection and can start.
+	phaseReady
+	// phaseRunning has a run in progress, including the confirmation
+	// before a directory run.
+	phaseRunning
+	// phaseDone has finished a run; a new selection makes it ready again.
+	phaseDone
+)
+
+// runState is changed from the UI callbacks and from the processing and
+// updater goroutines, so every method holds the mutex.
+type runState struct {
+	mutex sync.Mutex
+	phase runPhase
+}
+
+// selected records a new selection. It is ignored while a run is in
+// progress.
+func (s *runState) selected() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase != phaseRunning {
+		s.phase = phaseReady
+	}
+}
+
+// cleared records that the selection is gone, e.g. the directory was deleted.
+func (s *runState) cleared() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase != phaseRunning {
+		s.phase = phaseIdle
+	}
+}
+
+// start moves a ready selection into a run and reports whether it did.
+func (s *runState) start() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase != phaseReady {
+		return false
+	}
+	s.phase = phaseRunning
+	return true
+}
+
+// cancel abandons a run before it sends anything, keeping the selection.
+func (s *runState) cancel() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase == phaseRunning {
+		s.phase = phaseReady
+	}
+}
+
+// complete ends a successful run.
+func (s *runState) complete() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase == phaseRunning {
+		s.phase = phaseDone
+	}
+}
+
+// reset ends a failed run, or starts over after a new selection, leaving the
+// selection ready to start again. Without a selection there is nothing to
+// start, so an idle window stays idle.
+func (s *runState) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.phase != phaseIdle {
+		s.phase = phaseReady
+	}
+}
+
+func (s *runState) running() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.phase == phaseRunning
+}
+
+func (s *runState) canStart() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.phase == phaseReady
+}
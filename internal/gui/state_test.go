@@ -0,0 +1,58 @@
+package gui
+
+import "testing"
+
+func TestRunState(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []func(*runState)
+		want  runPhase
+	}{
+		{"new window", nil, phaseIdle},
+		{"reset without a selection", []func(*runState){(*runState).reset}, phaseIdle},
+		{"selected", []func(*runState){(*runState).selected}, phaseReady},
+		{"reset keeps a selection", []func(*runState){(*runState).selected, (*runState).reset}, phaseReady},
+		{"started", []func(*runState){(*runState).selected, startRun}, phaseRunning},
+		{"start without a selection", []func(*runState){startRun}, phaseIdle},
+		{"completed", []func(*runState){(*runState).selected, startRun, (*runState).complete}, phaseDone},
+		{"failed run reset", []func(*runState){(*runState).selected, startRun, (*runState).reset}, phaseReady},
+		{"declined confirmation", []func(*runState){(*runState).selected, startRun, (*runState).cancel}, phaseReady},
+		{"selection while running", []func(*runState){(*runState).selected, startRun, (*runState).selected}, phaseRunning},
+		{"cleared while running", []func(*runState){(*runState).selected, startRun, (*runState).cleared}, phaseRunning},
+		{"cleared", []func(*runState){(*runState).selected, (*runState).cleared}, phaseIdle},
+		{"new selection after a run", []func(*runState){(*runState).selected, startRun, (*runState).complete, (*runState).selected}, phaseReady},
+		{"complete without a run", []func(*runState){(*runState).selected, (*runState).complete}, phaseReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s runState
+			for _, step := range tt.steps {
+				step(&s)
+			}
+			if s.phase != tt.want {
+				t.Fatalf("phase = %d, want %d", s.phase, tt.want)
+			}
+			if s.canStart() != (tt.want == phaseReady) {
+				t.Errorf("canStart() = %v in phase %d", s.canStart(), s.phase)
+			}
+			if s.running() != (tt.want == phaseRunning) {
+				t.Errorf("running() = %v in phase %d", s.running(), s.phase)
+			}
+		})
+	}
+}
+
+func startRun(s *runState) {
+	s.start()
+}
+
+func TestRunStateStartsOnce(t *testing.T) {
+	var s runState
+	s.selected()
+	if !s.start() {
+		t.Fatal("first start() = false, want true")
+	}
+	if s.start() {
+		t.Fatal("second start() = true while running, want false")
+	}
+}
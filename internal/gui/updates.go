@@ -0,0 +1,145 @@
+package gui
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// uiRefreshInterval is the shortest time between two redraws caused by
+// background work.
+const uiRefreshInterval = 100 * time.Millisecond
+
+// maxLogLines is how many lines the log view keeps. Older lines are only in
+// the log file.
+const maxLogLines = 2000
+
+// uiState is the set of changes waiting to be drawn.
+type uiState struct {
+	clearLog bool
+	lines    []string
+	status   *string
+	progress *float64
+	calls    []func()
+}
+
+// uiUpdater collects GUI changes made from processing goroutines and applies
+// them from a single goroutine, at most once per uiRefreshInterval. A long
+// run logs hundreds of lines a second; redrawing the log for each of them
+// made the window flicker and let concurrent writers interleave.
+//
+// Every widget change and dialog started off the UI callbacks goes through
+// here, so run is the one place that touches widgets from the background.
+// Fyne 2.5 has no way to hand work to its main thread; once the dependency
+// moves to a release with fyne.Do, run passes each batch through it.
+type uiUpdater struct {
+	mutex   sync.Mutex
+	pending uiState
+	wake    chan struct{}
+}
+
+func newUIUpdater() *uiUpdater {
+	return &uiUpdater{wake: make(chan struct{}, 1)}
+}
+
+func (u *uiUpdater) AppendLog(line string) {
+	u.change(func(s *uiState) {
+		s.lines = append(s.lines, line)
+		if over := len(s.lines) - maxLogLines; over > 0 {
+			s.lines = s.lines[over:]
+		}
+	})
+}
+
+func (u *uiUpdater) ClearLog() {
+	u.change(func(s *uiState) {
+		s.clearLog = true
+		s.lines = nil
+	})
+}
+
+func (u *uiUpdater) Status(status string) {
+	u.change(func(s *uiState) {
+		s.status = &status
+	})
+}
+
+func (u *uiUpdater) Progress(value float64) {
+	u.change(func(s *uiState) {
+		s.progress = &value
+	})
+}
+
+// Do queues fn to run with the next batch, after the log, status and
+// progress changes queued before it. Calls run in the order they were queued.
+func (u *uiUpdater) Do(fn func()) {
+	u.change(func(s *uiState) {
+		s.calls = append(s.calls, fn)
+	})
+}
+
+func (u *uiUpdater) change(update func(*uiState)) {
+	u.mutex.Lock()
+	update(&u.pending)
+	u.mutex.Unlock()
+
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (u *uiUpdater) take() uiState {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	state := u.pending
+	u.pending = uiState{}
+	return state
+}
+
+// run applies pending changes until the program exits. Everything that
+// arrives while a batch is drawn or during the pause after it is applied
+// together in the next batch.
+func (u *uiUpdater) run(apply func(uiState)) {
+	for range u.wake {
+		apply(u.take())
+		time.Sleep(uiRefreshInterval)
+	}
+}
+
+// logRing holds the last maxLogLines lines shown in the log view, so each
+// redraw costs the same however long the run has been going.
+type logRing struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+func newLogRing(size int) *logRing {
+	return &logRing{lines: make([]string, size)}
+}
+
+func (r *logRing) add(line string) {
+	r.lines[r.next] = line
+	r.next++
+	if r.next == len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+func (r *logRing) clear() {
+	r.next = 0
+	r.full = false
+}
+
+// String returns the lines oldest first, one per line.
+func (r *logRing) String() string {
+	if !r.full {
+		return strings.Join(r.lines[:r.next], "\n")
+	}
+	ordered := make([]string, 0, len(r.lines))
+	ordered = append(ordered, r.lines[r.next:]...)
+	ordered = append(ordered, r.lines[:r.next]...)
+	return strings.Join(ordered, "\n")
+}
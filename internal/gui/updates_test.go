@@ -0,0 +1,117 @@
+package gui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUIUpdaterCoalesces(t *testing.T) {
+	u := newUIUpdater()
+	var order []string
+
+	u.AppendLog("one")
+	u.Status("first")
+	u.Progress(0.25)
+	u.Do(func() { order = append(order, "a") })
+	u.AppendLog("two")
+	u.Status("second")
+	u.Progress(0.5)
+	u.Do(func() { order = append(order, "b") })
+
+	state := u.take()
+	if !reflect.DeepEqual(state.lines, []string{"one", "two"}) {
+		t.Errorf("lines = %q, want [one two]", state.lines)
+	}
+	if state.status == nil || *state.status != "second" {
+		t.Errorf("status = %v, want second", state.status)
+	}
+	if state.progress == nil || *state.progress != 0.5 {
+		t.Errorf("progress = %v, want 0.5", state.progress)
+	}
+	for _, call := range state.calls {
+		call()
+	}
+	if !reflect.DeepEqual(order, []string{"a", "b"}) {
+		t.Errorf("calls ran as %q, want [a b]", order)
+	}
+
+	if next := u.take(); next.lines != nil || next.status != nil || next.calls != nil {
+		t.Errorf("take left changes behind: %+v", next)
+	}
+}
+
+func TestUIUpdaterClearLog(t *testing.T) {
+	u := newUIUpdater()
+	u.AppendLog("old")
+	u.ClearLog()
+	u.AppendLog("new")
+
+	state := u.take()
+	if !state.clearLog {
+		t.Error("clearLog = false, want true")
+	}
+	if !reflect.DeepEqual(state.lines, []string{"new"}) {
+		t.Errorf("lines = %q, want [new]", state.lines)
+	}
+}
+
+func TestUIUpdaterCapsPendingLines(t *testing.T) {
+	u := newUIUpdater()
+	for i := 0; i < maxLogLines+10; i++ {
+		u.AppendLog(fmt.Sprint(i))
+	}
+
+	lines := u.take().lines
+	if len(lines) != maxLogLines {
+		t.Fatalf("%d pending lines, want %d", len(lines), maxLogLines)
+	}
+	if lines[0] != "10" {
+		t.Errorf("oldest pending line = %s, want 10", lines[0])
+	}
+}
+
+func TestLogRing(t *testing.T) {
+	tests := []struct {
+		name  string
+		add   int
+		clear bool
+		want  string
+	}{
+		{name: "empty", want: ""},
+		{name: "partly filled", add: 2, want: "0\n1"},
+		{name: "exactly full", add: 3, want: "0\n1\n2"},
+		{name: "wrapped", add: 5, want: "2\n3\n4"},
+		{name: "wrapped twice", add: 7, want: "4\n5\n6"},
+		{name: "cleared", add: 5, clear: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newLogRing(3)
+			for i := 0; i < tt.add; i++ {
+				ring.add(fmt.Sprint(i))
+			}
+			if tt.clear {
+				ring.clear()
+			}
+			if got := ring.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogRingAfterClear(t *testing.T) {
+	ring := newLogRing(3)
+	for _, line := range strings.Fields("a b c d") {
+		ring.add(line)
+	}
+	ring.clear()
+	ring.add("e")
+
+	if got := ring.String(); got != "e" {
+		t.Errorf("String() = %q, want %q", got, "e")
+	}
+}
@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrSalesforceUnavailable = errors.New("Salesforce appears to be unavailable")
+
+// breakerTransport fails fast once threshold requests in a row have failed,
+// instead of letting every remaining request and retry hit a broken org.
+// After cooldown a single request is let through; success closes the breaker.
+type breakerTransport struct {
+	base      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	lastError string
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.allow(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.recordFailure(err.Error())
+	case isFailureStatus(resp.StatusCode):
+		t.recordFailure(fmt.Sprintf("%s %s returned status %d", req.Method, req.URL.Path, resp.StatusCode))
+	default:
+		t.recordSuccess()
+	}
+	return resp, err
+}
+
+// isFailureStatus reports whether a response means the org is failing. A
+// 401 does not: an expired session answers every request with one until
+// the token is refreshed, and that refresh goes through this client too.
+func isFailureStatus(status int) bool {
+	return status >= 500
+}
+
+func (t *breakerTransport) allow() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.failures < t.threshold {
+		return nil
+	}
+	if time.Now().Before(t.openUntil) {
+		return fmt.Errorf("%w: %d consecutive requests failed, last error: %s",
+			ErrSalesforceUnavailable, t.failures, t.lastError)
+	}
+	// Half-open: let this request probe the org and hold the rest back.
+	t.openUntil = time.Now().Add(t.cooldown)
+	return nil
+}
+
+func (t *breakerTransport) recordFailure(message string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.failures++
+	t.lastError = message
+	if t.failures >= t.threshold {
+		t.openUntil = time.Now().Add(t.cooldown)
+	}
+}
+
+func (t *breakerTransport) recordSuccess() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.failures = 0
+	t.lastError = ""
+}
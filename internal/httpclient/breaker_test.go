@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// scriptedTransport answers with the given statuses in turn; 0 stands for a
+// transport error.
+func scriptedTransport(statuses ...int) (http.RoundTripper, *int) {
+	calls := 0
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		if status == 0 {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}), &calls
+}
+
+func TestBreakerTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantOpen  bool
+		wantCalls int
+	}{
+		{"server errors open", []int{500, 503, 502, 200}, true, 3},
+		{"transport errors open", []int{0, 0, 0, 200}, true, 3},
+		{"expired session does not open", []int{401, 401, 401, 401}, false, 4},
+		{"client errors do not open", []int{400, 404, 429, 200}, false, 4},
+		{"success resets the count", []int{500, 500, 200, 500, 500, 200}, false, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, calls := scriptedTransport(tt.statuses...)
+			breaker := &breakerTransport{base: base, threshold: 3, cooldown: time.Hour}
+
+			var lastErr error
+			for i := 0; i < 4; i++ {
+				req, _ := http.NewRequest(http.MethodGet, "https://acme.my.salesforce.com/services/data", nil)
+				resp, err := breaker.RoundTrip(req)
+				if resp != nil {
+					resp.Body.Close()
+				}
+				lastErr = err
+			}
+
+			if open := errors.Is(lastErr, ErrSalesforceUnavailable); open != tt.wantOpen {
+				t.Errorf("open = %v (last error %v), want %v", open, lastErr, tt.wantOpen)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("%d requests reached the org, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBreakerTransportHalfOpen(t *testing.T) {
+	base, calls := scriptedTransport(500, 500, 500, 200, 200)
+	breaker := &breakerTransport{base: base, threshold: 3, cooldown: 20 * time.Millisecond}
+	send := func() error {
+		req, _ := http.NewRequest(http.MethodGet, "https://acme.my.salesforce.com/services/data", nil)
+		resp, err := breaker.RoundTrip(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		send()
+	}
+	if err := send(); !errors.Is(err, ErrSalesforceUnavailable) {
+		t.Fatalf("breaker not open: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := send(); err != nil {
+		t.Fatalf("probe after cooldown failed: %v", err)
+	}
+	if err := send(); err != nil {
+		t.Fatalf("breaker not closed after a successful probe: %v", err)
+	}
+	if *calls != 5 {
+		t.Errorf("%d requests reached the org, want 5", *calls)
+	}
+}
+
+func TestBreakerTransportHalfOpenHoldsOthersBack(t *testing.T) {
+	base, calls := scriptedTransport(500)
+	breaker := &breakerTransport{base: base, threshold: 1, cooldown: 20 * time.Millisecond}
+	req, _ := http.NewRequest(http.MethodGet, "https://acme.my.salesforce.com/services/data", nil)
+
+	breaker.RoundTrip(req)
+	time.Sleep(30 * time.Millisecond)
+	breaker.RoundTrip(req) // the probe, which fails again
+	if _, err := breaker.RoundTrip(req); !errors.Is(err, ErrSalesforceUnavailable) {
+		t.Errorf("breaker not reopened after a failed probe: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("%d requests reached the org, want 2", *calls)
+	}
+}
@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// New builds the HTTP client shared by authentication and all Salesforce
+// calls, so pooling, timeouts, proxy and TLS settings live in one place.
+func New() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 20
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %v", config.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate %s: %v", config.CACertFile, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if config.GzipRequests {
+		roundTripper = &gzipTransport{base: roundTripper}
+	}
+	if config.CircuitBreakerThreshold > 0 {
+		roundTripper = &breakerTransport{
+			base:      roundTripper,
+			threshold: config.CircuitBreakerThreshold,
+			cooldown:  config.CircuitBreakerCooldown,
+		}
+	}
+
+	return &http.Client{
+		Transport:     roundTripper,
+		Timeout:       config.HTTPTimeout,
+		CheckRedirect: checkRedirect(config.RedirectHosts),
+	}, nil
+}
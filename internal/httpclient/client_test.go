@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// TestNewReusesConnections checks that requests through the shared client
+// reuse one pooled connection instead of dialling each time.
+func TestNewReusesConnections(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if n := connections.Load(); n != 1 {
+		t.Errorf("%d connections opened for 5 requests, want 1", n)
+	}
+}
+
+func TestNewSettings(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		proxy   string
+		caFile  string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "proxy", proxy: "http://proxy.example.com:3128"},
+		{name: "invalid proxy", proxy: "http://[::1", wantErr: true},
+		{name: "missing CA file", caFile: filepath.Join(dir, "missing.pem"), wantErr: true},
+		{name: "CA file without certificates", caFile: notPEM, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousProxy, previousCA := config.ProxyURL, config.CACertFile
+			config.ProxyURL, config.CACertFile = tt.proxy, tt.caFile
+			defer func() { config.ProxyURL, config.CACertFile = previousProxy, previousCA }()
+
+			client, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr || tt.proxy == "" {
+				return
+			}
+			req := httptest.NewRequest(http.MethodGet, "https://acme.my.salesforce.com", nil)
+			proxyURL, err := client.Transport.(*http.Transport).Proxy(req)
+			if err != nil || proxyURL.String() != tt.proxy {
+				t.Errorf("proxy = %v, %v, want %s", proxyURL, err, tt.proxy)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// gzipTransport compresses composite request bodies, which are mostly base64
+// file content. If the server rejects a compressed body the request is sent
+// again uncompressed and compression stays off for the rest of the session.
+// Compressed responses need no handling here: net/http already asks for and
+// transparently decodes gzip responses.
+type gzipTransport struct {
+	base     http.RoundTripper
+	disabled atomic.Bool
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.disabled.Load() || req.Body == nil || !strings.Contains(req.URL.Path, "/composite") ||
+		req.Header.Get("Content-Encoding") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := gzipBytes(body)
+	if err != nil {
+		return t.base.RoundTrip(withBody(req, body))
+	}
+
+	gzipped := withBody(req, compressed)
+	gzipped.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := t.base.RoundTrip(gzipped)
+	if err != nil || !rejectsEncoding(resp) {
+		return resp, err
+	}
+
+	resp.Body.Close()
+	t.disabled.Store(true)
+	return t.base.RoundTrip(withBody(req, body))
+}
+
+func rejectsEncoding(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnsupportedMediaType
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func withBody(req *http.Request, body []byte) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return clone
+}
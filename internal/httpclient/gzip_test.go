@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestGzipRequests(t *testing.T) {
+	const payload = `{"compositeRequest":[{"body":{"VersionData":"` + "QUFBQUFB" + `"}}]}`
+	tests := []struct {
+		name         string
+		acceptsGzip  bool
+		wantEncoding []string
+	}{
+		{name: "server decompresses", acceptsGzip: true, wantEncoding: []string{"gzip", "gzip"}},
+		// After one rejection the client stops compressing for the session.
+		{name: "server rejects gzip", acceptsGzip: false, wantEncoding: []string{"gzip", "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var encodings []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding := r.Header.Get("Content-Encoding")
+				encodings = append(encodings, encoding)
+				body := io.Reader(r.Body)
+				if encoding == "gzip" {
+					if !tt.acceptsGzip {
+						w.WriteHeader(http.StatusUnsupportedMediaType)
+						return
+					}
+					reader, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Errorf("request body is not gzip: %v", err)
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					body = reader
+				}
+				data, _ := io.ReadAll(body)
+				if string(data) != payload {
+					t.Errorf("server received %q, want %q", data, payload)
+				}
+			}))
+			defer server.Close()
+
+			previous := config.GzipRequests
+			config.GzipRequests = true
+			defer func() { config.GzipRequests = previous }()
+
+			client, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				resp, err := client.Post(server.URL+"/services/data/v62.0/composite", "application/json", strings.NewReader(payload))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("request %d status = %d, want 200", i, resp.StatusCode)
+				}
+			}
+
+			if !reflect.DeepEqual(encodings, tt.wantEncoding) {
+				t.Errorf("Content-Encoding per request = %q, want %q", encodings, tt.wantEncoding)
+			}
+		})
+	}
+}
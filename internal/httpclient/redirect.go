@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxRedirects matches the limit of net/http's default policy.
+const maxRedirects = 10
+
+// ErrUnexpectedRedirect is returned for a redirect to another host that is
+// not listed in REDIRECT_ALLOWED_HOSTS, or from https to http.
+var ErrUnexpectedRedirect = errors.New("unexpected redirect")
+
+// checkRedirect keeps the Authorization header on redirects within the same
+// host, such as a path moved by an API version change, and refuses to follow
+// redirects elsewhere unless the host is allowed. A moved instance would
+// otherwise surface as a puzzling 401 once the header has been dropped.
+func checkRedirect(allowedHosts []string) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		first := via[0]
+		if first.URL.Scheme == "https" && req.URL.Scheme != "https" {
+			return fmt.Errorf("%w from %s to insecure %s", ErrUnexpectedRedirect, first.URL.Host, req.URL.Redacted())
+		}
+
+		if strings.EqualFold(req.URL.Host, first.URL.Host) {
+			if auth := first.Header.Get("Authorization"); auth != "" && req.Header.Get("Authorization") == "" {
+				req.Header.Set("Authorization", auth)
+			}
+			return nil
+		}
+
+		for _, host := range allowedHosts {
+			if strings.EqualFold(req.URL.Hostname(), host) {
+				req.Header.Del("Authorization")
+				return nil
+			}
+		}
+		return fmt.Errorf("%w from %s to %s; if the org moved to a new domain, update SF_INSTANCE_URL",
+			ErrUnexpectedRedirect, first.URL.Host, req.URL.Host)
+	}
+}
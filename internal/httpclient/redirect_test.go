@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	tests := []struct {
+		name string
+		to   string
+		// auth is the Authorization header net/http left on the redirect.
+		auth     string
+		via      int
+		wantErr  bool
+		wantAuth string
+	}{
+		{name: "same host keeps authorization", to: "https://acme.my.salesforce.com/services/data/v58.0/", auth: "Bearer token", wantAuth: "Bearer token"},
+		{name: "same host restores authorization", to: "https://ACME.my.salesforce.com/x", wantAuth: "Bearer token"},
+		{name: "allowed host drops authorization", to: "https://files.example.com/x", auth: "Bearer token", wantAuth: ""},
+		{name: "other host refused", to: "https://evil.example.com/x", wantErr: true},
+		{name: "downgrade refused", to: "http://acme.my.salesforce.com/x", wantErr: true},
+		{name: "too many redirects", to: "https://acme.my.salesforce.com/x", via: maxRedirects, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, _ := http.NewRequest("GET", "https://acme.my.salesforce.com/services/data/v57.0/", nil)
+			first.Header.Set("Authorization", "Bearer token")
+			via := []*http.Request{first}
+			for len(via) < tt.via {
+				via = append(via, first)
+			}
+
+			req, _ := http.NewRequest("GET", tt.to, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+
+			err := checkRedirect([]string{"files.example.com"})(req, via)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("checkRedirect() followed the redirect")
+				}
+				if tt.via == 0 && !errors.Is(err, ErrUnexpectedRedirect) {
+					t.Errorf("checkRedirect() = %v, want ErrUnexpectedRedirect", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkRedirect() = %v", err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
-	"fyne.io/fyne/v2/widget"
+	"github.com/ORAITApps/document-uploader/internal/config"
 )
 
 type LogLevel int
@@ -60,10 +61,15 @@ var logConfigs = map[LogLevel]LogConfig{
 	},
 }
 
+// recentLineLimit is how many log lines are kept in memory for error reports.
+const recentLineLimit = 50
+
 type Logger struct {
-	logFile    *os.File
-	guiLogView *widget.TextGrid
-	mutex      sync.Mutex
+	logFile *os.File
+	fileErr error
+	guiSink func(line string)
+	recent  []string
+	mutex   sync.Mutex
 }
 
 var instance *Logger
@@ -73,12 +79,21 @@ func GetLogger() *Logger {
 	once.Do(func() {
 		instance = &Logger{}
 		if err := instance.initLogFile(); err != nil {
+			instance.fileErr = err
 			fmt.Printf("Failed to initialize logger: %v\n", err)
 		}
+		instance.applyRetention()
 	})
 	return instance
 }
 
+// FileErr returns why the log file could not be opened, or nil when messages
+// are written to it. Without a file, messages still reach the console and
+// the GUI.
+func (l *Logger) FileErr() error {
+	return l.fileErr
+}
+
 func (l *Logger) initLogFile() error {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
@@ -105,10 +120,29 @@ func (l *Logger) initLogFile() error {
 	return nil
 }
 
-func (l *Logger) SetGuiLogView(logView *widget.TextGrid) {
+// applyRetention removes log files from earlier days that fall outside
+// LOG_RETENTION_DAYS or LOG_RETENTION_MB.
+func (l *Logger) applyRetention() {
+	if l.logFile == nil {
+		return
+	}
+	current := l.logFile.Name()
+	removed, err := removeOldLogs(filepath.Dir(current), current,
+		time.Duration(config.LogRetentionDays)*24*time.Hour, config.LogRetentionBytes, time.Now())
+	if err != nil {
+		l.Warning("Failed to remove old log files: %v", err)
+	}
+	if len(removed) > 0 {
+		l.Info("Removed %d old log file(s)", len(removed))
+	}
+}
+
+// SetGuiSink sets where lines meant for the GUI log go. The sink is called
+// with the logger locked, so it must not log.
+func (l *Logger) SetGuiSink(sink func(line string)) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
-	l.guiLogView = logView
+	l.guiSink = sink
 }
 
 func (l *Logger) logWithConfig(level LogLevel, showInGUI bool, emoji string, format string, args ...any) {
@@ -121,13 +155,18 @@ func (l *Logger) logWithConfig(level LogLevel, showInGUI bool, emoji string, for
 		Message:   fmt.Sprintf(format, args...),
 	}
 
+	fileLog := fmt.Sprintf("[%s] [%s] %s\n",
+		entry.Timestamp.Format("2006-01-02 15:04:05"),
+		getLevelString(level),
+		entry.Message)
+
+	l.recent = append(l.recent, strings.TrimSuffix(fileLog, "\n"))
+	if len(l.recent) > recentLineLimit {
+		l.recent = l.recent[len(l.recent)-recentLineLimit:]
+	}
+
 	// Write to file
 	if l.logFile != nil {
-		fileLog := fmt.Sprintf("[%s] [%s] %s\n",
-			entry.Timestamp.Format("2006-01-02 15:04:05"),
-			getLevelString(level),
-			entry.Message)
-
 		if _, err := l.logFile.WriteString(fileLog); err != nil {
 			fmt.Printf("Error writing to log file: %v\n", err)
 		}
@@ -135,17 +174,20 @@ func (l *Logger) logWithConfig(level LogLevel, showInGUI bool, emoji string, for
 	}
 
 	// Show in GUI if configured
-	if showInGUI && l.guiLogView != nil {
+	if showInGUI && l.guiSink != nil {
 		timeStr := entry.Timestamp.Format("15:04:05")
-		guiLog := fmt.Sprintf("%s %s %s", timeStr, emoji, entry.Message)
+		l.guiSink(fmt.Sprintf("%s %s %s", timeStr, emoji, entry.Message))
+	}
+}
 
-		currentText := l.guiLogView.Text()
-		if currentText != "" {
-			currentText += "\n"
-		}
-		l.guiLogView.SetText(currentText + guiLog)
-		l.guiLogView.Refresh()
+// RecentLines returns up to n of the most recent log lines, oldest first.
+func (l *Logger) RecentLines(n int) []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if n > len(l.recent) {
+		n = len(l.recent)
 	}
+	return append([]string(nil), l.recent[len(l.recent)-n:]...)
 }
 
 func getLevelString(level LogLevel) string {
@@ -191,6 +233,16 @@ func (l *Logger) Success(format string, args ...any) {
 	l.logWithConfig(SUCCESS, config.ShowInGUI, config.Emoji, format, args...)
 }
 
+// FilePath returns the path of today's log file, or "" if there is none.
+func (l *Logger) FilePath() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.logFile == nil {
+		return ""
+	}
+	return l.logFile.Name()
+}
+
 func (l *Logger) Close() {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
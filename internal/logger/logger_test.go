@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// freshLogger makes the next GetLogger initialize a new logger in dir, and
+// puts the previous one back after the test.
+func freshLogger(t *testing.T, dir string) {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	previousInstance := instance
+	instance, once = nil, sync.Once{}
+	t.Cleanup(func() {
+		os.Chdir(previous)
+		if instance != nil && instance.logFile != nil {
+			instance.logFile.Close()
+		}
+		instance, once = previousInstance, sync.Once{}
+		if previousInstance != nil {
+			once.Do(func() {})
+		}
+	})
+}
+
+func TestGetLoggerWithoutLogsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	// A file where the logs directory belongs cannot be replaced by one.
+	if err := os.WriteFile(filepath.Join(dir, "logs"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	freshLogger(t, dir)
+
+	logger := GetLogger()
+	err := logger.FileErr()
+	if err == nil || !strings.Contains(err.Error(), "failed to create logs directory") {
+		t.Fatalf("FileErr() = %v, want the logs directory error", err)
+	}
+	if logger.FilePath() != "" {
+		t.Errorf("FilePath() = %q without a log file", logger.FilePath())
+	}
+
+	// Messages still reach the GUI and the recent lines.
+	var shown []string
+	logger.SetGuiSink(func(line string) { shown = append(shown, line) })
+	logger.Info("still logging")
+	if len(shown) != 1 || !strings.Contains(shown[0], "still logging") {
+		t.Errorf("GUI lines = %q, want the message", shown)
+	}
+	if recent := logger.RecentLines(1); len(recent) != 1 || !strings.Contains(recent[0], "still logging") {
+		t.Errorf("RecentLines() = %q, want the message", recent)
+	}
+}
+
+func TestGetLoggerConcurrent(t *testing.T) {
+	freshLogger(t, t.TempDir())
+
+	loggers := make([]*Logger, 10)
+	var wg sync.WaitGroup
+	for i := range loggers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loggers[i] = GetLogger()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, logger := range loggers {
+		if logger != loggers[0] {
+			t.Fatal("GetLogger() returned more than one logger")
+		}
+	}
+	if err := loggers[0].FileErr(); err != nil {
+		t.Errorf("FileErr() = %v, want the log file opened", err)
+	}
+	if _, err := os.Stat(loggers[0].FilePath()); err != nil {
+		t.Errorf("log file not created: %v", err)
+	}
+}
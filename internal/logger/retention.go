@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// removeOldLogs deletes log files in logsDir older than maxAge, then the
+// oldest remaining ones until the total fits in maxBytes. The file in use is
+// always kept, and a zero limit disables that check. It returns the removed
+// paths.
+func removeOldLogs(logsDir, current string, maxAge time.Duration, maxBytes int64, now time.Time) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(logsDir, "document_uploader_*.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	type logFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []logFile
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var removed []string
+	var total int64
+	for _, file := range files {
+		if file.path == current {
+			total += file.size
+			continue
+		}
+
+		tooOld := maxAge > 0 && now.Sub(file.modTime) > maxAge
+		tooBig := maxBytes > 0 && total+file.size > maxBytes
+		if !tooOld && !tooBig {
+			total += file.size
+			continue
+		}
+
+		if err := os.Remove(file.path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, file.path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRemoveOldLogs(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxBytes int64
+		want     []string
+	}{
+		{"no limits", 0, 0, nil},
+		{"too old", 30 * day, 0, []string{"document_uploader_old.log"}},
+		{"too big", 0, 250, []string{"document_uploader_old.log", "document_uploader_week.log"}},
+		{"both", 3 * day, 1000, []string{"document_uploader_old.log", "document_uploader_week.log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := []struct {
+				name string
+				age  time.Duration
+			}{
+				{"document_uploader_today.log", 0},
+				{"document_uploader_yesterday.log", day},
+				{"document_uploader_week.log", 7 * day},
+				{"document_uploader_old.log", 60 * day},
+				{"notes.txt", 90 * day},
+			}
+			for _, file := range files {
+				path := filepath.Join(dir, file.name)
+				if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+					t.Fatal(err)
+				}
+				modTime := now.Add(-file.age)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			current := filepath.Join(dir, "document_uploader_today.log")
+			removed, err := removeOldLogs(dir, current, tt.maxAge, tt.maxBytes, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, path := range removed {
+				got = append(got, filepath.Base(path))
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists", path)
+				}
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("removed %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("removed %q, want %q", got, tt.want)
+				}
+			}
+			if _, err := os.Stat(current); err != nil {
+				t.Errorf("the log in use was removed: %v", err)
+			}
+		})
+	}
+}
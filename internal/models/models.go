@@ -1,8 +1,10 @@
 package models
 
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
 }
 
 type BulkLookupRequest struct {
@@ -17,12 +19,32 @@ type EntityLookup struct {
 type DocumentInfo struct {
 	FilePath          string
 	RelativePath      string
+	Title             string
 	EntityType        string
 	NamePath          map[string]string
 	DocumentType      string
 	ContentType       string
 	SalesforceIds     map[string]string
 	ContentDocumentId string
+	Warnings          []string
+	ExtraFields       map[string]any
+	// Rejected explains why the file is left out of the upload. Rejected
+	// files are recorded as failed in the run report.
+	Rejected string
+}
+
+// DirectoryRun selects the documents of a directory run. The filters are
+// optional; empty ones select everything.
+type DirectoryRun struct {
+	DocumentsDir string
+	// SubtreeFilter restricts the run to one entity path, e.g.
+	// Project/Phase/Zone.
+	SubtreeFilter string
+	// ExcludePatterns is a comma-separated list of glob patterns of files to
+	// leave out.
+	ExcludePatterns string
+	// DocumentType restricts the run to one document type.
+	DocumentType string
 }
 
 type AttachmentUploader struct {
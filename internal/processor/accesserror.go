@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+)
+
+var accessErrorCodes = map[string]bool{
+	"INSUFFICIENT_ACCESS":                           true,
+	"INSUFFICIENT_ACCESS_OR_READONLY":               true,
+	"INSUFFICIENT_ACCESS_ON_CROSS_REFERENCE_ENTITY": true,
+}
+
+// AccessError is a subrequest Salesforce refused because the running user
+// lacks access, typically to the entity record a lookup resolved. It is kept
+// apart from other failures because only an administrator can fix it.
+type AccessError struct {
+	ReferenceID string
+	ErrorCode   string
+	Message     string
+
+	SObject    string
+	EntityType string
+	EntityPath string
+	EntityID   string
+}
+
+func (e *AccessError) Error() string {
+	if e.SObject == "" {
+		return fmt.Sprintf("%s: %s - %s. The user lacks the access this request needs",
+			e.ReferenceID, e.ErrorCode, e.Message)
+	}
+	if e.EntityID == "" {
+		return fmt.Sprintf("%s: %s - %s. The user lacks the access needed to create %s records",
+			e.ReferenceID, e.ErrorCode, e.Message, e.SObject)
+	}
+	return fmt.Sprintf("%s: %s - %s. The user cannot attach files to %s %s (%s); "+
+		"ask an administrator for edit access to that record and create permission on %s",
+		e.ReferenceID, e.ErrorCode, e.Message, e.EntityType, e.EntityPath, e.EntityID, e.SObject)
+}
+
+// withAccessContext names the sobject and entity record behind an access
+// error, finding the document from the subrequest's reference ID. Other
+// errors are returned unchanged.
+func withAccessContext(err error, sobject string, refs referenceMap) error {
+	var accessErr *AccessError
+	if !errors.As(err, &accessErr) {
+		return err
+	}
+
+	accessErr.SObject = sobject
+	if doc := refs[accessErr.ReferenceID]; doc != nil {
+		accessErr.EntityType = doc.EntityType
+		accessErr.EntityPath = generateFullPath(*doc)
+		accessErr.EntityID = attachmentEntityID(*doc)
+	}
+	return accessErr
+}
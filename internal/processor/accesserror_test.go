@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestInsufficientAccessNamesEntity(t *testing.T) {
+	tests := []struct {
+		name      string
+		errorCode string
+		access    bool
+	}{
+		{name: "insufficient access", errorCode: "INSUFFICIENT_ACCESS_OR_READONLY", access: true},
+		{name: "cross reference", errorCode: "INSUFFICIENT_ACCESS_ON_CROSS_REFERENCE_ENTITY", access: true},
+		{name: "other error", errorCode: "FIELD_CUSTOM_VALIDATION_EXCEPTION"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(map[string]any{"compositeResponse": []map[string]any{
+					{"referenceId": "linkRef0", "httpStatusCode": http.StatusBadRequest, "body": []map[string]any{
+						{"errorCode": "PROCESSING_HALTED", "message": "The transaction was rolled back since another operation in the same transaction failed."},
+					}},
+					{"referenceId": "linkRef1", "httpStatusCode": http.StatusBadRequest, "body": []map[string]any{
+						{"errorCode": tt.errorCode, "message": "insufficient access rights on object id"},
+					}},
+				}})
+			})
+
+			dir := t.TempDir()
+			documents := writeDocuments(t, dir, 2)
+			for i := range documents {
+				documents[i].ContentDocumentId = "069" + documents[i].SalesforceIds["building"][3:]
+				documents[i].NamePath = map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": documents[i].NamePath["building"]}
+			}
+			logger := logging.GetLogger()
+			checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+			err := createContentDocumentLinks(context.Background(), "token", documents, nil, checkpoint, logger)
+			if err == nil {
+				t.Fatal("createContentDocumentLinks() succeeded")
+			}
+
+			var accessErr *AccessError
+			if errors.As(err, &accessErr) != tt.access {
+				t.Fatalf("error = %v, want an access error %v", err, tt.access)
+			}
+			if !tt.access {
+				return
+			}
+			want := AccessError{
+				ReferenceID: "linkRef1",
+				ErrorCode:   tt.errorCode,
+				Message:     "insufficient access rights on object id",
+				SObject:     "ContentDocumentLink",
+				EntityType:  "BUILDING",
+				EntityPath:  generateFullPath(documents[1]),
+				EntityID:    documents[1].SalesforceIds["building"],
+			}
+			if *accessErr != want {
+				t.Errorf("AccessError = %+v, want %+v", *accessErr, want)
+			}
+			if !strings.Contains(err.Error(), "ask an administrator") || !strings.Contains(err.Error(), want.EntityID) {
+				t.Errorf("error = %q, want an actionable message naming the record", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+type AuditEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	RunID      string    `json:"runId"`
+	Operation  string    `json:"operation"`
+	SObject    string    `json:"sobject"`
+	RecordID   string    `json:"recordId"`
+	EntityPath string    `json:"entityPath,omitempty"`
+	File       string    `json:"file,omitempty"`
+}
+
+// auditLog appends one JSON line per Salesforce write and syncs after each
+// entry so the trail survives a crash mid-run. A nil *auditLog is valid and
+// records nothing.
+type auditLog struct {
+	file  *os.File
+	runID string
+	mutex sync.Mutex
+}
+
+func reportsDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %v", err)
+	}
+	dir := filepath.Join(cwd, "reports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create reports directory: %v", err)
+	}
+	return dir, nil
+}
+
+func openAuditLog(runID string) (*auditLog, error) {
+	dir, err := reportsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("audit_%s.jsonl", time.Now().Format("2006-01-02")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	return &auditLog{file: file, runID: runID}, nil
+}
+
+func (a *auditLog) record(operation, sobject, recordId string, doc *models.DocumentInfo) {
+	if a == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Timestamp: time.Now().UTC(),
+		RunID:     a.runID,
+		Operation: operation,
+		SObject:   sobject,
+		RecordID:  recordId,
+	}
+	if doc != nil {
+		entry.EntityPath = generateFullPath(*doc)
+		entry.File = doc.RelativePath
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.file.Write(append(line, '\n'))
+	a.file.Sync()
+}
+
+func (a *auditLog) Close() {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.file.Close()
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func readAuditLog(t *testing.T) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(filepathInReports(t, "audit_"+time.Now().Format("2006-01-02")+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestAuditLogRecordsEachCreate checks that every record a run creates is
+// written to the audit log as it happens, and that later runs append to it.
+func TestAuditLogRecordsEachCreate(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	useTestServer(t, org.ServeHTTP)
+	logger := logging.GetLogger()
+
+	var want []AuditEntry
+	for _, runID := range []string{"run-1", "run-2"} {
+		runDir := filepath.Join(dir, runID)
+		if err := os.Mkdir(runDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		documents := writeDocuments(t, runDir, 2)
+		audit, err := openAuditLog(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkpoint := &checkpointer{runID: runID, documentsDir: runDir, collected: documents, documents: &documents, logger: logger}
+		err = bulkUploadContentVersions(context.Background(), "token", runDir, documents, audit, nil, nil, checkpoint, logger, nil)
+		audit.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sobject := range []string{"ContentVersion", "ContentDistribution"} {
+			for _, doc := range documents {
+				want = append(want, AuditEntry{RunID: runID, Operation: "create", SObject: sobject, EntityPath: generateFullPath(doc), File: doc.RelativePath})
+			}
+		}
+
+		entries := readAuditLog(t)
+		if len(entries) != len(want) {
+			t.Fatalf("after %s the audit log has %d entries, want %d", runID, len(entries), len(want))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, entry := range readAuditLog(t) {
+		if entry.RecordID == "" || seen[entry.RecordID] || entry.Timestamp.IsZero() {
+			t.Errorf("entry %d = %+v, want a new record with a timestamp", i, entry)
+		}
+		seen[entry.RecordID] = true
+		entry.RecordID, entry.Timestamp = "", time.Time{}
+		if entry != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+}
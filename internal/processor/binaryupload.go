@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// useBinaryUpload reports whether a file is large enough to be uploaded on
+// its own as multipart binary rather than base64 inside a composite batch,
+// which would inflate it by a third and run into the composite size limit.
+func useBinaryUpload(size, threshold int64) bool {
+	return threshold > 0 && size > threshold
+}
+
+// uploadContentVersionBinary creates a ContentVersion from body, the same
+// fields a composite subrequest would send minus VersionData, sending the
+// file as the binary part of a multipart request. It returns the new ID.
+//
+// The request is staged in a file first rather than streamed, so it goes out
+// with a Content-Length and can be sent again when the org redirects it.
+func uploadContentVersionBinary(ctx context.Context, accessToken, fullPath string, body map[string]any, staging *stagingArea) (string, error) {
+	entity, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling ContentVersion: %v", err)
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("error opening file %s: %v", fullPath, err)
+	}
+	defer file.Close()
+
+	staged, err := staging.create("contentversion-*.multipart")
+	if err != nil {
+		return "", fmt.Errorf("error staging %s: %v", filepath.Base(fullPath), err)
+	}
+	defer func() {
+		staged.Close()
+		os.Remove(staged.Name())
+	}()
+
+	form := multipart.NewWriter(staged)
+	if err := writeContentVersionParts(form, entity, filepath.Base(fullPath), file); err != nil {
+		return "", fmt.Errorf("error staging %s: %v", filepath.Base(fullPath), err)
+	}
+	size, err := staged.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("error staging %s: %v", filepath.Base(fullPath), err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", config.DataURL("/sobjects/ContentVersion"), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating binary upload request: %v", err)
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(staged, 0, size)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = size
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("binary upload of %s failed: %w", filepath.Base(fullPath), err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s", ErrSessionExpired, string(respBody))
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("binary upload of %s failed: status %d: %s", filepath.Base(fullPath), resp.StatusCode, string(respBody))
+	}
+
+	if errs := responseErrors(respBody); len(errs) > 0 {
+		return "", fmt.Errorf("binary upload of %s failed: %s - %s", filepath.Base(fullPath), errs[0].code(), errs[0].Message)
+	}
+
+	var created saveResult
+	if err := json.Unmarshal(respBody, &created); err != nil || created.ID == "" || saveFailed(respBody) {
+		return "", fmt.Errorf("unexpected binary upload response for %s: %s", filepath.Base(fullPath), string(respBody))
+	}
+	return created.ID, nil
+}
+
+// writeContentVersionParts writes the two parts Salesforce expects: the
+// record fields as JSON in entity_content, then the file as VersionData.
+func writeContentVersionParts(form *multipart.Writer, entity []byte, fileName string, file io.Reader) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="entity_content"`)
+	header.Set("Content-Type", "application/json")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(entity); err != nil {
+		return err
+	}
+
+	header = make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="VersionData"; filename=%q`, fileName))
+	header.Set("Content-Type", "application/octet-stream")
+	part, err = form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+
+	return form.Close()
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestUseBinaryUpload(t *testing.T) {
+	tests := []struct {
+		size, threshold int64
+		want            bool
+	}{
+		{size: 99, threshold: 100, want: false},
+		{size: 100, threshold: 100, want: false},
+		{size: 101, threshold: 100, want: true},
+		{size: 1 << 30, threshold: 0, want: false},
+	}
+	for _, tt := range tests {
+		if got := useBinaryUpload(tt.size, tt.threshold); got != tt.want {
+			t.Errorf("useBinaryUpload(%d, %d) = %v, want %v", tt.size, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestLargeFilesUploadedAsBinary(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+
+	var mutex sync.Mutex
+	binary := make(map[string]string)
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/sobjects/ContentVersion") {
+			org.ServeHTTP(w, r)
+			return
+		}
+		reader, err := r.MultipartReader()
+		if err != nil {
+			t.Errorf("binary upload is not multipart: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var title, data string
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			content, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "entity_content":
+				var entity map[string]any
+				json.Unmarshal(content, &entity)
+				title, _ = entity["Title"].(string)
+			case "VersionData":
+				data = string(content)
+			}
+		}
+		mutex.Lock()
+		binary[title] = data
+		id := fmt.Sprintf("068binary%06d", len(binary))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"id": id, "success": true})
+	})
+
+	previous := config.BinaryUploadThreshold
+	config.BinaryUploadThreshold = 64
+	defer func() { config.BinaryUploadThreshold = previous }()
+
+	documents := writeDocuments(t, dir, 3)
+	large := strings.Repeat("x", 65)
+	if err := os.WriteFile(documents[1].FilePath, []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(binary) != 1 || binary[contentTitle(documents[1])] != large {
+		t.Errorf("binary uploads = %v, want only %s", binary, documents[1].RelativePath)
+	}
+	if n := org.count("ContentVersion"); n != 2 {
+		t.Errorf("%d ContentVersions sent in composite batches, want 2", n)
+	}
+	for _, doc := range documents {
+		if doc.SalesforceIds["contentVersionId"] == "" {
+			t.Errorf("%s has no ContentVersion ID", doc.RelativePath)
+		}
+	}
+	if id := documents[1].SalesforceIds["contentVersionId"]; !strings.HasPrefix(id, "068binary") {
+		t.Errorf("large file ContentVersion ID = %q, want the binary upload's", id)
+	}
+}
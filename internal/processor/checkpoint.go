@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"errors"
+	"sync"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// errRunInterrupted is recorded against unfinished documents in a
+// checkpoint. If the app dies, the checkpoint is the report to resume from.
+var errRunInterrupted = errors.New("run interrupted before this file finished")
+
+// progressKeys are the SalesforceIds a run creates for a document, as
+// opposed to the entity IDs found by the lookup. A checkpoint keeps them for
+// unfinished documents, so a resumed run skips the steps they completed.
+var progressKeys = []string{
+	"contentVersionId",
+	"previewContentVersionId",
+	"previewContentDocumentId",
+	"contentDocumentLinkId",
+	"distributionUrl",
+}
+
+// contentDocumentIDKey stores DocumentInfo.ContentDocumentId in a report.
+const contentDocumentIDKey = "contentDocumentId"
+
+// checkpointer rewrites the run report after every finished batch, so a
+// crash loses at most the batches in flight. Batches sent in parallel record
+// their results through apply, which keeps them from writing documents
+// while a checkpoint reads them.
+type checkpointer struct {
+	mu           sync.Mutex
+	runID        string
+	documentsDir string
+	collected    []models.DocumentInfo
+	documents    *[]models.DocumentInfo
+	logger       *logging.Logger
+}
+
+// apply records a finished batch with update and saves a checkpoint.
+func (c *checkpointer) apply(update func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update()
+	c.write(errRunInterrupted)
+}
+
+// save writes a checkpoint recording runErr against unfinished documents.
+func (c *checkpointer) save(runErr error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.write(runErr)
+}
+
+func (c *checkpointer) write(runErr error) {
+	report := buildRunReport(c.runID, c.documentsDir, c.collected, *c.documents, runErr)
+	if _, err := saveRunReport(report); err != nil {
+		c.logger.Warning("Checkpoint not written: %v", err)
+		return
+	}
+	c.logger.Debug("Checkpoint written for run %s", c.runID)
+}
+
+// savedProgress returns the progress IDs of a document, or nil if it has
+// none.
+func savedProgress(doc models.DocumentInfo) map[string]string {
+	saved := make(map[string]string)
+	for _, key := range progressKeys {
+		if id := doc.SalesforceIds[key]; id != "" {
+			saved[key] = id
+		}
+	}
+	if doc.ContentDocumentId != "" {
+		saved[contentDocumentIDKey] = doc.ContentDocumentId
+	}
+	if len(saved) == 0 {
+		return nil
+	}
+	return saved
+}
+
+// restoreProgress puts the progress IDs from a report back on a document.
+func restoreProgress(doc *models.DocumentInfo, saved map[string]string) {
+	if len(saved) == 0 {
+		return
+	}
+	if doc.SalesforceIds == nil {
+		doc.SalesforceIds = make(map[string]string)
+	}
+	for _, key := range progressKeys {
+		if id := saved[key]; id != "" {
+			doc.SalesforceIds[key] = id
+		}
+	}
+	if id := saved[contentDocumentIDKey]; id != "" {
+		doc.ContentDocumentId = id
+	}
+}
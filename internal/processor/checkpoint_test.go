@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestCheckpointSaveAndReload(t *testing.T) {
+	dir := inTempDir(t)
+	logger := logging.GetLogger()
+	collected := writeDocuments(t, dir, 3)
+	documents := append(collected[:0:0], collected...)
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: collected, documents: &documents, logger: logger}
+
+	checkpoint.apply(func() {
+		documents[0].SalesforceIds["attachmentUploaderId"] = "a0X000000000001"
+		documents[1].SalesforceIds["contentVersionId"] = "068000000000002"
+		documents[1].ContentDocumentId = "069000000000002"
+	})
+
+	path := filepathInReports(t, "run_test.json")
+	report, err := LoadRunReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		status   string
+		error    string
+		progress map[string]string
+	}{
+		{status: StatusUploaded},
+		{status: StatusFailed, error: errRunInterrupted.Error(), progress: map[string]string{
+			"contentVersionId":   "068000000000002",
+			contentDocumentIDKey: "069000000000002",
+		}},
+		{status: StatusFailed, error: errRunInterrupted.Error()},
+	}
+	if len(report.Documents) != len(tests) {
+		t.Fatalf("report has %d documents, want %d", len(report.Documents), len(tests))
+	}
+	for i, want := range tests {
+		entry := report.Documents[i]
+		if entry.Status != want.status || entry.Error != want.error {
+			t.Errorf("%s: status %q error %q, want %q %q", entry.RelativePath, entry.Status, entry.Error, want.status, want.error)
+		}
+		if len(entry.Progress) != len(want.progress) {
+			t.Errorf("%s: progress %v, want %v", entry.RelativePath, entry.Progress, want.progress)
+		}
+		for key, id := range want.progress {
+			if entry.Progress[key] != id {
+				t.Errorf("%s: progress[%s] = %s, want %s", entry.RelativePath, key, entry.Progress[key], id)
+			}
+		}
+		if entry.Size == 0 || entry.ModTime == 0 {
+			t.Errorf("%s: size and modification time not recorded", entry.RelativePath)
+		}
+	}
+
+	leftovers, err := filepath.Glob(filepathInReports(t, ".run_*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	fresh := writeDocuments(t, dir, 3)
+	retry, err := report.retrySet(fresh, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retry) != 2 {
+		t.Fatalf("retrying %d documents, want 2", len(retry))
+	}
+	if retry[0].SalesforceIds["contentVersionId"] != "068000000000002" || retry[0].ContentDocumentId != "069000000000002" {
+		t.Errorf("progress not restored: %v, ContentDocumentId %q", retry[0].SalesforceIds, retry[0].ContentDocumentId)
+	}
+	if retry[0].SalesforceIds["building"] == "" {
+		t.Error("restoring progress dropped the entity IDs")
+	}
+	if len(savedProgress(retry[1])) != 0 {
+		t.Errorf("%s gained progress it never made: %v", retry[1].RelativePath, savedProgress(retry[1]))
+	}
+}
+
+func TestCheckpointReplacesPreviousSave(t *testing.T) {
+	dir := inTempDir(t)
+	documents := writeDocuments(t, dir, 2)
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logging.GetLogger()}
+
+	checkpoint.save(errRunInterrupted)
+	checkpoint.apply(func() {
+		documents[0].SalesforceIds["attachmentUploaderId"] = "a0X000000000001"
+		documents[1].SalesforceIds["attachmentUploaderId"] = "a0X000000000002"
+	})
+
+	report, err := LoadRunReport(filepathInReports(t, "run_test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := report.retryPaths(); len(paths) != 0 {
+		t.Errorf("reloaded report still retries %v", paths)
+	}
+}
+
+func TestRetrySetRejectsMissingFiles(t *testing.T) {
+	dir := inTempDir(t)
+	documents := writeDocuments(t, dir, 2)
+	report := buildRunReport("test", dir, documents, documents, errRunInterrupted)
+
+	if _, err := report.retrySet(documents[:1], logging.GetLogger()); err == nil {
+		t.Fatal("retrySet() accepted a directory missing a reported file")
+	}
+}
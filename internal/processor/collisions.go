@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func attachmentSlotKey(doc models.DocumentInfo) string {
+	return fmt.Sprintf("%s|%s|%s", doc.EntityType, generateFullPath(doc), doc.DocumentType)
+}
+
+// resolveCollisions finds documents that would produce more than one
+// attachment record for the same entity and document type, and applies the
+// configured policy. Galleries hold many images per entity by design, so they
+// never collide.
+func resolveCollisions(documentsDir string, documents []models.DocumentInfo, policy string, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	slots := make(map[string][]int)
+	var order []string
+	for i, doc := range documents {
+		if doc.DocumentType == config.DocTypeGallery {
+			continue
+		}
+		key := attachmentSlotKey(doc)
+		if _, exists := slots[key]; !exists {
+			order = append(order, key)
+		}
+		slots[key] = append(slots[key], i)
+	}
+
+	drop := make(map[int]bool)
+	var collisions []string
+	for _, key := range order {
+		indexes := slots[key]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		var paths []string
+		for _, i := range indexes {
+			paths = append(paths, documents[i].RelativePath)
+		}
+		collisions = append(collisions, strings.Join(paths, ", "))
+
+		for _, i := range indexes {
+			documents[i].Warnings = append(documents[i].Warnings,
+				fmt.Sprintf("%s collides with %d other file(s) for the same entity", documents[i].DocumentType, len(indexes)-1))
+		}
+
+		if policy != config.CollisionKeepNewest {
+			continue
+		}
+
+		sorted := append([]int(nil), indexes...)
+		sort.SliceStable(sorted, func(a, b int) bool {
+			return modTime(documentsDir, documents[sorted[a]]) > modTime(documentsDir, documents[sorted[b]])
+		})
+		for _, i := range sorted[1:] {
+			drop[i] = true
+			logger.Warning("Skipping %s: newer file %s fills the same slot",
+				documents[i].RelativePath, documents[sorted[0]].RelativePath)
+		}
+	}
+
+	if len(collisions) == 0 {
+		return documents, nil
+	}
+
+	logger.Warning("Found %d attachment slot collisions", len(collisions))
+
+	if policy == config.CollisionError {
+		return nil, fmt.Errorf("multiple files map to the same attachment slot:\n- %s",
+			strings.Join(collisions, "\n- "))
+	}
+
+	kept := make([]models.DocumentInfo, 0, len(documents)-len(drop))
+	for i, doc := range documents {
+		if !drop[i] {
+			kept = append(kept, doc)
+		}
+	}
+	return kept, nil
+}
+
+func modTime(documentsDir string, doc models.DocumentInfo) int64 {
+	info, err := os.Stat(filepath.Join(documentsDir, doc.RelativePath))
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestResolveCollisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		gallery bool
+		want    []string
+		wantErr bool
+		warned  bool
+	}{
+		{name: "keep both", policy: config.CollisionKeepBoth, want: []string{"bl_000.jpg", "bl_001.jpg", "bl_002.jpg"}, warned: true},
+		{name: "keep newest", policy: config.CollisionKeepNewest, want: []string{"bl_001.jpg", "bl_002.jpg"}, warned: true},
+		{name: "error", policy: config.CollisionError, wantErr: true},
+		{name: "galleries never collide", policy: config.CollisionError, gallery: true, want: []string{"bl_000.jpg", "bl_001.jpg", "bl_002.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			documents := writeDocuments(t, dir, 3)
+			// The first two files fill the same slot of building B0; the
+			// second is newer.
+			documents[1].NamePath["building"] = "B0"
+			for i, age := range []time.Duration{2 * time.Hour, time.Hour} {
+				modified := time.Now().Add(-age)
+				if err := os.Chtimes(documents[i].FilePath, modified, modified); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.gallery {
+				for i := range documents {
+					documents[i].DocumentType = config.DocTypeGallery
+				}
+			}
+
+			kept, err := resolveCollisions(dir, documents, tt.policy, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCollisions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := relativePaths(kept); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			for _, doc := range kept {
+				warned := len(doc.Warnings) > 0
+				if doc.RelativePath == "bl_002.jpg" {
+					if warned {
+						t.Errorf("%s warned without a collision: %v", doc.RelativePath, doc.Warnings)
+					}
+				} else if warned != tt.warned {
+					t.Errorf("%s warnings = %v, want a collision warning %v", doc.RelativePath, doc.Warnings, tt.warned)
+				}
+			}
+		})
+	}
+}
+
+func relativePaths(documents []models.DocumentInfo) []string {
+	var paths []string
+	for _, doc := range documents {
+		paths = append(paths, doc.RelativePath)
+	}
+	return paths
+}
@@ -0,0 +1,227 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/salesforce"
+)
+
+// ErrSessionExpired means Salesforce rejected the access token, usually
+// because the session timed out during a long run.
+var ErrSessionExpired = errors.New("Salesforce session expired")
+
+// compositeBatchSize is the most subrequests Salesforce accepts in a single
+// composite request.
+const compositeBatchSize = 25
+
+// subrequestOverhead approximates the JSON of a subrequest apart from any
+// file content it carries.
+const subrequestOverhead = 2 << 10
+
+type CompositeResult struct {
+	ReferenceID    string          `json:"referenceId"`
+	HTTPStatusCode int             `json:"httpStatusCode"`
+	Body           json.RawMessage `json:"body"`
+}
+
+// Succeeded reports whether the subrequest worked. A create can come back
+// with a 2xx status and still carry success false, so that is checked too,
+// as is the record ID every create must return.
+func (r CompositeResult) Succeeded() bool {
+	return r.HTTPStatusCode >= 200 && r.HTTPStatusCode < 300 && !saveFailed(r.Body) && !r.missingID()
+}
+
+// missingID reports a create that claims success without naming the new
+// record, which would otherwise pass on an empty ID.
+func (r CompositeResult) missingID() bool {
+	return r.HTTPStatusCode == http.StatusCreated && r.ID() == ""
+}
+
+// ID returns the record ID from a successful create.
+func (r CompositeResult) ID() string {
+	var body struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(r.Body, &body)
+	return body.ID
+}
+
+// Error describes a failed subrequest using the first Salesforce error in
+// its body, falling back to the status code. Access errors are returned as
+// *AccessError.
+func (r CompositeResult) Error() error {
+	if errs := responseErrors(r.Body); len(errs) > 0 {
+		if accessErrorCodes[errs[0].code()] {
+			return &AccessError{ReferenceID: r.ReferenceID, ErrorCode: errs[0].code(), Message: errs[0].Message}
+		}
+		return fmt.Errorf("%s: %s - %s", r.ReferenceID, errs[0].code(), errs[0].Message)
+	}
+	if saveFailed(r.Body) {
+		return fmt.Errorf("%s: status %d but the save was not successful", r.ReferenceID, r.HTTPStatusCode)
+	}
+	if r.missingID() {
+		return fmt.Errorf("%s: status %d but the response has no record id: %s", r.ReferenceID, r.HTTPStatusCode, string(r.Body))
+	}
+	return fmt.Errorf("%s: status %d", r.ReferenceID, r.HTTPStatusCode)
+}
+
+func (r CompositeResult) halted() bool {
+	errs := responseErrors(r.Body)
+	return len(errs) > 0 && errs[0].code() == "PROCESSING_HALTED"
+}
+
+// salesforceError is one entry of an error list. Failed requests name the
+// code errorCode, while the errors inside a save result call it statusCode.
+type salesforceError struct {
+	ErrorCode  string `json:"errorCode"`
+	StatusCode string `json:"statusCode"`
+	Message    string `json:"message"`
+}
+
+func (e salesforceError) code() string {
+	if e.ErrorCode != "" {
+		return e.ErrorCode
+	}
+	return e.StatusCode
+}
+
+// saveResult is the body of an sObject create.
+type saveResult struct {
+	ID      string            `json:"id"`
+	Success *bool             `json:"success"`
+	Errors  []salesforceError `json:"errors"`
+}
+
+// saveFailed reports whether body is a save result with success false.
+func saveFailed(body []byte) bool {
+	var result saveResult
+	return json.Unmarshal(body, &result) == nil && result.Success != nil && !*result.Success
+}
+
+// responseErrors returns the errors in a response body, either a plain error
+// list or the errors of a failed save result.
+func responseErrors(body []byte) []salesforceError {
+	var errs []salesforceError
+	if json.Unmarshal(body, &errs) == nil {
+		return errs
+	}
+	var result saveResult
+	if json.Unmarshal(body, &result) == nil && result.Success != nil && !*result.Success {
+		return result.Errors
+	}
+	return nil
+}
+
+// compositeError returns the error of the subrequest that caused a composite
+// request to fail. With allOrNone the other subrequests only report
+// PROCESSING_HALTED, even those that succeeded and were rolled back, so they
+// are passed over in favour of the real cause and only counted.
+func compositeError(results []CompositeResult) error {
+	var cause, halted error
+	rolledBack := 0
+	for _, result := range results {
+		if result.Succeeded() {
+			continue
+		}
+		if result.halted() {
+			rolledBack++
+			if halted == nil {
+				halted = result.Error()
+			}
+			continue
+		}
+		if cause == nil {
+			cause = result.Error()
+		}
+	}
+
+	if cause == nil {
+		return halted
+	}
+	if rolledBack > 0 {
+		return fmt.Errorf("%w (%d other subrequest(s) rolled back)", cause, rolledBack)
+	}
+	return cause
+}
+
+// packSubrequests splits subrequests into composite batches, keeping their
+// order, of at most compositeBatchSize subrequests and maxBytes of JSON.
+// Many small files fill a batch to the subrequest limit, while large ones
+// get smaller batches; a subrequest over maxBytes on its own is sent alone.
+// A maxBytes of zero limits the count only.
+func packSubrequests(subrequests []map[string]any, maxBytes int64) [][]map[string]any {
+	var batches [][]map[string]any
+	var batch []map[string]any
+	var batchBytes int64
+	for _, subrequest := range subrequests {
+		size := subrequestSize(subrequest)
+		full := len(batch) == compositeBatchSize || (maxBytes > 0 && batchBytes+size > maxBytes)
+		if len(batch) > 0 && full {
+			batches = append(batches, batch)
+			batch, batchBytes = nil, 0
+		}
+		batch = append(batch, subrequest)
+		batchBytes += size
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// subrequestSize estimates a subrequest's share of the composite request,
+// which is dominated by the base64 file content of ContentVersion creates.
+func subrequestSize(subrequest map[string]any) int64 {
+	size := int64(subrequestOverhead)
+	if body, ok := subrequest["body"].(map[string]any); ok {
+		if data, ok := body["VersionData"].(string); ok {
+			size += int64(len(data))
+		}
+	}
+	return size
+}
+
+// sendComposite posts up to compositeBatchSize subrequests as one composite
+// request. Failed subrequests are not an error here; callers inspect the
+// results, usually through compositeError.
+func sendComposite(ctx context.Context, client *salesforce.Client, subrequests []map[string]any, allOrNone bool) ([]CompositeResult, error) {
+	if len(subrequests) > compositeBatchSize {
+		return nil, fmt.Errorf("composite request has %d subrequests, the limit is %d", len(subrequests), compositeBatchSize)
+	}
+
+	resp, err := client.MakeRequestContext(ctx, "POST", config.DataURL("/composite"), map[string]any{
+		"allOrNone":        allOrNone,
+		"compositeRequest": subrequests,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("composite request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading composite response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("composite request failed: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var compositeResponse struct {
+		CompositeResponse []CompositeResult `json:"compositeResponse"`
+	}
+	if err := json.Unmarshal(body, &compositeResponse); err != nil {
+		return nil, fmt.Errorf("error decoding composite response: %v", err)
+	}
+
+	return compositeResponse.CompositeResponse, nil
+}
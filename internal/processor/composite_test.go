@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func fileSubrequest(id string, dataBytes int) map[string]any {
+	return map[string]any{
+		"referenceId": id,
+		"body":        map[string]any{"VersionData": strings.Repeat("A", dataBytes)},
+	}
+}
+
+func TestPackSubrequests(t *testing.T) {
+	const kb = 1 << 10
+	tests := []struct {
+		name     string
+		sizes    []int
+		maxBytes int64
+		want     []int
+	}{
+		{"count limit only", make([]int, 2*compositeBatchSize+1), 0, []int{compositeBatchSize, compositeBatchSize, 1}},
+		{"fills to byte limit", []int{10 * kb, 10 * kb, 10 * kb, 10 * kb}, 2*(10*kb+subrequestOverhead) + 1, []int{2, 2}},
+		{"large file sent alone", []int{kb, 100 * kb, kb}, 20 * kb, []int{1, 1, 1}},
+		{"exact fit", []int{kb, kb}, 2 * (kb + subrequestOverhead), []int{2}},
+		{"empty", nil, 20 * kb, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subrequests []map[string]any
+			for i, size := range tt.sizes {
+				subrequests = append(subrequests, fileSubrequest(string(rune('a'+i%26)), size))
+			}
+
+			batches := packSubrequests(subrequests, tt.maxBytes)
+
+			var got []int
+			var flattened []map[string]any
+			for _, batch := range batches {
+				got = append(got, len(batch))
+				flattened = append(flattened, batch...)
+				if len(batch) > compositeBatchSize {
+					t.Errorf("batch of %d subrequests exceeds %d", len(batch), compositeBatchSize)
+				}
+				var bytes int64
+				for _, subrequest := range batch {
+					bytes += subrequestSize(subrequest)
+				}
+				if tt.maxBytes > 0 && len(batch) > 1 && bytes > tt.maxBytes {
+					t.Errorf("batch of %d bytes exceeds %d", bytes, tt.maxBytes)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("batch sizes = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("batch sizes = %v, want %v", got, tt.want)
+				}
+			}
+			for i := range flattened {
+				if flattened[i]["referenceId"] != subrequests[i]["referenceId"] {
+					t.Fatalf("subrequest %d out of order", i)
+				}
+			}
+		})
+	}
+}
+
+func compositeResult(ref string, status int, body string) CompositeResult {
+	return CompositeResult{ReferenceID: ref, HTTPStatusCode: status, Body: json.RawMessage(body)}
+}
+
+func TestCompositeError(t *testing.T) {
+	const (
+		created = `{"id":"068000000000001","success":true,"errors":[]}`
+		halted  = `[{"errorCode":"PROCESSING_HALTED","message":"The transaction was rolled back"}]`
+		invalid = `[{"errorCode":"INVALID_FIELD","message":"No such column"}]`
+	)
+	tests := []struct {
+		name    string
+		results []CompositeResult
+		want    string
+	}{
+		{"all succeeded", []CompositeResult{
+			compositeResult("ref0", 201, created),
+			compositeResult("ref1", 201, created),
+		}, ""},
+		{"cause before halted", []CompositeResult{
+			compositeResult("ref0", 400, invalid),
+			compositeResult("ref1", 400, halted),
+			compositeResult("ref2", 400, halted),
+		}, "ref0: INVALID_FIELD - No such column (2 other subrequest(s) rolled back)"},
+		{"cause after halted", []CompositeResult{
+			compositeResult("ref0", 400, halted),
+			compositeResult("ref1", 400, invalid),
+		}, "ref1: INVALID_FIELD - No such column (1 other subrequest(s) rolled back)"},
+		{"only halted", []CompositeResult{
+			compositeResult("ref0", 400, halted),
+			compositeResult("ref1", 400, halted),
+		}, "ref0: PROCESSING_HALTED - The transaction was rolled back"},
+		{"single failure", []CompositeResult{
+			compositeResult("ref0", 201, created),
+			compositeResult("ref1", 500, `{}`),
+		}, "ref1: status 500"},
+		{"created but not saved", []CompositeResult{
+			compositeResult("ref0", 201, created),
+			compositeResult("ref1", 201, `{"id":"","success":false,"errors":[{"statusCode":"FIELD_CUSTOM_VALIDATION_EXCEPTION","message":"Title is required"}]}`),
+		}, "ref1: FIELD_CUSTOM_VALIDATION_EXCEPTION - Title is required"},
+		{"created but not saved without errors", []CompositeResult{
+			compositeResult("ref0", 201, `{"id":"068000000000001","success":false,"errors":[]}`),
+		}, "ref0: status 201 but the save was not successful"},
+		{"created without id", []CompositeResult{
+			compositeResult("ref0", 201, `{"success":true}`),
+		}, `ref0: status 201 but the response has no record id: {"success":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compositeError(tt.results)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("compositeError() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("compositeError() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompositeResultSucceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		result CompositeResult
+		want   bool
+	}{
+		{"created", compositeResult("ref0", 201, `{"id":"068000000000001","success":true,"errors":[]}`), true},
+		{"created with unknown fields", compositeResult("ref0", 201, `{"id":"068000000000001","success":true,"errors":[],"warnings":[],"infos":[]}`), true},
+		{"created without id", compositeResult("ref0", 201, `{"success":true,"errors":[]}`), false},
+		{"created with empty id", compositeResult("ref0", 201, `{"id":"","success":true}`), false},
+		{"read needs no id", compositeResult("ref0", 200, `{"ContentDownloadUrl":"https://acme.file.force.com/x"}`), true},
+		{"not saved", compositeResult("ref0", 201, `{"id":"068000000000001","success":false,"errors":[]}`), false},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Succeeded(); got != tt.want {
+			t.Errorf("%s: Succeeded() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompositeErrorKeepsAccessError(t *testing.T) {
+	err := compositeError([]CompositeResult{
+		compositeResult("ref0", 400, `[{"errorCode":"INSUFFICIENT_ACCESS_OR_READONLY","message":"no access"}]`),
+		compositeResult("ref1", 400, `[{"errorCode":"PROCESSING_HALTED","message":"rolled back"}]`),
+	})
+	var accessErr *AccessError
+	if !errors.As(err, &accessErr) {
+		t.Fatalf("compositeError() = %v, want an *AccessError", err)
+	}
+	if accessErr.ReferenceID != "ref0" {
+		t.Errorf("ReferenceID = %s, want ref0", accessErr.ReferenceID)
+	}
+}
+
+// TestUploadBatchesMixedSizes checks the batches a ContentVersion upload
+// sends when a large file sits among many small ones: the small files fill
+// batches up to the subrequest limit and the large one goes alone.
+func TestUploadBatchesMixedSizes(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	var batches [][]string
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite") {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var request struct {
+				CompositeRequest []struct {
+					URL  string         `json:"url"`
+					Body map[string]any `json:"body"`
+				} `json:"compositeRequest"`
+			}
+			json.Unmarshal(body, &request)
+			var titles []string
+			for _, sub := range request.CompositeRequest {
+				if strings.HasSuffix(sub.URL, "/ContentVersion") {
+					titles = append(titles, sub.Body["PathOnClient"].(string))
+				}
+			}
+			if len(titles) > 0 {
+				batches = append(batches, titles)
+			}
+		}
+		org.ServeHTTP(w, r)
+	})
+
+	previousBytes, previousParallel := config.CompositeMaxBytes, config.ParallelRequests
+	config.CompositeMaxBytes, config.ParallelRequests = 64<<10, 1
+	defer func() { config.CompositeMaxBytes, config.ParallelRequests = previousBytes, previousParallel }()
+
+	documents := writeDocuments(t, dir, 30)
+	if err := os.WriteFile(filepath.Join(dir, "bl_003.jpg"), make([]byte, 60<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, batch := range batches {
+		sizes = append(sizes, len(batch))
+	}
+	if want := []int{3, 1, compositeBatchSize, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("batch sizes = %v, want %v", sizes, want)
+	}
+	if batches[1][0] != contentTitle(documents[3]) {
+		t.Errorf("batch sent alone = %v, want the large file", batches[1])
+	}
+}
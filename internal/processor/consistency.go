@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// verifyHierarchy checks that every resolved record really belongs to the
+// record resolved for its parent folder, e.g. that the building found for
+// Zone Z1/Building B1 points at zone Z1. A stale cache entry or a lookup that
+// matched a reused name in another branch would otherwise attach documents
+// to the wrong entity. Levels whose records cannot be queried are skipped
+// with a warning.
+func verifyHierarchy(accessToken string, pathsByLevel map[string]map[string]models.DocumentInfo, foundIds map[string]string, logger *logging.Logger) error {
+	var mismatches []string
+
+	for _, entityType := range filestructure.LookupLevels() {
+		level, _ := filestructure.HierarchyLevel(entityType)
+		if level.SObject == "" || level.ParentField == "" {
+			continue
+		}
+
+		type expectation struct {
+			path     string
+			parentID string
+		}
+		expected := make(map[string]expectation)
+		var ids []string
+		for _, doc := range pathsByLevel[entityType] {
+			parentKey := getParentKey(entityType, doc.NamePath)
+			id := foundIds[entityPathKey(entityType, doc.NamePath)]
+			if parentKey == "" || id == "" || foundIds[parentKey] == "" {
+				continue
+			}
+			expected[salesforceID15(id)] = expectation{path: generateFullPath(doc), parentID: foundIds[parentKey]}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		actual, err := queryParentIds(accessToken, level, ids)
+		if err != nil {
+			logger.Warning("Skipping %s hierarchy check: %v", level.Label, err)
+			continue
+		}
+
+		for id, want := range expected {
+			got, ok := actual[id]
+			if !ok || salesforceID15(got) == salesforceID15(want.parentID) {
+				continue
+			}
+			mismatches = append(mismatches, fmt.Sprintf("%s resolved to %s, which belongs to %s %s instead of %s",
+				want.path, id, level.ParentField, got, want.parentID))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("resolved entities do not match their folders:\n- %s", strings.Join(mismatches, "\n- "))
+	}
+	logger.Debug("Entity hierarchy verified")
+	return nil
+}
+
+// queryParentIds returns each record's parent lookup value, keyed by the
+// record's 15-character ID.
+func queryParentIds(accessToken string, level filestructure.EntityHierarchy, ids []string) (map[string]string, error) {
+	const batchSize = 100
+
+	parents := make(map[string]string)
+	for i := 0; i < len(ids); i += batchSize {
+		end := min(i+batchSize, len(ids))
+		query := fmt.Sprintf("SELECT Id, %s FROM %s WHERE Id IN ('%s')",
+			level.ParentField, level.SObject, strings.Join(ids[i:end], "','"))
+
+		req, err := http.NewRequest("GET", config.DataURL("/query?q="+url.QueryEscape(query)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s query: %v", level.SObject, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s query failed: %v", level.SObject, err)
+		}
+
+		var result struct {
+			Records []map[string]any `json:"records"`
+		}
+		status := resp.StatusCode
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("%s query failed: status %d", level.SObject, status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s query response: %v", level.SObject, err)
+		}
+
+		for _, record := range result.Records {
+			id, _ := record["Id"].(string)
+			parentId, _ := record[level.ParentField].(string)
+			parents[salesforceID15(id)] = parentId
+		}
+	}
+
+	return parents, nil
+}
+
+// salesforceID15 reduces an ID to its case-sensitive 15-character form, so
+// IDs from different APIs compare equal.
+func salesforceID15(id string) string {
+	if len(id) == 18 {
+		return id[:15]
+	}
+	return id
+}
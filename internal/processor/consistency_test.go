@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+var parentQuery = regexp.MustCompile(`SELECT Id, (\w+) FROM (\w+) WHERE`)
+
+func TestVerifyHierarchy(t *testing.T) {
+	zone1 := map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1"}
+	zone2 := map[string]string{"project": "Tower", "phase": "P1", "zone": "Z2"}
+	building := map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1"}
+
+	pathsByLevel := map[string]map[string]models.DocumentInfo{
+		"ZONE": {
+			entityPathKey("ZONE", zone1): {EntityType: "ZONE", NamePath: zone1},
+			entityPathKey("ZONE", zone2): {EntityType: "ZONE", NamePath: zone2},
+		},
+		"BUILDING": {
+			entityPathKey("BUILDING", building): {EntityType: "BUILDING", NamePath: building},
+		},
+	}
+	foundIds := map[string]string{
+		entityPathKey("PHASE", zone1):       "a0P000000000001",
+		entityPathKey("ZONE", zone1):        "a0Z000000000001",
+		entityPathKey("ZONE", zone2):        "a0Z000000000002",
+		entityPathKey("BUILDING", building): "a0B000000000001AAA",
+	}
+
+	tests := []struct {
+		name string
+		// parents is the parent lookup value the org holds for each record.
+		parents map[string]string
+		// unqueryable lists the sObjects the user cannot query.
+		unqueryable string
+		wantErr     string
+	}{
+		{
+			name: "consistent",
+			parents: map[string]string{
+				"a0Z000000000001": "a0P000000000001AAA", "a0Z000000000002": "a0P000000000001",
+				"a0B000000000001": "a0Z000000000001",
+			},
+		},
+		{
+			name: "misfiled building",
+			parents: map[string]string{
+				"a0Z000000000001": "a0P000000000001", "a0Z000000000002": "a0P000000000001",
+				"a0B000000000001": "a0Z000000000002",
+			},
+			wantErr: "Tower/Phase P1/Zone Z1/Building B1 resolved to a0B000000000001, which belongs to Zone__c a0Z000000000002 instead of a0Z000000000001",
+		},
+		{
+			name: "level not queryable",
+			parents: map[string]string{
+				"a0Z000000000001": "a0P000000000001", "a0Z000000000002": "a0P000000000001",
+				"a0B000000000001": "a0Z000000000002",
+			},
+			unqueryable: "Building__c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query().Get("q")
+				match := parentQuery.FindStringSubmatch(query)
+				if match == nil || match[2] == tt.unqueryable {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`[]`))
+					return
+				}
+				var records []map[string]any
+				for _, id := range queryIDs.FindAllStringSubmatch(query, -1) {
+					records = append(records, map[string]any{"Id": id[1], match[1]: tt.parents[salesforceID15(id[1])]})
+				}
+				json.NewEncoder(w).Encode(map[string]any{"records": records})
+			})
+
+			err := verifyHierarchy("token", pathsByLevel, foundIds, logging.GetLogger())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifyHierarchy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifyHierarchy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
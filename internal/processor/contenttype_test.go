@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestContentTypeValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapping   map[string]string
+		values    []string
+		described bool
+		wantErr   bool
+	}{
+		{name: "no mapping", values: []string{"Image", "PDF"}},
+		{name: "custom mapping", mapping: map[string]string{"Image": "Photo"}, values: []string{"Photo", "PDF"}, described: true},
+		{name: "unknown picklist value", mapping: map[string]string{"PDF": "Drawing"}, values: []string{"Image", "Drawing"}, described: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			described := false
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/sobjects/"+attachmentSObject+"/describe") {
+					http.NotFound(w, r)
+					return
+				}
+				described = true
+				var picklist []map[string]any
+				for _, value := range []string{"Photo", "Image", "PDF"} {
+					picklist = append(picklist, map[string]any{"value": value, "active": true})
+				}
+				json.NewEncoder(w).Encode(map[string]any{"fields": []map[string]any{
+					{"name": "Content_Type__c", "type": "picklist", "picklistValues": picklist},
+				}})
+			})
+
+			previous := config.ContentTypeValues
+			config.ContentTypeValues = tt.mapping
+			defer func() { config.ContentTypeValues = previous }()
+
+			documents := []models.DocumentInfo{{ContentType: config.ContentTypeImage}, {ContentType: config.ContentTypePDF}}
+			if got := contentTypeValues(documents); !reflect.DeepEqual(got, tt.values) {
+				t.Errorf("contentTypeValues() = %v, want %v", got, tt.values)
+			}
+
+			err := validateContentTypeValues("token", documents, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateContentTypeValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if described != tt.described {
+				t.Errorf("described = %v, want %v", described, tt.described)
+			}
+		})
+	}
+}
+
+func TestUndetectedContentPolicy(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{"bl_front.jpg": jpegContent, "bl_blob.jpg": {0x00, 0xfe, 0x13, 0x37, 0x00, 0x80, 0x01}}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		policy      string
+		contentType string
+		rejected    bool
+	}{
+		{policy: config.UndetectedContentImage, contentType: config.ContentTypeImage},
+		{policy: config.UndetectedContentDocument, contentType: config.ContentTypeDocument},
+		{policy: config.UndetectedContentFail, rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			previous := config.UndetectedContentPolicy
+			config.UndetectedContentPolicy = tt.policy
+			defer func() { config.UndetectedContentPolicy = previous }()
+
+			documents := []models.DocumentInfo{{RelativePath: "bl_front.jpg"}, {RelativePath: "bl_blob.jpg"}}
+			logger := logging.GetLogger()
+			if err := annotateContent(dir, documents, logger); err != nil {
+				t.Fatal(err)
+			}
+
+			front, blob := documents[0], documents[1]
+			if front.ContentType != config.ContentTypeImage || front.Rejected != "" {
+				t.Errorf("detected file: ContentType = %q, Rejected = %q", front.ContentType, front.Rejected)
+			}
+			kept := dropRejected(documents, logger)
+			report := buildRunReport("test", dir, documents, kept, nil)
+
+			if tt.rejected {
+				if blob.Rejected == "" || len(kept) != 1 {
+					t.Fatalf("undetected file was not rejected: Rejected = %q, %d kept", blob.Rejected, len(kept))
+				}
+				entry := report.Documents[1]
+				if entry.Status != StatusFailed || entry.Error != "content type could not be identified" {
+					t.Errorf("report entry = %+v, want a failure naming the content type", entry)
+				}
+				return
+			}
+			if blob.Rejected != "" || len(kept) != 2 {
+				t.Fatalf("undetected file was rejected: %q", blob.Rejected)
+			}
+			if blob.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", blob.ContentType, tt.contentType)
+			}
+			want := "content type could not be identified, uploading as " + tt.contentType
+			if len(blob.Warnings) == 0 || blob.Warnings[0] != want {
+				t.Errorf("Warnings = %q, want %q", blob.Warnings, want)
+			}
+		})
+	}
+}
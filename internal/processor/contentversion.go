@@ -0,0 +1,233 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// validOrigins and validSharingPrivacy list the picklist values Salesforce
+// accepts for ContentVersion.Origin and ContentVersion.SharingPrivacy.
+var (
+	validOrigins        = map[string]bool{"C": true, "H": true}
+	validSharingPrivacy = map[string]bool{"N": true, "P": true}
+)
+
+type contentVersionOptions struct {
+	description *template.Template
+	fields      map[string]string
+	// externalIDs holds the CONTENT_VERSION_EXTERNAL_ID_FIELD value of each
+	// document by relative path.
+	externalIDs map[string]string
+}
+
+type contentVersionTemplateData struct {
+	EntityPath   string
+	EntityType   string
+	DocumentType string
+	FileName     string
+	RelativePath string
+}
+
+func loadContentVersionOptions(accessToken string, logger *logging.Logger) (*contentVersionOptions, error) {
+	opts := &contentVersionOptions{fields: make(map[string]string)}
+
+	for name, value := range config.ContentVersionFields {
+		opts.fields[name] = value
+	}
+	if config.ContentVersionTags != "" {
+		opts.fields["TagCsv"] = config.ContentVersionTags
+	}
+	if config.ContentVersionOrigin != "" {
+		if !validOrigins[config.ContentVersionOrigin] {
+			return nil, fmt.Errorf("invalid ContentVersion origin %q, expected C (Content) or H (Chatter)", config.ContentVersionOrigin)
+		}
+		opts.fields["Origin"] = config.ContentVersionOrigin
+	}
+	if config.SharingPrivacy != "" {
+		if !validSharingPrivacy[config.SharingPrivacy] {
+			return nil, fmt.Errorf("invalid ContentVersion sharing privacy %q, expected N (none) or P (private on records)", config.SharingPrivacy)
+		}
+		opts.fields["SharingPrivacy"] = config.SharingPrivacy
+	}
+
+	if config.RecordOwnerID != "" {
+		if err := validateOwnerID(config.RecordOwnerID); err != nil {
+			return nil, err
+		}
+		opts.fields["OwnerId"] = config.RecordOwnerID
+	}
+
+	var names []string
+	for name := range opts.fields {
+		names = append(names, name)
+	}
+
+	if config.ContentVersionDescription != "" {
+		tmpl, err := template.New("description").Option("missingkey=error").Parse(config.ContentVersionDescription)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ContentVersion description template: %v", err)
+		}
+		opts.description = tmpl
+		names = append(names, "Description")
+	}
+
+	if err := validateCreateableFields(accessToken, "ContentVersion", names, logger); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// validateOwnerID checks that id looks like a user ID: 15 or 18 letters and
+// digits starting with the User key prefix 005.
+func validateOwnerID(id string) error {
+	if (len(id) != 15 && len(id) != 18) || !strings.HasPrefix(id, "005") {
+		return fmt.Errorf("invalid owner ID %q, expected a 15 or 18 character user ID starting with 005", id)
+	}
+	for _, r := range id {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return fmt.Errorf("invalid owner ID %q, expected only letters and digits", id)
+		}
+	}
+	return nil
+}
+
+func (o *contentVersionOptions) apply(body map[string]any, doc models.DocumentInfo) error {
+	if o == nil {
+		return nil
+	}
+
+	for name, value := range o.fields {
+		body[name] = value
+	}
+
+	if o.description != nil {
+		var buf bytes.Buffer
+		err := o.description.Execute(&buf, contentVersionTemplateData{
+			EntityPath:   generateFullPath(doc),
+			EntityType:   doc.EntityType,
+			DocumentType: doc.DocumentType,
+			FileName:     filepath.Base(doc.FilePath),
+			RelativePath: doc.RelativePath,
+		})
+		if err != nil {
+			return fmt.Errorf("error rendering description for %s: %v", doc.FilePath, err)
+		}
+		body["Description"] = buf.String()
+	}
+
+	if externalID := o.externalIDs[doc.RelativePath]; externalID != "" {
+		body[config.ContentVersionExternalIDField] = externalID
+	}
+
+	return nil
+}
+
+// contentVersionBody returns the fields of a new ContentVersion for doc,
+// except VersionData, which depends on how the file is sent.
+func contentVersionBody(doc models.DocumentInfo, options *contentVersionOptions) (map[string]any, error) {
+	body := map[string]any{
+		"Title":        contentTitle(doc),
+		"PathOnClient": contentTitle(doc),
+	}
+	if locationId := publishLocationID(doc); locationId != "" {
+		body["FirstPublishLocationId"] = locationId
+	}
+	if err := options.apply(body, doc); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// publishLocationID is where a new ContentDocument is first shared: the
+// configured library, otherwise the document's entity record. In link mode
+// without a library the entity link is created explicitly with its own
+// sharing settings, so publishing there as well would produce a duplicate
+// link.
+func publishLocationID(doc models.DocumentInfo) string {
+	if config.ContentLibraryID != "" {
+		return config.ContentLibraryID
+	}
+	if config.AttachmentMode == config.AttachmentModeLink {
+		return ""
+	}
+	return attachmentEntityID(doc)
+}
+
+func fetchContentDocumentIds(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
+	const batchSize = 100
+
+	type versionRef struct {
+		index   int
+		preview bool
+	}
+
+	refsByVersion := make(map[string]versionRef)
+	var versionIds []string
+	for i, doc := range documents {
+		if versionId := doc.SalesforceIds["contentVersionId"]; versionId != "" {
+			refsByVersion[versionId] = versionRef{index: i}
+			versionIds = append(versionIds, versionId)
+		}
+		if versionId := doc.SalesforceIds["previewContentVersionId"]; versionId != "" {
+			refsByVersion[versionId] = versionRef{index: i, preview: true}
+			versionIds = append(versionIds, versionId)
+		}
+	}
+
+	for i := 0; i < len(versionIds); i += batchSize {
+		end := min(i+batchSize, len(versionIds))
+		query := fmt.Sprintf("SELECT Id, ContentDocumentId FROM ContentVersion WHERE Id IN ('%s')",
+			strings.Join(versionIds[i:end], "','"))
+
+		req, err := http.NewRequest("GET",
+			config.DataURL("/query?q="+url.QueryEscape(query)), nil)
+		if err != nil {
+			return fmt.Errorf("error creating ContentVersion query: %v", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("ContentVersion query failed: %v", err)
+		}
+
+		var result struct {
+			Records []struct {
+				Id                string `json:"Id"`
+				ContentDocumentId string `json:"ContentDocumentId"`
+			} `json:"records"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error decoding ContentVersion query response: %v", err)
+		}
+
+		for _, record := range result.Records {
+			ref, ok := refsByVersion[record.Id]
+			if !ok {
+				continue
+			}
+			if ref.preview {
+				documents[ref.index].SalesforceIds["previewContentDocumentId"] = record.ContentDocumentId
+			} else {
+				documents[ref.index].ContentDocumentId = record.ContentDocumentId
+			}
+			logger.Debug("ContentVersion %s belongs to ContentDocument %s", record.Id, record.ContentDocumentId)
+		}
+	}
+
+	return nil
+}
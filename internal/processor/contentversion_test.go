@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// describeContentVersion answers ContentVersion describes with the standard
+// fields a run may set plus one custom field.
+func describeContentVersion(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/sobjects/ContentVersion/describe") {
+		http.NotFound(w, r)
+		return
+	}
+	var fields []map[string]any
+	for _, name := range []string{"Title", "PathOnClient", "Description", "TagCsv", "Origin", "SharingPrivacy", "OwnerId", "Department__c"} {
+		fields = append(fields, map[string]any{"name": name, "type": "string", "createable": true})
+	}
+	json.NewEncoder(w).Encode(map[string]any{"fields": fields})
+}
+
+func TestContentVersionExtraFields(t *testing.T) {
+	doc := models.DocumentInfo{
+		FilePath:     "bl_front.jpg",
+		RelativePath: "Tower/P1/Z1/B1/bl_front.jpg",
+		EntityType:   "BUILDING",
+		DocumentType: config.DocTypeBuildingLocation,
+		NamePath:     map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1"},
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		tags        string
+		fields      map[string]string
+		want        map[string]any
+		wantErr     bool
+	}{
+		{
+			name: "none",
+			want: map[string]any{},
+		},
+		{
+			name:        "description and tags",
+			description: "{{.DocumentType}} of {{.EntityPath}}",
+			tags:        "tower,handover",
+			want: map[string]any{
+				"Description": "Building Location of Tower/Phase P1/Zone Z1/Building B1",
+				"TagCsv":      "tower,handover",
+			},
+		},
+		{
+			name:   "custom field",
+			fields: map[string]string{"Department__c": "Sales"},
+			want:   map[string]any{"Department__c": "Sales"},
+		},
+		{
+			name:    "field missing from the org",
+			fields:  map[string]string{"Region__c": "North"},
+			wantErr: true,
+		},
+		{
+			name:        "unknown template key",
+			description: "{{.Building}}",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, describeContentVersion)
+
+			previousDescription, previousTags, previousFields := config.ContentVersionDescription, config.ContentVersionTags, config.ContentVersionFields
+			config.ContentVersionDescription, config.ContentVersionTags, config.ContentVersionFields = tt.description, tt.tags, tt.fields
+			defer func() {
+				config.ContentVersionDescription, config.ContentVersionTags, config.ContentVersionFields = previousDescription, previousTags, previousFields
+			}()
+
+			options, err := loadContentVersionOptions("token", logging.GetLogger())
+			var body map[string]any
+			if err == nil {
+				body, err = contentVersionBody(doc, options)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			extra := make(map[string]any)
+			for name, value := range body {
+				if name != "Title" && name != "PathOnClient" && name != "FirstPublishLocationId" {
+					extra[name] = value
+				}
+			}
+			if !reflect.DeepEqual(extra, tt.want) {
+				t.Errorf("extra fields = %v, want %v", extra, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentVersionOriginAndSharing(t *testing.T) {
+	doc := models.DocumentInfo{FilePath: "bl_front.jpg", RelativePath: "bl_front.jpg", EntityType: "BUILDING"}
+
+	tests := []struct {
+		name    string
+		origin  string
+		privacy string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "not configured", want: map[string]any{}},
+		{name: "origin", origin: "H", want: map[string]any{"Origin": "H"}},
+		{name: "sharing privacy", privacy: "P", want: map[string]any{"SharingPrivacy": "P"}},
+		{name: "both", origin: "C", privacy: "N", want: map[string]any{"Origin": "C", "SharingPrivacy": "N"}},
+		{name: "invalid origin", origin: "X", wantErr: true},
+		{name: "invalid sharing privacy", privacy: "public", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, describeContentVersion)
+
+			previousOrigin, previousPrivacy := config.ContentVersionOrigin, config.SharingPrivacy
+			config.ContentVersionOrigin, config.SharingPrivacy = tt.origin, tt.privacy
+			defer func() { config.ContentVersionOrigin, config.SharingPrivacy = previousOrigin, previousPrivacy }()
+
+			options, err := loadContentVersionOptions("token", logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadContentVersionOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			body, err := contentVersionBody(doc, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]any)
+			for _, name := range []string{"Origin", "SharingPrivacy"} {
+				if value, ok := body[name]; ok {
+					got[name] = value
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sharing fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
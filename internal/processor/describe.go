@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+type SObjectField struct {
+	Name           string          `json:"name"`
+	Type           string          `json:"type"`
+	Createable     bool            `json:"createable"`
+	ExternalID     bool            `json:"externalId"`
+	PicklistValues []PicklistValue `json:"picklistValues"`
+}
+
+type PicklistValue struct {
+	Value  string `json:"value"`
+	Active bool   `json:"active"`
+}
+
+func describeSObject(accessToken, sobject string) ([]SObjectField, error) {
+	req, err := http.NewRequest("GET",
+		config.DataURL(fmt.Sprintf("/sobjects/%s/describe", url.PathEscape(sobject))), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating describe request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("describe request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("describe %s failed: status %d: %s", sobject, resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Fields []SObjectField `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding describe response: %v", err)
+	}
+
+	return result.Fields, nil
+}
+
+// validateCreateableFields checks that every named field exists on the sObject
+// and can be set on create. When the describe call itself fails the check is
+// skipped with a warning, since not every user can describe every object.
+func validateCreateableFields(accessToken, sobject string, names []string, logger *logging.Logger) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	fields, err := describeSObject(accessToken, sobject)
+	if err != nil {
+		logger.Warning("Skipping %s field validation: %v", sobject, err)
+		return nil
+	}
+
+	createable := make(map[string]bool)
+	for _, field := range fields {
+		createable[strings.ToLower(field.Name)] = field.Createable
+	}
+
+	var invalid []string
+	for _, name := range names {
+		if !createable[strings.ToLower(name)] {
+			invalid = append(invalid, name)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("unknown or non-createable %s fields: %s", sobject, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// validateExternalIDField checks that the named field exists, can be set on
+// create and is marked as an external ID, so it is indexed for the lookups
+// that deduplicate uploads. Like the other checks it only warns when the
+// describe is unavailable.
+func validateExternalIDField(accessToken, sobject, name string, logger *logging.Logger) error {
+	fields, err := describeSObject(accessToken, sobject)
+	if err != nil {
+		logger.Warning("Skipping %s.%s external ID validation: %v", sobject, name, err)
+		return nil
+	}
+
+	for _, field := range fields {
+		if !strings.EqualFold(field.Name, name) {
+			continue
+		}
+		if !field.Createable || !field.ExternalID {
+			return fmt.Errorf("%s.%s must be a createable external ID field", sobject, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no field %s; create it as an external ID text field first", sobject, name)
+}
+
+// validatePicklistValues checks that every value is an active entry of the
+// field's picklist. Like validateCreateableFields it only warns when the
+// describe is unavailable.
+func validatePicklistValues(accessToken, sobject, fieldName string, values []string, logger *logging.Logger) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	fields, err := describeSObject(accessToken, sobject)
+	if err != nil {
+		logger.Warning("Skipping %s.%s picklist validation: %v", sobject, fieldName, err)
+		return nil
+	}
+
+	active := make(map[string]bool)
+	for _, field := range fields {
+		if !strings.EqualFold(field.Name, fieldName) {
+			continue
+		}
+		if field.Type != "picklist" && field.Type != "multipicklist" {
+			return nil
+		}
+		for _, value := range field.PicklistValues {
+			active[value.Value] = value.Active
+		}
+	}
+
+	var invalid []string
+	for _, value := range values {
+		if !active[value] {
+			invalid = append(invalid, value)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s.%s has no active picklist value %s", sobject, fieldName, strings.Join(invalid, ", "))
+	}
+	return nil
+}
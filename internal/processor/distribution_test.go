@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func useDistributionRetry(t *testing.T, timeout, delay time.Duration) {
+	t.Helper()
+	previousTimeout, previousDelay := distributionURLTimeout, distributionURLRetryDelay
+	distributionURLTimeout, distributionURLRetryDelay = timeout, delay
+	t.Cleanup(func() { distributionURLTimeout, distributionURLRetryDelay = previousTimeout, previousDelay })
+}
+
+func TestAwaitDistributionDownloadUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		emptyFor int32
+		timeout  time.Duration
+		want     string
+		calls    int32
+	}{
+		{name: "populated at once", emptyFor: 0, timeout: time.Second, want: "https://acme.file.force.com/05D1", calls: 1},
+		{name: "populated after two reads", emptyFor: 2, timeout: time.Second, want: "https://acme.file.force.com/05D1", calls: 3},
+		{name: "never populated", emptyFor: 1000, timeout: 50 * time.Millisecond, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDistributionRetry(t, tt.timeout, 5*time.Millisecond)
+			var calls atomic.Int32
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.emptyFor {
+					json.NewEncoder(w).Encode(map[string]any{"ContentDownloadUrl": nil, "DistributionPublicUrl": nil})
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"ContentDownloadUrl": "https://acme.file.force.com/05D1"})
+			})
+
+			got, err := awaitDistributionDownloadUrl(context.Background(), "token", "05D1", logging.GetLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("download URL = %q, want %q", got, tt.want)
+			}
+			if tt.calls > 0 && calls.Load() != tt.calls {
+				t.Errorf("details read %d times, want %d", calls.Load(), tt.calls)
+			}
+			if tt.want == "" && calls.Load() < 2 {
+				t.Errorf("details read %d times, want retries before giving up", calls.Load())
+			}
+		})
+	}
+}
+
+func TestAwaitDistributionDownloadUrlCancelled(t *testing.T) {
+	useDistributionRetry(t, time.Hour, time.Minute)
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"ContentDownloadUrl": nil})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := awaitDistributionDownloadUrl(ctx, "token", "05D1", logging.GetLogger()); err != context.DeadlineExceeded {
+		t.Errorf("awaitDistributionDownloadUrl() = %v, want the context error", err)
+	}
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// DocumentTypes lists the document types a run can be restricted to, in the
+// order they are offered to the user.
+func DocumentTypes() []string {
+	return []string{
+		config.DocTypeBuildingLocation,
+		config.DocTypeFinish,
+		config.DocTypeFloorPlan,
+		config.DocTypeGallery,
+		config.DocTypeProjectPlan,
+		config.DocTypeUnitPlan,
+		config.DocTypeGeneric,
+	}
+}
+
+// resolveDocumentType accepts a document type by name or by its file name
+// prefix, e.g. "Gallery" or "g", and returns its name.
+func resolveDocumentType(docType string) (string, error) {
+	docType = strings.TrimSpace(docType)
+	for _, name := range DocumentTypes() {
+		if strings.EqualFold(name, docType) {
+			return name, nil
+		}
+	}
+	info := &models.DocumentInfo{}
+	if err := parseDocumentType(strings.ToLower(docType), info); err == nil && len(info.Warnings) == 0 {
+		return info.DocumentType, nil
+	}
+	return "", fmt.Errorf("unknown document type: %s", docType)
+}
+
+// filterDocumentType keeps the documents of one type across the whole tree,
+// for re-uploading a category that was regenerated. An empty type keeps all.
+func filterDocumentType(documents []models.DocumentInfo, docType string, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	if strings.TrimSpace(docType) == "" {
+		return documents, nil
+	}
+	name, err := resolveDocumentType(docType)
+	if err != nil {
+		return nil, err
+	}
+
+	var included []models.DocumentInfo
+	for _, doc := range documents {
+		if doc.DocumentType == name {
+			included = append(included, doc)
+		}
+	}
+
+	logger.Info("Document type filter %q: %d document(s) included, %d excluded",
+		name, len(included), len(documents)-len(included))
+
+	if len(included) == 0 {
+		return nil, fmt.Errorf("no %s documents found", name)
+	}
+	return included, nil
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestResolveDocumentType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "Gallery", want: config.DocTypeGallery},
+		{input: " gallery ", want: config.DocTypeGallery},
+		{input: "fp", want: config.DocTypeFloorPlan},
+		{input: "Brochure", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolveDocumentType(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveDocumentType(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+// TestProcessDocumentsByType runs a directory restricted to galleries and
+// checks that nothing else is uploaded or appears in the run report.
+func TestProcessDocumentsByType(t *testing.T) {
+	dir := inTempDir(t)
+	building := filepath.Join(dir, "docs", "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"g_front.jpg", "g_back.jpg", "bl_site.jpg", "fp_plan.jpg"} {
+		if err := os.WriteFile(filepath.Join(building, name), jpegContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	org := newFakeOrg()
+	lookup := &fakeLookup{}
+	sent := useFakeOrg(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/bulk-lookup") {
+			lookup.ServeHTTP(w, r)
+			return
+		}
+		org.ServeHTTP(w, r)
+	}))
+	previousLookupURL := config.BulkLookupURL
+	config.BulkLookupURL = config.SFInstanceURL + "/services/apexrest/admin/bulk-lookup"
+	defer func() { config.BulkLookupURL = previousLookupURL }()
+
+	result, err := ProcessDocuments("token", models.DirectoryRun{DocumentsDir: filepath.Join(dir, "docs"), DocumentType: "g"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.RecordIDs) != 2 {
+		t.Errorf("%d records created, want one per gallery", len(result.RecordIDs))
+	}
+	for _, sub := range sent() {
+		if sub.SObject == "ContentVersion" && !strings.HasPrefix(sub.Body["PathOnClient"].(string), "g_") {
+			t.Errorf("uploaded %v, want only galleries", sub.Body["PathOnClient"])
+		}
+	}
+
+	report, err := LoadRunReport(result.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []string
+	for _, entry := range report.Documents {
+		reported = append(reported, filepath.Base(entry.RelativePath)+" "+entry.Status)
+	}
+	if want := "g_back.jpg uploaded,g_front.jpg uploaded"; strings.Join(reported, ",") != want {
+		t.Errorf("report = %v, want %s", reported, want)
+	}
+}
+
+func TestFilterDocumentTypeNoMatches(t *testing.T) {
+	documents := []models.DocumentInfo{{RelativePath: "bl_site.jpg", DocumentType: config.DocTypeBuildingLocation}}
+	if _, err := filterDocumentType(documents, "Gallery", logging.GetLogger()); err == nil || err.Error() != "no Gallery documents found" {
+		t.Errorf("filterDocumentType() = %v, want no Gallery documents found", err)
+	}
+}
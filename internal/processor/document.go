@@ -2,113 +2,469 @@ package processor
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/ORAITApps/document-uploader/internal/config"
 	"github.com/ORAITApps/document-uploader/internal/filestructure"
-	"github.com/ORAITApps/document-uploader/internal/gui"
 	logging "github.com/ORAITApps/document-uploader/internal/logger"
 	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/ORAITApps/document-uploader/internal/salesforce"
 	"github.com/gabriel-vasile/mimetype"
 )
 
+var httpClient = http.DefaultClient
+
+// SetHTTPClient replaces the client used for all Salesforce requests.
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
 type LookupError struct {
 	EntityType string
 	Path       string
 	Details    string
 }
 
-type EntityHierarchy struct {
-	Type    string
-	Parent  string
-	IDKey   string
-	NameKey string
+type LookupMatch struct {
+	Lookup models.EntityLookup
+	ID     string
 }
 
-var hierarchyDefinition = []EntityHierarchy{
-	{Type: "PROJECT", Parent: "", IDKey: "project", NameKey: "project"},
-	{Type: "PHASE", Parent: "PROJECT", IDKey: "phase", NameKey: "phase"},
-	{Type: "ZONE", Parent: "PHASE", IDKey: "zone", NameKey: "zone"},
-	{Type: "BUILDING", Parent: "ZONE", IDKey: "building", NameKey: "building"},
-	{Type: "UNIT", Parent: "BUILDING", IDKey: "unit", NameKey: "unit"},
-	{Type: "DESIGN_TYPE", Parent: "PHASE", IDKey: "design_type", NameKey: "designType"},
+type LookupFailure struct {
+	Lookup  models.EntityLookup
+	Message string
 }
 
-func ProcessDocuments(accessToken, documentsDir string, app *gui.App) error {
+// BulkLookupResult splits the raw bulk-lookup response, where failures are
+// reported as values prefixed with "ERROR:", into matches and failures.
+type BulkLookupResult struct {
+	Matches  []LookupMatch
+	Failures []LookupFailure
+}
+
+// ProcessDocuments uploads the documents of a directory run. Like
+// ProcessFiles and ResumeFromReport, it expects the caller to hold the run
+// guard from TryStartRun.
+func ProcessDocuments(accessToken string, run models.DirectoryRun, reporter *progress.Reporter) (*RunResult, error) {
 	logger := logging.GetLogger()
+	documentsDir := run.DocumentsDir
+
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
 
 	if documentsDir == "" {
-		return fmt.Errorf("no documents directory selected")
+		return nil, fmt.Errorf("no documents directory selected")
+	}
+	if absDir, err := filepath.Abs(documentsDir); err == nil {
+		documentsDir = absDir
 	}
 
-	if _, err := os.Stat(documentsDir); os.IsNotExist(err) {
-		return fmt.Errorf("documents directory does not exist: %s", documentsDir)
+	info, err := os.Stat(documentsDir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("documents directory does not exist: %s", documentsDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access documents directory %s: %v", documentsDir, err)
+	}
+	if !info.IsDir() {
+		logger.Info("%s is a file, not a directory; processing it as a single file", documentsDir)
+		return processFiles(accessToken, []string{documentsDir}, logger, reporter)
 	}
 
-	app.SetStatus("Collecting documents...")
-	documents, err := collectDocuments(documentsDir, logger)
+	exclude, err := filestructure.ParseExcludePatterns(run.ExcludePatterns)
 	if err != nil {
-		return fmt.Errorf("error collecting documents: %v", err)
+		return nil, err
 	}
-	app.SetProgress(0.2)
 
-	app.SetStatus("Looking up entities...")
-	if err := bulkLookupEntities(accessToken, documents, logger); err != nil {
-		return fmt.Errorf("bulk lookup failed: %v", err)
+	reporter.Phase(progress.PhaseCollect, "Collecting documents...")
+	documents, err := collectDocuments(documentsDir, exclude, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error collecting documents: %v", err)
+	}
+
+	documents, err = filterSubtree(documents, run.SubtreeFilter, logger)
+	if err != nil {
+		return nil, err
+	}
+	documents, err = filterDocumentType(documents, run.DocumentType, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return processCollectedDocuments(accessToken, documentsDir, documents, logger, reporter)
+}
+
+// ProcessFiles uploads individually selected files. Their names must follow
+// the flat naming convention understood by ParseFileName since there is no
+// folder structure to derive the entity path from.
+func ProcessFiles(accessToken string, filePaths []string, reporter *progress.Reporter) (*RunResult, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	return processFiles(accessToken, filePaths, logging.GetLogger(), reporter)
+}
+
+func processFiles(accessToken string, filePaths []string, logger *logging.Logger, reporter *progress.Reporter) (*RunResult, error) {
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("no files selected")
+	}
+
+	reporter.Phase(progress.PhaseCollect, "Collecting documents...")
+	documents, err := collectFiles(filePaths, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error collecting documents: %v", err)
+	}
+
+	return processCollectedDocuments(accessToken, "", documents, logger, reporter)
+}
+
+// ResumeFromReport re-runs only the documents a previous run report marked as
+// failed or skipped, collecting them again from the same source.
+func ResumeFromReport(accessToken, reportPath string, reporter *progress.Reporter) (*RunResult, error) {
+	logger := logging.GetLogger()
+
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	report, err := LoadRunReport(reportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	retryPaths := report.retryPaths()
+	if len(retryPaths) == 0 {
+		logger.Success("Run %s has no failed or skipped documents, nothing to resume", report.RunID)
+		return nil, nil
+	}
+	logger.Info("Resuming run %s: %d document(s) to retry", report.RunID, len(retryPaths))
+
+	reporter.Phase(progress.PhaseCollect, "Collecting documents...")
+	var documents []models.DocumentInfo
+	if report.DocumentsDir == "" {
+		documents, err = collectFiles(retryPaths, logger)
+	} else {
+		documents, err = collectDocuments(report.DocumentsDir, nil, logger)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error collecting documents: %v", err)
+	}
+
+	documents, err = report.retrySet(documents, logger)
+	if err != nil {
+		return nil, err
 	}
-	app.SetProgress(0.4)
 
-	app.SetStatus("Uploading content...")
-	if err := bulkUploadContentVersions(accessToken, documentsDir, documents, logger, app); err != nil {
+	return processCollectedDocuments(accessToken, report.DocumentsDir, documents, logger, reporter)
+}
+
+func processCollectedDocuments(accessToken, documentsDir string, documents []models.DocumentInfo, logger *logging.Logger, reporter *progress.Reporter) (result *RunResult, err error) {
+	limitsBefore := snapshotAPILimits(accessToken, logger)
+	defer reportAPIUsage(accessToken, limitsBefore, logger)
+
+	sortDocuments(documents)
+	collected := documents
+	documents = dropRejected(documents, logger)
+	documents, err = resolveCollisions(documentsDir, documents, config.CollisionPolicy, logger)
+	if err != nil {
+		return nil, err
+	}
+	documents, err = checkFileSizes(documentsDir, documents, config.MaxFileSize, config.SkipOversizedFiles, logger)
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no documents left to upload")
+	}
+	parseReport := buildParseReport(documents)
+	parseReport.Rejected = rejectedWarnings(collected)
+	logParseReport(parseReport, logger)
+	reporter.Progress(0.2)
+
+	ctx := context.Background()
+	runID := newRunID()
+	logger.Info("Run ID: %s", runID)
+
+	defer func() {
+		if config.RunMode == config.RunModeCSV {
+			return
+		}
+		path := writeRunReport(buildRunReport(runID, documentsDir, collected, documents, err), logger)
+		if err != nil && path != "" {
+			err = &RunError{ReportPath: path, Err: err}
+		}
+		if result != nil {
+			result.ReportPath = path
+		}
+	}()
+
+	checkpoint := &checkpointer{
+		runID:        runID,
+		documentsDir: documentsDir,
+		collected:    collected,
+		documents:    &documents,
+		logger:       logger,
+	}
+
+	var reconnect *reconnector
+	if config.AutoResume {
+		reconnect = &reconnector{
+			timeout: config.AutoResumeTimeout,
+			checkpoint: func() {
+				checkpoint.save(errConnectionLost)
+			},
+			logger:   logger,
+			reporter: reporter,
+		}
+	}
+
+	audit, err := openAuditLog(runID)
+	if err != nil {
+		logger.Warning("Audit log unavailable: %v", err)
+	}
+	defer audit.Close()
+	created := newCreatedRecords()
+
+	reporter.Phase(progress.PhaseLookup, "Looking up entities...")
+	err = withPhaseTimeout(ctx, progress.PhaseLookup, config.LookupTimeout, func(ctx context.Context) error {
+		return bulkLookupEntities(ctx, accessToken, documents, logger)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bulk lookup failed: %w", err)
+	}
+	documents = checkDuplicateTitles(accessToken, documents, config.DuplicateTitlePolicy, logger)
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no documents left to upload: every file is already in Salesforce")
+	}
+	reporter.Progress(0.4)
+
+	if config.RunMode == config.RunModeCSV {
+		reporter.Phase(progress.PhaseExport, "Exporting Data Loader CSV...")
+		exportDir, err := exportDataLoaderCSV(documentsDir, documents, logger)
+		if err != nil {
+			logger.Error("CSV export failed: %v", err)
+			return nil, fmt.Errorf("csv export failed: %v", err)
+		}
+		reporter.Progress(1.0)
+		logger.Success("Data Loader files written to %s", exportDir)
+		return nil, nil
+	}
+
+	reporter.Phase(progress.PhaseUpload, "Uploading content...")
+	err = withPhaseTimeout(ctx, progress.PhaseUpload, config.UploadTimeout, func(ctx context.Context) error {
+		return bulkUploadContentVersions(ctx, accessToken, documentsDir, documents, audit, created, reconnect, checkpoint, logger, reporter)
+	})
+	if err != nil {
 		logger.Error("Bulk content upload failed: %v", err)
-		return fmt.Errorf("bulk content upload failed: %v", err)
+		rollbackIfEnabled(accessToken, runID, documents, created, audit, logger, reporter)
+		return nil, fmt.Errorf("bulk content upload failed: %w", err)
 	}
-	app.SetProgress(0.8)
+	reporter.Progress(0.8)
 
-	app.SetStatus("Creating attachment records...")
-	if err := bulkCreateAttachmentUploaders(accessToken, documents, logger); err != nil {
+	if config.AttachmentMode == config.AttachmentModeLink {
+		reporter.Progress(1.0)
+		logger.Info("Document processing completed successfully")
+		return newRunResult(runID, documents), nil
+	}
+
+	reporter.Phase(progress.PhaseAttach, "Creating attachment records...")
+	err = withPhaseTimeout(ctx, progress.PhaseAttach, config.AttachTimeout, func(ctx context.Context) error {
+		return bulkCreateAttachmentUploaders(ctx, accessToken, runID, documents, audit, created, reconnect, checkpoint, logger)
+	})
+	if err != nil {
 		logger.Error("Bulk attachment uploader creation failed: %v", err)
-		return fmt.Errorf("bulk attachment uploader creation failed: %v", err)
+		verifyIfEnabled(accessToken, runID, documents, logger)
+		rollbackIfEnabled(accessToken, runID, documents, created, audit, logger, reporter)
+		return nil, fmt.Errorf("bulk attachment uploader creation failed: %w", err)
 	}
-	app.SetProgress(1.0)
+	verifyIfEnabled(accessToken, runID, documents, logger)
+	reporter.Progress(1.0)
 
 	logger.Info("Document processing completed successfully")
-	return nil
+	return newRunResult(runID, documents), nil
 }
 
-func collectDocuments(documentsDir string, logger *logging.Logger) ([]models.DocumentInfo, error) {
+// scanProgressInterval is how many files collection handles between progress
+// messages; per-file details are only logged at debug level.
+const scanProgressInterval = 5000
+
+func collectDocuments(documentsDir string, exclude []string, logger *logging.Logger) ([]models.DocumentInfo, error) {
 	logger.Info("Reading documents from directory: %s", documentsDir)
 
-	if _, err := os.Stat(documentsDir); os.IsNotExist(err) {
+	info, err := os.Stat(documentsDir)
+	if os.IsNotExist(err) {
 		logger.Error("Directory not found: %s", documentsDir)
 		return nil, fmt.Errorf("directory not found: %s", documentsDir)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %v", documentsDir, err)
+	}
+	if !info.IsDir() {
+		logger.Error("Not a directory: %s", documentsDir)
+		return nil, fmt.Errorf("%s is a file, not a directory; select the folder that contains it", documentsDir)
+	}
+
+	levelHint := filestructure.CheckRootLevel(documentsDir)
+	if levelHint != "" {
+		logger.Warning("Directory structure check: %s", levelHint)
+	}
+
+	appPaths := filestructure.AppPaths()
+	if warning := filestructure.CheckAppDirectory(documentsDir, appPaths); warning != "" {
+		logger.Warning("Directory check: %s", warning)
+	}
 
 	walker := filestructure.NewDocumentWalker(documentsDir)
+	walker.SetFollowSymlinks(config.FollowSymlinks)
+	walker.SetLenientDocumentTypes(config.LenientDocumentTypes)
+	walker.SetSkipPaths(appPaths)
+	walker.SetExcludePatterns(exclude)
+	walker.SetProgressHandler(scanProgressInterval, func(scanned int) {
+		logger.Info("Scanned %d files...", scanned)
+	})
 	documents, err := walker.Walk()
 	if err != nil {
 		logger.Error("Failed to walk documents directory: %v", err)
+		if levelHint != "" {
+			return nil, fmt.Errorf("error walking documents directory: %v\nHint: %s", err, levelHint)
+		}
 		return nil, fmt.Errorf("error walking documents directory: %v", err)
 	}
+	if count := walker.ExcludedCount(); count > 0 {
+		logger.Info("Excluded %d file(s) matching %s", count, strings.Join(exclude, ", "))
+	}
 
 	if len(documents) == 0 {
 		logger.Warning("No documents found in directory")
 		return nil, fmt.Errorf("no documents found in directory: %s", documentsDir)
 	}
 
+	if err := annotateContent(documentsDir, documents, logger); err != nil {
+		return nil, err
+	}
+
+	if err := loadDocumentMetadata(documentsDir, config.MetadataCSV, documents, logger); err != nil {
+		return nil, err
+	}
+
+	if err := checkTitles(documents, config.TruncateLongTitles, logger); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Collected %d documents for processing", len(documents))
+	return documents, nil
+}
+
+func collectFiles(filePaths []string, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	logger.Info("Reading %d selected files", len(filePaths))
+
+	var documents []models.DocumentInfo
+	for _, path := range filePaths {
+		doc, err := ParseFile(path)
+		if err != nil {
+			logger.Error("Failed to parse %s: %v", path, err)
+			return nil, err
+		}
+		documents = append(documents, *doc)
+	}
+
+	if err := annotateContent("", documents, logger); err != nil {
+		return nil, err
+	}
+
+	if err := loadDocumentMetadata("", config.MetadataCSV, documents, logger); err != nil {
+		return nil, err
+	}
+
+	if err := checkTitles(documents, config.TruncateLongTitles, logger); err != nil {
+		return nil, err
+	}
+
 	logger.Info("Collected %d documents for processing", len(documents))
 	return documents, nil
 }
 
-func executeBulkLookup(accessToken string, bulkRequest models.BulkLookupRequest, logger *logging.Logger) (map[string]string, error) {
+// mimeSniffLimit caps how much of each file is read to detect its type, so
+// huge files cost no more than small ones.
+const mimeSniffLimit = 3072
+
+func sniffContent(fullPath string) (*mimetype.MIME, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, mimeSniffLimit)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return mimetype.Detect(header[:n]), nil
+}
+
+// annotateContent detects each file's type once, recording the ContentType
+// used for the attachment record and any extension mismatch warning. Files
+// that cannot be read are reported together rather than mislabelled, and
+// files of unknown type are handled as UNDETECTED_CONTENT_POLICY says.
+func annotateContent(documentsDir string, documents []models.DocumentInfo, logger *logging.Logger) error {
+	var unreadable []string
+	for i := range documents {
+		if i > 0 && i%scanProgressInterval == 0 {
+			logger.Info("Checked the content of %d of %d files...", i, len(documents))
+		}
+		if documents[i].Rejected != "" {
+			continue
+		}
+		fullPath := filepath.Join(documentsDir, documents[i].RelativePath)
+
+		detected, err := sniffContent(fullPath)
+		if err != nil {
+			logger.Error("Cannot read %s: %v", documents[i].RelativePath, err)
+			unreadable = append(unreadable, fmt.Sprintf("%s: %v", documents[i].RelativePath, err))
+			continue
+		}
+
+		contentType := contentTypeFor(detected)
+		if detected.Is("application/octet-stream") {
+			switch config.UndetectedContentPolicy {
+			case config.UndetectedContentFail:
+				documents[i].Rejected = "content type could not be identified"
+				continue
+			case config.UndetectedContentDocument:
+				contentType = config.ContentTypeDocument
+			}
+			documents[i].Warnings = append(documents[i].Warnings,
+				fmt.Sprintf("content type could not be identified, uploading as %s", contentType))
+		}
+
+		documents[i].ContentType = contentType
+		if warning := detectExtensionMismatch(fullPath, detected); warning != "" {
+			documents[i].Warnings = append(documents[i].Warnings, warning)
+		}
+	}
+
+	if len(unreadable) > 0 {
+		return fmt.Errorf("%d file(s) could not be read:\n- %s", len(unreadable), strings.Join(unreadable, "\n- "))
+	}
+	return nil
+}
+
+func executeBulkLookup(ctx context.Context, accessToken string, bulkRequest models.BulkLookupRequest, logger *logging.Logger) (*BulkLookupResult, error) {
 	jsonData, err := json.Marshal(bulkRequest)
 	if err != nil {
 		logger.Error("Failed to marshal bulk lookup request: %v", err)
@@ -118,7 +474,7 @@ func executeBulkLookup(accessToken string, bulkRequest models.BulkLookupRequest,
 	logger.Debug("Bulk lookup request payload: %s", string(jsonData))
 
 	logger.Debug("Sending bulk lookup request to Salesforce")
-	req, err := http.NewRequest("POST", config.BulkLookupURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", config.BulkLookupURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		logger.Error("Failed to create bulk lookup request: %v", err)
 		return nil, err
@@ -127,7 +483,7 @@ func executeBulkLookup(accessToken string, bulkRequest models.BulkLookupRequest,
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		logger.Error("Failed to execute bulk lookup request: %v", err)
 		return nil, err
@@ -137,96 +493,106 @@ func executeBulkLookup(accessToken string, bulkRequest models.BulkLookupRequest,
 	respBody, _ := io.ReadAll(resp.Body)
 	logger.Debug("Bulk lookup response: %s", string(respBody))
 
-	var results map[string]string
-	if err := json.Unmarshal(respBody, &results); err != nil {
+	results, err := decodeBulkLookupResponse(respBody)
+	if err != nil {
 		logger.Error("Failed to decode bulk lookup response: %v", err)
 		return nil, err
 	}
 
+	return classifyLookupResults(results, logger), nil
+}
+
+type bulkLookupPair struct {
+	Key   json.RawMessage `json:"key"`
+	Value string          `json:"value"`
+}
+
+// decodeBulkLookupResponse accepts both shapes the bulk-lookup Apex endpoint
+// is known to return. Each key is the JSON-encoded EntityLookup that was
+// requested and each value is the matched record ID, or "ERROR: <message>":
+//
+//	{"<lookup json>": "<id>", ...}
+//	[{"key": "<lookup json>", "value": "<id>"}, ...]
+//
+// In the array shape the key may also be the lookup object itself.
+func decodeBulkLookupResponse(body []byte) (map[string]string, error) {
+	var results map[string]string
+	mapErr := json.Unmarshal(body, &results)
+	if mapErr == nil {
+		return results, nil
+	}
+
+	var pairs []bulkLookupPair
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("response is neither a map nor a list of key/value pairs: %v", mapErr)
+	}
+
+	results = make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		var key string
+		if err := json.Unmarshal(pair.Key, &key); err != nil {
+			key = string(pair.Key)
+		}
+		results[key] = pair.Value
+	}
 	return results, nil
 }
 
-func bulkLookupEntities(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
+func classifyLookupResults(results map[string]string, logger *logging.Logger) *BulkLookupResult {
+	classified := &BulkLookupResult{}
+	for resultKey, resultId := range results {
+		var lookup models.EntityLookup
+		if err := json.Unmarshal([]byte(resultKey), &lookup); err != nil {
+			logger.Warning("Ignoring unrecognised bulk lookup key %q: %v", resultKey, err)
+			continue
+		}
+
+		if strings.HasPrefix(resultId, "ERROR:") {
+			classified.Failures = append(classified.Failures, LookupFailure{
+				Lookup:  lookup,
+				Message: strings.TrimSpace(strings.TrimPrefix(resultId, "ERROR:")),
+			})
+			continue
+		}
+
+		classified.Matches = append(classified.Matches, LookupMatch{Lookup: lookup, ID: resultId})
+	}
+	return classified
+}
+
+func bulkLookupEntities(ctx context.Context, accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
 	logger.Info("Starting bulk entity lookup for %d documents", len(documents))
 
+	if err := resolveRecordEntities(accessToken, documents, logger); err != nil {
+		return err
+	}
+
 	pathsByLevel := make(map[string]map[string]models.DocumentInfo)
 	foundIds := make(map[string]string)
 	var lookupErrors []LookupError
 
-	levels := []string{"PHASE", "ZONE", "BUILDING", "UNIT", "DESIGN_TYPE"}
+	cache := openLookupCache(config.LookupCacheTTL, config.LookupCacheRefresh, logger)
+	defer func() {
+		if err := cache.save(); err != nil {
+			logger.Warning("Lookup cache not saved: %v", err)
+		}
+	}()
+	cacheHits := 0
+
+	levels := filestructure.LookupLevels()
 	for _, level := range levels {
 		pathsByLevel[level] = make(map[string]models.DocumentInfo)
 	}
 
 	for _, doc := range documents {
-		switch doc.EntityType {
-		case "UNIT":
-			pathsByLevel["PHASE"][doc.NamePath["phase"]] = models.DocumentInfo{
-				EntityType: "PHASE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-				},
-			}
-			pathsByLevel["ZONE"][doc.NamePath["zone"]] = models.DocumentInfo{
-				EntityType: "ZONE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-					"zone":    doc.NamePath["zone"],
-				},
-			}
-			pathsByLevel["BUILDING"][doc.NamePath["building"]] = models.DocumentInfo{
-				EntityType: "BUILDING",
-				NamePath: map[string]string{
-					"project":  doc.NamePath["project"],
-					"phase":    doc.NamePath["phase"],
-					"zone":     doc.NamePath["zone"],
-					"building": doc.NamePath["building"],
-				},
-			}
-			pathsByLevel["UNIT"][doc.NamePath["unit"]] = doc
-
-		case "BUILDING":
-			pathsByLevel["PHASE"][doc.NamePath["phase"]] = models.DocumentInfo{
-				EntityType: "PHASE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-				},
-			}
-			pathsByLevel["ZONE"][doc.NamePath["zone"]] = models.DocumentInfo{
-				EntityType: "ZONE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-					"zone":    doc.NamePath["zone"],
-				},
-			}
-			pathsByLevel["BUILDING"][doc.NamePath["building"]] = doc
-
-		case "ZONE":
-			pathsByLevel["PHASE"][doc.NamePath["phase"]] = models.DocumentInfo{
-				EntityType: "PHASE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-				},
-			}
-			pathsByLevel["ZONE"][doc.NamePath["zone"]] = doc
-
-		case "PHASE":
-			pathsByLevel["PHASE"][doc.NamePath["phase"]] = doc
-
-		case "DESIGN_TYPE":
-			pathsByLevel["PHASE"][doc.NamePath["phase"]] = models.DocumentInfo{
-				EntityType: "PHASE",
-				NamePath: map[string]string{
-					"project": doc.NamePath["project"],
-					"phase":   doc.NamePath["phase"],
-				},
+		for _, level := range filestructure.Ancestry(doc.EntityType) {
+			if level.Parent == "" {
+				continue
 			}
-			pathsByLevel["DESIGN_TYPE"][doc.NamePath["designType"]] = doc
+			addLevelLookup(pathsByLevel[level.Type], models.DocumentInfo{
+				EntityType: level.Type,
+				NamePath:   filestructure.LevelNamePath(level.Type, doc.NamePath),
+			})
 		}
 	}
 
@@ -247,6 +613,11 @@ func bulkLookupEntities(accessToken string, documents []models.DocumentInfo, log
 					continue
 				}
 			}
+			if cachedId, ok := cache.get(entityPathKey(entityType, doc.NamePath)); ok {
+				applyLookupMatch(entityType, doc.NamePath, cachedId, foundIds, documents, logger)
+				cacheHits++
+				continue
+			}
 			bulkRequest.Lookups = append(bulkRequest.Lookups, models.EntityLookup{
 				EntityType: entityType,
 				NamePath:   doc.NamePath,
@@ -257,39 +628,29 @@ func bulkLookupEntities(accessToken string, documents []models.DocumentInfo, log
 			continue
 		}
 
-		results, err := executeBulkLookup(accessToken, bulkRequest, logger)
+		results, err := executeBulkLookup(ctx, accessToken, bulkRequest, logger)
 		if err != nil {
 			return err
 		}
 
-		for resultKey, resultId := range results {
-			var resultLookup models.EntityLookup
-			if err := json.Unmarshal([]byte(resultKey), &resultLookup); err != nil {
-				continue
-			}
-
-			if strings.HasPrefix(resultId, "ERROR:") {
-				lookupErrors = append(lookupErrors, LookupError{
-					EntityType: entityType,
-					Path:       generateFullPath(models.DocumentInfo{EntityType: entityType, NamePath: resultLookup.NamePath}),
-					Details:    resultId,
-				})
-				continue
-			}
-
-			idKey := strings.ToLower(entityType)
-			key := fmt.Sprintf("%s_%s", entityType, resultLookup.NamePath[idKey])
-			foundIds[key] = resultId
-			logger.Debug("Found ID for %s: %s", key, resultId)
+		for _, failure := range results.Failures {
+			lookupErrors = append(lookupErrors, LookupError{
+				EntityType: entityType,
+				Path:       generateFullPath(models.DocumentInfo{EntityType: entityType, NamePath: failure.Lookup.NamePath}),
+				Details:    failure.Message,
+			})
+		}
 
-			for i := range documents {
-				if documents[i].EntityType == entityType && compareNamePaths(resultLookup.NamePath, documents[i].NamePath) {
-					documents[i].SalesforceIds[idKey] = resultId
-				}
-			}
+		for _, match := range results.Matches {
+			applyLookupMatch(entityType, match.Lookup.NamePath, match.ID, foundIds, documents, logger)
+			cache.put(entityPathKey(entityType, match.Lookup.NamePath), match.ID)
 		}
 	}
 
+	if cacheHits > 0 {
+		logger.Info("Resolved %d entities from the lookup cache", cacheHits)
+	}
+
 	if len(lookupErrors) > 0 {
 		errorMsg := "The following entities were not found:\n"
 		for _, err := range lookupErrors {
@@ -299,42 +660,86 @@ func bulkLookupEntities(accessToken string, documents []models.DocumentInfo, log
 		return fmt.Errorf(errorMsg)
 	}
 
+	if config.VerifyHierarchy {
+		if err := verifyHierarchy(accessToken, pathsByLevel, foundIds, logger); err != nil {
+			return err
+		}
+	}
+
 	logger.Info("Successfully completed bulk entity lookup")
 	return nil
 }
 
+func applyLookupMatch(entityType string, namePath map[string]string, id string, foundIds map[string]string, documents []models.DocumentInfo, logger *logging.Logger) {
+	level, _ := filestructure.HierarchyLevel(entityType)
+	idKey := level.IDKey
+	key := entityPathKey(entityType, namePath)
+	foundIds[key] = id
+	logger.Debug("Found ID for %s: %s", key, id)
+
+	for i := range documents {
+		if documents[i].EntityType == entityType && compareNamePaths(namePath, documents[i].NamePath) {
+			documents[i].SalesforceIds[idKey] = id
+		}
+	}
+}
+
+// addLevelLookup keys lookups by their full hierarchical path, since leaf
+// names such as "Z1" or "B1" are routinely reused across branches.
+func addLevelLookup(level map[string]models.DocumentInfo, doc models.DocumentInfo) {
+	level[generateFullPath(doc)] = doc
+}
+
+func entityPathKey(entityType string, namePath map[string]string) string {
+	return fmt.Sprintf("%s_%s", entityType, generateFullPath(models.DocumentInfo{EntityType: entityType, NamePath: namePath}))
+}
+
 func getParentKey(entityType string, namePath map[string]string) string {
-	switch entityType {
-	case "PHASE":
+	level, ok := filestructure.HierarchyLevel(entityType)
+	if !ok || level.Parent == "" {
 		return ""
-	case "ZONE":
-		return fmt.Sprintf("PHASE_%s", namePath["phase"])
-	case "BUILDING":
-		return fmt.Sprintf("ZONE_%s", namePath["zone"])
-	case "UNIT":
-		return fmt.Sprintf("BUILDING_%s", namePath["building"])
-	case "DESIGN_TYPE":
-		return fmt.Sprintf("PHASE_%s", namePath["phase"])
-	default:
+	}
+	parent, _ := filestructure.HierarchyLevel(level.Parent)
+	if parent.Parent == "" {
 		return ""
 	}
+	return entityPathKey(parent.Type, namePath)
 }
 
-func bulkUploadContentVersions(accessToken string, documentsDir string, documents []models.DocumentInfo, logger *logging.Logger, app *gui.App) error {
-	const batchSize = 25
-
+func bulkUploadContentVersions(ctx context.Context, accessToken string, documentsDir string, documents []models.DocumentInfo, audit *auditLog, created *createdRecords, reconnect *reconnector, checkpoint *checkpointer, logger *logging.Logger, reporter *progress.Reporter) error {
 	var allRequests []map[string]any
 	logger.Info("Preparing content version upload requests")
 
-	totalBatches := (len(documents) + batchSize - 1) / batchSize
-	currentBatch := 0
-	progressStart := 0.4
-	progressEnd := 0.8
-	progressPerBatch := (progressEnd - progressStart) / float64(totalBatches)
+	versionOptions, err := loadContentVersionOptions(accessToken, logger)
+	if err != nil {
+		logger.Error("Invalid ContentVersion field configuration: %v", err)
+		return err
+	}
+	versionOptions.externalIDs, err = assignExternalIDs(accessToken, documentsDir, documents, logger)
+	if err != nil {
+		logger.Error("Checking for files already uploaded failed: %v", err)
+		return err
+	}
 
+	var binaryUploads []int
+	refs := make(referenceMap)
+	resumed := 0
 	for i, doc := range documents {
+		// Files uploaded before a resumed run stopped, or found by their
+		// external ID, are not sent again.
+		if doc.SalesforceIds["contentVersionId"] != "" {
+			resumed++
+			continue
+		}
+
 		fullPath := filepath.Clean(filepath.Join(documentsDir, doc.RelativePath))
 
+		if info, err := os.Stat(fullPath); err == nil && useBinaryUpload(info.Size(), config.BinaryUploadThreshold) {
+			logger.Debug("Uploading %s (%s) separately as binary", fullPath, formatBytes(info.Size()))
+			binaryUploads = append(binaryUploads, i)
+			continue
+		}
+
 		logger.Debug("Reading file from path: %s", fullPath)
 		fileBytes, err := os.ReadFile(fullPath)
 		if err != nil {
@@ -343,110 +748,164 @@ func bulkUploadContentVersions(accessToken string, documentsDir string, document
 			return fmt.Errorf(errMsg)
 		}
 
+		body, err := contentVersionBody(doc, versionOptions)
+		if err != nil {
+			logger.Error("%v", err)
+			return err
+		}
+		body["VersionData"] = base64.StdEncoding.EncodeToString(fileBytes)
+
+		ref, err := refs.add(fmt.Sprintf("ref%d", i), &documents[i])
+		if err != nil {
+			logger.Error("%v", err)
+			return err
+		}
 		request := map[string]any{
 			"method":      "POST",
-			"url":         "/services/data/v57.0/sobjects/ContentVersion",
-			"referenceId": fmt.Sprintf("ref%d", i),
-			"body": map[string]any{
-				"Title":                  filepath.Base(doc.FilePath),
-				"PathOnClient":           filepath.Base(doc.FilePath),
-				"VersionData":            base64.StdEncoding.EncodeToString(fileBytes),
-				"FirstPublishLocationId": doc.SalesforceIds[strings.ToLower(doc.EntityType)],
-			},
+			"url":         config.DataPath("/sobjects/ContentVersion"),
+			"referenceId": ref,
+			"body":        body,
 		}
 		allRequests = append(allRequests, request)
 		logger.Debug("Prepared request for file: %s", fullPath)
-	}
 
-	for i := 0; i < len(allRequests); i += batchSize {
-		currentBatch++
-		end := min(i+batchSize, len(allRequests))
-		logger.Info("Processing batch %d of %d (%d files)", currentBatch, totalBatches, end-i)
+		if config.PreviewMaxDimension > 0 && doc.ContentType == config.ContentTypeImage {
+			previewRef := fmt.Sprintf("%s%d", previewRefPrefix, i)
+			if previewRequest := buildPreviewRequest(previewRef, doc, fileBytes, body, logger); previewRequest != nil {
+				if _, err := refs.add(previewRef, &documents[i]); err != nil {
+					logger.Error("%v", err)
+					return err
+				}
+				allRequests = append(allRequests, previewRequest)
+			}
+		}
+	}
 
-		progress := progressStart + (float64(currentBatch) * progressPerBatch)
-		app.SetProgress(progress)
+	if resumed > 0 {
+		logger.Info("%d file(s) were already uploaded and are not sent again", resumed)
+	}
 
-		logger.Info("Processing batch %d of %d (%d files)", currentBatch, totalBatches, end-i)
+	client := salesforce.NewClient(accessToken, httpClient)
+	batches := packSubrequests(allRequests, config.CompositeMaxBytes)
+	totalBatches := len(batches)
+	totalSteps := totalBatches + len(binaryUploads)
+	progressStart := 0.4
+	progressEnd := 0.8
+	progressPerBatch := (progressEnd - progressStart) / float64(totalSteps)
+
+	// Each batch records its results through the checkpoint as soon as it
+	// finishes, so no DocumentInfo is written while another batch reads it.
+	// Successful batches are recorded even when others fail, so a rollback
+	// can find everything that was created.
+	var completed atomic.Int32
+	_, batchErr := parallelMap(ctx, batches, config.ParallelRequests, func(ctx context.Context, batch []map[string]any) ([]CompositeResult, error) {
+		results, err := reconnect.sendComposite(ctx, client, batch, true, committedContentVersions(accessToken, logger))
+		checkpoint.apply(func() {
+			applyContentVersionResults(refs, results, audit, created, logger)
+			if err == nil {
+				if err = compositeError(results); err != nil {
+					err = fmt.Errorf("failed to create ContentVersion %w",
+						withAccessContext(err, "ContentVersion", refs))
+				}
+			}
+		})
+
+		done := int(completed.Add(1))
+		reporter.Step(done, totalSteps, progressStart+float64(done)*progressPerBatch)
+		logger.Info("Finished batch %d of %d (%d files)", done, totalBatches, len(batch))
+		return results, err
+	})
+	currentBatch := len(batches)
+
+	if batchErr != nil {
+		logger.Error("Failed to upload content: %v", batchErr)
+		return batchErr
+	}
 
-		batchRequests := allRequests[i:end]
-		compositeRequest := map[string]any{
-			"allOrNone":        true,
-			"compositeRequest": batchRequests,
-		}
+	staging := newStagingArea(ctx)
+	defer staging.Close()
+	for _, i := range binaryUploads {
+		currentBatch++
+		reporter.Step(currentBatch, totalSteps, progressStart+float64(currentBatch)*progressPerBatch)
 
-		jsonBody, err := json.Marshal(compositeRequest)
-		if err != nil {
-			logger.Error("Failed to marshal composite request: %v", err)
-			return fmt.Errorf("error marshaling request: %v", err)
-		}
+		fullPath := filepath.Clean(filepath.Join(documentsDir, documents[i].RelativePath))
+		logger.Info("Uploading large file %s", documents[i].RelativePath)
 
-		req, err := http.NewRequest("POST",
-			config.SFInstanceURL+"/services/data/v57.0/composite",
-			bytes.NewBuffer(jsonBody))
+		body, err := contentVersionBody(documents[i], versionOptions)
 		if err != nil {
-			logger.Error("Failed to create composite request: %v", err)
-			return fmt.Errorf("error creating composite request: %v", err)
+			logger.Error("%v", err)
+			return err
 		}
-
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-		req.Header.Set("Content-Type", "application/json")
-
-		logger.Debug("Sending batch request to Salesforce")
-		resp, err := http.DefaultClient.Do(req)
+		versionId, err := uploadContentVersionBinary(ctx, accessToken, fullPath, body, staging)
 		if err != nil {
-			logger.Error("Failed to execute composite request: %v", err)
-			return fmt.Errorf("composite request failed: %v", err)
-		}
-		defer resp.Body.Close()
-
-		var compositeResponse struct {
-			CompositeResponse []struct {
-				Body           any    `json:"body"`
-				HttpStatusCode int    `json:"httpStatusCode"`
-				ReferenceId    string `json:"referenceId"`
-			} `json:"compositeResponse"`
+			logger.Error("Failed to upload %s: %v", documents[i].RelativePath, err)
+			return err
 		}
+		checkpoint.apply(func() {
+			documents[i].SalesforceIds["contentVersionId"] = versionId
+			created.add(versionId)
+			audit.record("create", "ContentVersion", versionId, &documents[i])
+		})
+		logger.Debug("Created ContentVersion with ID: %s for file: %s", versionId, documents[i].FilePath)
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&compositeResponse); err != nil {
-			logger.Error("Failed to decode composite response: %v", err)
-			return fmt.Errorf("error decoding composite response: %v", err)
-		}
+	logger.Info("Successfully completed content version uploads")
 
-		for _, result := range compositeResponse.CompositeResponse {
-			if result.HttpStatusCode != 201 {
-				errMsg := fmt.Sprintf("failed to create ContentVersion for reference %s: status %d",
-					result.ReferenceId, result.HttpStatusCode)
-				logger.Error(errMsg)
-				return fmt.Errorf(errMsg)
-			}
+	if err := fetchContentDocumentIds(accessToken, documents, logger); err != nil {
+		logger.Error("Failed to fetch ContentDocument IDs: %v", err)
+		return fmt.Errorf("failed to fetch ContentDocument IDs: %v", err)
+	}
+	checkpoint.save(errRunInterrupted)
 
-			if successBody, ok := result.Body.(map[string]any); ok {
-				refIndex, _ := strconv.Atoi(strings.TrimPrefix(result.ReferenceId, "ref"))
-				if refIndex < len(documents) {
-					versionId := successBody["id"].(string)
-					documents[refIndex].SalesforceIds["contentVersionId"] = versionId
-					logger.Debug("Created ContentVersion with ID: %s for file: %s",
-						versionId, documents[refIndex].FilePath)
-				}
-			}
+	if config.ContentLibraryID != "" || config.AttachmentMode == config.AttachmentModeLink {
+		if err := createContentDocumentLinks(ctx, accessToken, documents, audit, checkpoint, logger); err != nil {
+			logger.Error("Failed to link content to entities: %v", err)
+			return fmt.Errorf("failed to link content to entities: %v", err)
 		}
 	}
 
-	logger.Info("Successfully completed content version uploads")
+	if config.AttachmentMode == config.AttachmentModeLink {
+		return nil
+	}
 
-	if err := createContentDistributions(accessToken, documents, logger); err != nil {
+	reporter.Phase(progress.PhaseDistribute, "Creating public links...")
+	err = withPhaseTimeout(ctx, progress.PhaseDistribute, config.DistributeTimeout, func(ctx context.Context) error {
+		return createContentDistributions(ctx, accessToken, documents, audit, logger)
+	})
+	if err != nil {
 		logger.Error("Failed to create content distributions: %v", err)
-		return fmt.Errorf("failed to create content distributions: %v", err)
+		return fmt.Errorf("failed to create content distributions: %w", err)
 	}
+	checkpoint.save(errRunInterrupted)
 
 	return nil
 }
 
-func bulkCreateAttachmentUploaders(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
-	const batchSize = 25
+func bulkCreateAttachmentUploaders(ctx context.Context, accessToken, runID string, documents []models.DocumentInfo, audit *auditLog, created *createdRecords, reconnect *reconnector, checkpoint *checkpointer, logger *logging.Logger) error {
 	logger.Info("Starting attachment uploader creation")
 
+	fieldNames := extraFieldNames(documents)
+	if config.RecordOwnerID != "" {
+		if err := validateOwnerID(config.RecordOwnerID); err != nil {
+			logger.Error("Invalid record owner: %v", err)
+			return err
+		}
+		fieldNames = append(fieldNames, "OwnerId")
+	}
+	if config.RunIDField != "" {
+		fieldNames = append(fieldNames, config.RunIDField)
+	}
+	if err := validateCreateableFields(accessToken, attachmentSObject, fieldNames, logger); err != nil {
+		logger.Error("Invalid metadata fields: %v", err)
+		return err
+	}
+	if err := validateContentTypeValues(accessToken, documents, logger); err != nil {
+		logger.Error("Invalid content type mapping: %v", err)
+		return err
+	}
+
 	var allRequests []map[string]any
+	refs := make(referenceMap)
 	for i, doc := range documents {
 		logger.Debug("Processing document: %s", doc.FilePath)
 		logger.Debug("ContentDocumentId: %s", doc.ContentDocumentId)
@@ -459,63 +918,35 @@ func bulkCreateAttachmentUploaders(accessToken string, documents []models.Docume
 			continue
 		}
 
-		var entityId string
-		switch doc.EntityType {
-		case "UNIT":
-			entityId = doc.SalesforceIds["unit"]
-		case "PHASE":
-			entityId = doc.SalesforceIds["phase"]
-		case "ZONE":
-			entityId = doc.SalesforceIds["zone"]
-		case "BUILDING":
-			entityId = doc.SalesforceIds["building"]
-		case "DESIGN_TYPE":
-			entityId = doc.SalesforceIds["design_type"]
-		}
-
+		entityId := attachmentEntityID(doc)
 		if entityId == "" {
 			errMsg := fmt.Sprintf("Missing %s ID for document: %s", doc.EntityType, doc.FilePath)
 			logger.Error(errMsg)
 			continue
 		}
 
-		displayValue := generateDisplayValue(doc)
-
 		distributionUrl := doc.SalesforceIds["distributionUrl"]
 		if distributionUrl == "" {
 			logger.Warning("No distribution URL found for document: %s", doc.FilePath)
-			distributionUrl = fmt.Sprintf("/lightning/r/ContentDocument/%s/view", doc.ContentDocumentId)
-		}
-
-		record := map[string]any{
-			"Name":                    entityId,
-			"Attachment_Type__c":      doc.DocumentType,
-			"Content_Type__c":         getContentType(doc.FilePath),
-			"ContentDocumentId__c":    doc.ContentDocumentId,
-			"Attachment_Url__c":       distributionUrl,
-			"Display_Value__c":        displayValue,
-			"Display_Value_Arabic__c": displayValue,
+			distributionUrl = "/lightning/r/ContentDocument/" + url.PathEscape(doc.ContentDocumentId) + "/view"
 		}
 
-		switch doc.EntityType {
-		case "UNIT":
-			record["Unit__c"] = entityId
-		case "PHASE":
-			record["Phase__c"] = entityId
-		case "ZONE":
-			record["Zone__c"] = entityId
-		case "BUILDING":
-			record["Building__c"] = entityId
-		case "DESIGN_TYPE":
-			record["Design_Type__c"] = entityId
+		record := buildAttachmentRecord(doc, entityId, distributionUrl)
+		if config.RunIDField != "" {
+			record[config.RunIDField] = runID
 		}
 
 		logger.Debug("Creating attachment uploader record for: %s", doc.FilePath)
 
+		ref, err := refs.add(fmt.Sprintf("attRef%d", i), &documents[i])
+		if err != nil {
+			logger.Error("%v", err)
+			return err
+		}
 		request := map[string]any{
 			"method":      "POST",
-			"url":         "/services/data/v57.0/sobjects/Attachments_Uploader__c",
-			"referenceId": fmt.Sprintf("attRef%d", i),
+			"url":         config.DataPath("/sobjects/" + attachmentSObject),
+			"referenceId": ref,
 			"body":        record,
 		}
 		allRequests = append(allRequests, request)
@@ -527,81 +958,123 @@ func bulkCreateAttachmentUploaders(accessToken string, documents []models.Docume
 		return fmt.Errorf(errMsg)
 	}
 
-	totalBatches := (len(allRequests) + batchSize - 1) / batchSize
-	currentBatch := 0
-
-	for i := 0; i < len(allRequests); i += batchSize {
-		currentBatch++
-		end := min(i+batchSize, len(allRequests))
-		logger.Info("Processing batch %d of %d (%d records)", currentBatch, totalBatches, end-i)
+	client := salesforce.NewClient(accessToken, httpClient)
+	var batches [][]map[string]any
+	for i := 0; i < len(allRequests); i += compositeBatchSize {
+		batches = append(batches, allRequests[i:min(i+compositeBatchSize, len(allRequests))])
+	}
 
-		batchRequests := allRequests[i:end]
-		compositeRequest := map[string]any{
-			"allOrNone":        true,
-			"compositeRequest": batchRequests,
+	// Each batch is all or none on its own, as it was when batches ran one
+	// after another: a failed batch leaves the others' records in place, and
+	// they are recorded for the report and any rollback.
+	var completed atomic.Int32
+	_, err := parallelMap(ctx, batches, attachmentConcurrency(), func(ctx context.Context, batch []map[string]any) ([]CompositeResult, error) {
+		// Attachment records carry no external ID to look them up by.
+		results, err := reconnect.sendComposite(ctx, client, batch, true, nil)
+		done := int(completed.Add(1))
+		if err != nil {
+			logger.Error("Failed to create attachment uploader batch: %v", err)
+			return nil, err
+		}
+		if err := compositeError(results); err != nil {
+			err = withAccessContext(err, attachmentSObject, refs)
+			logger.Error("Failed to create Attachments_Uploader__c %v", err)
+			return results, fmt.Errorf("failed to create Attachments_Uploader__c %w", err)
 		}
 
-		jsonBody, _ := json.Marshal(compositeRequest)
-		logger.Debug("Sending attachment uploader request")
+		checkpoint.apply(func() {
+			for _, result := range results {
+				id := result.ID()
+				logger.Debug("Created Attachments_Uploader__c with ID: %s", id)
+				if doc := refs[result.ReferenceID]; id != "" && doc != nil {
+					doc.SalesforceIds["attachmentUploaderId"] = id
+					created.add(id)
+					audit.record("create", "Attachments_Uploader__c", id, doc)
+				}
+			}
+		})
+		logger.Info("Finished batch %d of %d (%d records)", done, len(batches), len(batch))
+		return results, nil
+	})
+	if err != nil {
+		return err
+	}
 
-		req, err := http.NewRequest("POST",
-			config.SFInstanceURL+"/services/data/v57.0/composite",
-			bytes.NewBuffer(jsonBody))
-		if err != nil {
-			logger.Error("Failed to create composite request: %v", err)
-			return fmt.Errorf("error creating composite request: %v", err)
-		}
+	logger.Info("Successfully created all attachment uploaders")
+	return nil
+}
 
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-		req.Header.Set("Content-Type", "application/json")
+// attachmentConcurrency is how many attachment record batches are sent at
+// once. ATTACHMENT_ORDERED sends them one at a time, so records are created
+// in document order.
+func attachmentConcurrency() int {
+	if config.AttachmentOrdered {
+		return 1
+	}
+	if config.AttachmentParallelRequests > 0 {
+		return config.AttachmentParallelRequests
+	}
+	return config.ParallelRequests
+}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			logger.Error("Failed to execute composite request: %v", err)
-			return fmt.Errorf("composite request failed: %v", err)
-		}
-		defer resp.Body.Close()
+func attachmentEntityID(doc models.DocumentInfo) string {
+	if doc.EntityType == filestructure.RecordEntityType {
+		return doc.SalesforceIds[recordIDKey]
+	}
+	level, ok := filestructure.HierarchyLevel(doc.EntityType)
+	if !ok {
+		return ""
+	}
+	return doc.SalesforceIds[level.IDKey]
+}
 
-		body, _ := io.ReadAll(resp.Body)
-		logger.Debug("Received attachment uploader response: %s", string(body))
+func attachmentLookupField(entityType string) string {
+	level, _ := filestructure.HierarchyLevel(entityType)
+	return level.LookupField
+}
 
-		var compositeResponse struct {
-			CompositeResponse []struct {
-				Body           any    `json:"body"`
-				HttpStatusCode int    `json:"httpStatusCode"`
-				ReferenceId    string `json:"referenceId"`
-			} `json:"compositeResponse"`
-		}
+func buildAttachmentRecord(doc models.DocumentInfo, entityId, distributionUrl string) map[string]any {
+	displayValue := generateDisplayValue(doc)
+
+	record := map[string]any{
+		"Name":                    attachmentName(doc, entityId, displayValue, config.AttachmentNameSource),
+		"Attachment_Type__c":      doc.DocumentType,
+		"Content_Type__c":         contentTypeValue(doc.ContentType),
+		"ContentDocumentId__c":    doc.ContentDocumentId,
+		"Attachment_Url__c":       distributionUrl,
+		"Display_Value__c":        displayValue,
+		"Display_Value_Arabic__c": displayValue,
+	}
 
-		if err := json.Unmarshal(body, &compositeResponse); err != nil {
-			logger.Error("Failed to decode composite response: %v", err)
-			return fmt.Errorf("error decoding composite response: %v", err)
-		}
+	for name, value := range doc.ExtraFields {
+		record[name] = value
+	}
 
-		for _, result := range compositeResponse.CompositeResponse {
-			if result.HttpStatusCode != 201 {
-				if errArray, ok := result.Body.([]any); ok && len(errArray) > 0 {
-					if errMap, ok := errArray[0].(map[string]any); ok {
-						errMsg := fmt.Sprintf("failed to create Attachments_Uploader__c: %v - %v",
-							errMap["errorCode"], errMap["message"])
-						logger.Error(errMsg)
-						return fmt.Errorf(errMsg)
-					}
-				}
-				errMsg := fmt.Sprintf("failed to create Attachments_Uploader__c for reference %s: status %d",
-					result.ReferenceId, result.HttpStatusCode)
-				logger.Error(errMsg)
-				return fmt.Errorf(errMsg)
-			}
+	if field := attachmentLookupField(doc.EntityType); field != "" {
+		record[field] = entityId
+	}
+	if config.RecordOwnerID != "" {
+		record["OwnerId"] = config.RecordOwnerID
+	}
 
-			if successBody, ok := result.Body.(map[string]any); ok {
-				logger.Debug("Created Attachments_Uploader__c with ID: %v", successBody["id"])
-			}
+	return record
+}
+
+// attachmentName picks the record Name shown in list views, as chosen by
+// ATTACHMENT_NAME_SOURCE. The entity is always linked through its own lookup
+// field, so the Name is only a label. It defaults to the entity ID.
+func attachmentName(doc models.DocumentInfo, entityId, displayValue, source string) string {
+	switch {
+	case source == config.AttachmentNameDisplayValue && strings.TrimSpace(displayValue) != "":
+		if name := []rune(displayValue); len(name) > maxRecordNameLength {
+			return string(name[:maxRecordNameLength])
 		}
+		return displayValue
+	case source == config.AttachmentNameFilename:
+		return truncateTitle(contentTitle(doc), maxRecordNameLength)
+	default:
+		return entityId
 	}
-
-	logger.Info("Successfully created all attachment uploaders")
-	return nil
 }
 
 func compareNamePaths(path1, path2 map[string]string) bool {
@@ -616,6 +1089,24 @@ func compareNamePaths(path1, path2 map[string]string) bool {
 	return true
 }
 
+func applyContentVersionResults(refs referenceMap, results []CompositeResult, audit *auditLog, created *createdRecords, logger *logging.Logger) {
+	for _, result := range results {
+		doc := refs[result.ReferenceID]
+		if !result.Succeeded() || doc == nil {
+			continue
+		}
+		idKey := "contentVersionId"
+		if strings.HasPrefix(result.ReferenceID, previewRefPrefix) {
+			idKey = "previewContentVersionId"
+		}
+		versionId := result.ID()
+		doc.SalesforceIds[idKey] = versionId
+		created.add(versionId)
+		audit.record("create", "ContentVersion", versionId, doc)
+		logger.Debug("Created ContentVersion with ID: %s for file: %s", versionId, doc.FilePath)
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -623,18 +1114,20 @@ func min(a, b int) int {
 	return b
 }
 
-func getContentType(filename string) string {
-	mime, err := mimetype.DetectFile(filename)
-	if err != nil {
+// contentTypeFor maps a detected MIME type onto a content category.
+// Undetectable content is treated as an image unless annotateContent's
+// policy says otherwise.
+func contentTypeFor(detected *mimetype.MIME) string {
+	if detected == nil {
 		return config.ContentTypeImage
 	}
 
-	mainType := strings.Split(mime.String(), "/")[0]
+	mainType := strings.Split(detected.String(), "/")[0]
 
 	switch {
 	case mainType == "image":
 		return config.ContentTypeImage
-	case mime.String() == "application/pdf":
+	case detected.String() == "application/pdf":
 		return config.ContentTypePDF
 	case mainType == "video":
 		return config.ContentTypeVideo
@@ -643,99 +1136,97 @@ func getContentType(filename string) string {
 	}
 }
 
-func generateDisplayValue(doc models.DocumentInfo) string {
-	switch doc.EntityType {
-	case "UNIT":
-		return fmt.Sprintf("%s for Unit %s of Building %s in Phase %s of %s",
-			doc.DocumentType,
-			doc.NamePath["unit"],
-			doc.NamePath["building"],
-			doc.NamePath["phase"],
-			doc.NamePath["project"])
-
-	case "BUILDING":
-		return fmt.Sprintf("%s for Building %s in Zone %s of Phase %s - %s",
-			doc.DocumentType,
-			doc.NamePath["building"],
-			doc.NamePath["zone"],
-			doc.NamePath["phase"],
-			doc.NamePath["project"])
-
-	case "ZONE":
-		return fmt.Sprintf("%s for Zone %s in Phase %s of %s",
-			doc.DocumentType,
-			doc.NamePath["zone"],
-			doc.NamePath["phase"],
-			doc.NamePath["project"])
-
-	case "PHASE":
-		return fmt.Sprintf("%s for Phase %s of %s",
-			doc.DocumentType,
-			doc.NamePath["phase"],
-			doc.NamePath["project"])
-
-	case "DESIGN_TYPE":
-		return fmt.Sprintf("%s for Design Type %s in Phase %s of %s",
-			doc.DocumentType,
-			doc.NamePath["designType"],
-			doc.NamePath["phase"],
-			doc.NamePath["project"])
+// dropRejected leaves out the files annotateContent rejected. They stay in
+// the collected list, so the run report still records them.
+func dropRejected(documents []models.DocumentInfo, logger *logging.Logger) []models.DocumentInfo {
+	var kept []models.DocumentInfo
+	for _, doc := range documents {
+		if doc.Rejected != "" {
+			logger.Error("Not uploading %s: %s", doc.RelativePath, doc.Rejected)
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	return kept
+}
 
-	default:
-		return strings.TrimSuffix(filepath.Base(doc.FilePath), filepath.Ext(doc.FilePath))
+// contentTypeValue returns the org's Content_Type__c picklist value for a
+// content category, as configured in CONTENT_TYPE_VALUES. Categories without
+// a mapping are sent as they are.
+func contentTypeValue(category string) string {
+	if value, ok := config.ContentTypeValues[category]; ok && value != "" {
+		return value
+	}
+	return category
+}
+
+// contentTypeValues lists the distinct Content_Type__c values a run sends,
+// in order of first use.
+func contentTypeValues(documents []models.DocumentInfo) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, doc := range documents {
+		if value := contentTypeValue(doc.ContentType); !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// validateContentTypeValues checks the Content_Type__c values a run sends
+// against the org's picklist. Without CONTENT_TYPE_VALUES the categories are
+// sent as they always were, so there is nothing to check.
+func validateContentTypeValues(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
+	if len(config.ContentTypeValues) == 0 {
+		return nil
+	}
+	return validatePicklistValues(accessToken, attachmentSObject, "Content_Type__c", contentTypeValues(documents), logger)
+}
+
+func generateDisplayValue(doc models.DocumentInfo) string {
+	if doc.EntityType == filestructure.RecordEntityType {
+		return fmt.Sprintf("%s for %s", doc.DocumentType, generateFullPath(doc))
 	}
+	if path := filestructure.DisplayPath(doc.EntityType, doc.NamePath); path != "" {
+		return fmt.Sprintf("%s for %s", doc.DocumentType, path)
+	}
+	return strings.TrimSuffix(filepath.Base(doc.FilePath), filepath.Ext(doc.FilePath))
 }
 
 func generateFullPath(doc models.DocumentInfo) string {
-	switch doc.EntityType {
-	case "UNIT":
-		return fmt.Sprintf("%s/Phase %s/Zone %s/Building %s/Unit %s",
-			doc.NamePath["project"],
-			doc.NamePath["phase"],
-			doc.NamePath["zone"],
-			doc.NamePath["building"],
-			doc.NamePath["unit"])
-	case "BUILDING":
-		return fmt.Sprintf("%s/Phase %s/Zone %s/Building %s",
-			doc.NamePath["project"],
-			doc.NamePath["phase"],
-			doc.NamePath["zone"],
-			doc.NamePath["building"])
-	case "ZONE":
-		return fmt.Sprintf("%s/Phase %s/Zone %s",
-			doc.NamePath["project"],
-			doc.NamePath["phase"],
-			doc.NamePath["zone"])
-	case "PHASE":
-		return fmt.Sprintf("%s/Phase %s",
-			doc.NamePath["project"],
-			doc.NamePath["phase"])
-	case "DESIGN_TYPE":
-		return fmt.Sprintf("%s/Phase %s/Design Type %s",
-			doc.NamePath["project"],
-			doc.NamePath["phase"],
-			doc.NamePath["designType"])
-	default:
-		return doc.FilePath
+	if path := filestructure.EntityPath(doc.EntityType, doc.NamePath); path != "" {
+		return path
 	}
+	return doc.FilePath
 }
 
-func createContentDistributions(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
+func createContentDistributions(ctx context.Context, accessToken string, documents []models.DocumentInfo, audit *auditLog, logger *logging.Logger) error {
 	logger.Info("Creating content distributions")
 
 	var requests []map[string]any
+	refs := make(referenceMap)
+	resumed := 0
 	for i, doc := range documents {
 		if doc.ContentDocumentId == "" {
 			continue
 		}
+		if doc.SalesforceIds["distributionUrl"] != "" {
+			resumed++
+			continue
+		}
 
+		ref, err := refs.add(fmt.Sprintf("distRef%d", i), &documents[i])
+		if err != nil {
+			return err
+		}
 		request := map[string]any{
 			"method":      "POST",
-			"url":         "/services/data/v57.0/sobjects/ContentDistribution",
-			"referenceId": fmt.Sprintf("distRef%d", i),
+			"url":         config.DataPath("/sobjects/ContentDistribution"),
+			"referenceId": ref,
 			"body": map[string]any{
 				"ContentVersionId":                 doc.SalesforceIds["contentVersionId"],
-				"Name":                             filepath.Base(doc.FilePath),
+				"Name":                             truncateTitle(contentTitle(doc), maxDistributionNameLength),
 				"PreferencesAllowViewInBrowser":    true,
 				"PreferencesLinkLatestVersion":     true,
 				"PreferencesNotifyOnVisit":         false,
@@ -747,98 +1238,133 @@ func createContentDistributions(accessToken string, documents []models.DocumentI
 	}
 
 	if len(requests) == 0 {
+		if resumed > 0 {
+			return nil
+		}
 		return fmt.Errorf("no documents to create distributions for")
 	}
 
-	compositeRequest := map[string]any{
-		"allOrNone":        true,
-		"compositeRequest": requests,
+	client := salesforce.NewClient(accessToken, httpClient)
+	var results []CompositeResult
+	for i := 0; i < len(requests); i += compositeBatchSize {
+		end := min(i+compositeBatchSize, len(requests))
+		batchResults, err := sendComposite(ctx, client, requests[i:end], true)
+		if err != nil {
+			return fmt.Errorf("distribution request failed: %v", err)
+		}
+		if err := compositeError(batchResults); err != nil {
+			logger.Warning("Content distributions not created for batch: %v", err)
+		}
+		results = append(results, batchResults...)
 	}
 
-	jsonBody, _ := json.Marshal(compositeRequest)
-	logger.Debug("Content distribution request: %s", string(jsonBody))
-
-	req, err := http.NewRequest("POST",
-		config.SFInstanceURL+"/services/data/v57.0/composite",
-		bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("error creating distribution request: %v", err)
+	var created []CompositeResult
+	for _, response := range results {
+		if !response.Succeeded() {
+			continue
+		}
+		doc := refs[response.ReferenceID]
+		if doc == nil {
+			continue
+		}
+		audit.record("create", "ContentDistribution", response.ID(), doc)
+		created = append(created, response)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	// A distribution without details falls back to the document's record
+	// page, so one failure does not stop fetching the others.
+	downloadUrls, err := parallelMap(ctx, created, config.ParallelRequests, func(ctx context.Context, response CompositeResult) (string, error) {
+		downloadUrl, err := awaitDistributionDownloadUrl(ctx, accessToken, response.ID(), logger)
+		if err != nil {
+			logger.Error("Failed to get details of distribution %s: %v", response.ID(), err)
+		}
+		return downloadUrl, nil
+	})
 	if err != nil {
-		return fmt.Errorf("distribution request failed: %v", err)
+		logger.Error("Failed to get distribution details: %v", err)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	logger.Debug("Content distribution response: %s", string(respBody))
 
-	var result struct {
-		CompositeResponse []struct {
-			Body struct {
-				Id                    string `json:"id"`
-				ContentDistributionId string `json:"contentDistributionId"`
-			} `json:"body"`
-			HttpStatusCode int    `json:"httpStatusCode"`
-			ReferenceId    string `json:"referenceId"`
-		} `json:"compositeResponse"`
+	for i, response := range created {
+		if downloadUrls[i] == "" {
+			continue
+		}
+		doc := refs[response.ReferenceID]
+		doc.SalesforceIds["distributionUrl"] = downloadUrls[i]
+		logger.Debug("Set distribution URL for %s: %s", doc.FilePath, downloadUrls[i])
 	}
 
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return fmt.Errorf("error decoding distribution response: %v", err)
+	for _, doc := range documents {
+		if doc.SalesforceIds["distributionUrl"] == "" {
+			logger.Warning("Document %s does not have a distribution URL", doc.FilePath)
+		}
 	}
 
-	for _, response := range result.CompositeResponse {
-		if response.HttpStatusCode != 201 {
-			continue
-		}
+	return nil
+}
 
-		distributionUrl := fmt.Sprintf("%s/services/data/v57.0/sobjects/ContentDistribution/%s",
-			config.SFInstanceURL, response.Body.Id)
+// Salesforce sometimes fills in a new distribution's URLs a moment after
+// creating it, so the details are read again for a while until they appear.
+// These are variables so tests need not wait for them.
+var (
+	distributionURLTimeout    = 15 * time.Second
+	distributionURLRetryDelay = 500 * time.Millisecond
+)
 
-		req, err := http.NewRequest("GET", distributionUrl, nil)
-		if err != nil {
-			logger.Error("Failed to create distribution details request: %v", err)
-			continue
+// awaitDistributionDownloadUrl reads a distribution's download URL, retrying
+// with a growing delay while it is still empty. It returns an empty URL once
+// distributionURLTimeout has passed.
+func awaitDistributionDownloadUrl(ctx context.Context, accessToken, distributionId string, logger *logging.Logger) (string, error) {
+	deadline := time.Now().Add(distributionURLTimeout)
+	delay := distributionURLRetryDelay
+	for attempt := 1; ; attempt++ {
+		downloadUrl, err := fetchDistributionDownloadUrl(ctx, accessToken, distributionId)
+		if err != nil || downloadUrl != "" {
+			return downloadUrl, err
 		}
-
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-
-		detailsResp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			logger.Error("Failed to get distribution details: %v", err)
-			continue
+		if time.Now().Add(delay).After(deadline) {
+			logger.Warning("Distribution %s still has no download URL after %s", distributionId, distributionURLTimeout)
+			return "", nil
 		}
-		defer detailsResp.Body.Close()
 
-		var distributionDetails struct {
-			DistributionPublicUrl string `json:"DistributionPublicUrl"`
-			ContentDownloadUrl    string `json:"ContentDownloadUrl"`
+		logger.Info("Distribution %s has no download URL yet, checking again in %s (attempt %d)", distributionId, delay, attempt)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
 		}
-
-		if err := json.NewDecoder(detailsResp.Body).Decode(&distributionDetails); err != nil {
-			logger.Error("Failed to decode distribution details: %v", err)
-			continue
+		if delay < 4*time.Second {
+			delay *= 2
 		}
+	}
+}
 
-		refIndex, _ := strconv.Atoi(strings.TrimPrefix(response.ReferenceId, "distRef"))
-		if refIndex < len(documents) {
-			documents[refIndex].SalesforceIds["distributionUrl"] = distributionDetails.ContentDownloadUrl
-			logger.Debug("Set distribution URL for %s: %s",
-				documents[refIndex].FilePath,
-				distributionDetails.ContentDownloadUrl)
-		}
+func fetchDistributionDownloadUrl(ctx context.Context, accessToken, distributionId string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		config.DataURL("/sobjects/ContentDistribution/"+url.PathEscape(distributionId)), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating distribution details request: %v", err)
 	}
 
-	for _, doc := range documents {
-		if doc.SalesforceIds["distributionUrl"] == "" {
-			logger.Warning("Document %s does not have a distribution URL", doc.FilePath)
-		}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("distribution details request for %s failed: %v", distributionId, err)
 	}
+	defer resp.Body.Close()
 
-	return nil
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("distribution details request for %s failed: status %d", distributionId, resp.StatusCode)
+	}
+
+	var distributionDetails struct {
+		DistributionPublicUrl string `json:"DistributionPublicUrl"`
+		ContentDownloadUrl    string `json:"ContentDownloadUrl"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&distributionDetails); err != nil {
+		return "", fmt.Errorf("error decoding distribution details for %s: %v", distributionId, err)
+	}
+
+	return distributionDetails.ContentDownloadUrl, nil
 }
@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestDecodeBulkLookupResponse(t *testing.T) {
+	const key = `{"entityType":"BUILDING","name":"B1"}`
+	tests := []struct {
+		name    string
+		body    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "map",
+			body: `{"{\"entityType\":\"BUILDING\",\"name\":\"B1\"}":"a0B000000000001"}`,
+			want: map[string]string{key: "a0B000000000001"},
+		},
+		{
+			name: "list with string keys",
+			body: `[{"key":"{\"entityType\":\"BUILDING\",\"name\":\"B1\"}","value":"ERROR: not found"}]`,
+			want: map[string]string{key: "ERROR: not found"},
+		},
+		{
+			name: "list with object keys",
+			body: `[{"key":` + key + `,"value":"a0B000000000001"}]`,
+			want: map[string]string{key: "a0B000000000001"},
+		},
+		{name: "empty list", body: `[]`, want: map[string]string{}},
+		{name: "neither shape", body: `"unexpected"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBulkLookupResponse([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBulkLookupResponse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeBulkLookupResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteBulkLookupMixedResults(t *testing.T) {
+	lookups := []models.EntityLookup{
+		{EntityType: "PHASE", NamePath: map[string]string{"project": "Tower", "phase": "P1"}},
+		{EntityType: "ZONE", NamePath: map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1"}},
+		{EntityType: "BUILDING", NamePath: map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B9"}},
+	}
+	values := []string{"a0P000000000001", "a0Z000000000001", "ERROR:  no Building named B9"}
+
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		response := make(map[string]string)
+		for i, lookup := range lookups {
+			key, _ := json.Marshal(lookup)
+			response[string(key)] = values[i]
+		}
+		response["not a lookup"] = "a0X000000000001"
+		json.NewEncoder(w).Encode(response)
+	})
+	previous := config.BulkLookupURL
+	config.BulkLookupURL = config.SFInstanceURL + "/services/apexrest/admin/bulk-lookup"
+	defer func() { config.BulkLookupURL = previous }()
+
+	result, err := executeBulkLookup(context.Background(), "token", models.BulkLookupRequest{Lookups: lookups}, logging.GetLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(result.Matches, func(i, j int) bool { return result.Matches[i].ID < result.Matches[j].ID })
+	wantMatches := []LookupMatch{{Lookup: lookups[0], ID: values[0]}, {Lookup: lookups[1], ID: values[1]}}
+	if !reflect.DeepEqual(result.Matches, wantMatches) {
+		t.Errorf("Matches = %+v, want %+v", result.Matches, wantMatches)
+	}
+	wantFailures := []LookupFailure{{Lookup: lookups[2], Message: "no Building named B9"}}
+	if !reflect.DeepEqual(result.Failures, wantFailures) {
+		t.Errorf("Failures = %+v, want %+v", result.Failures, wantFailures)
+	}
+}
+
+func TestProcessDocumentsWithFilePath(t *testing.T) {
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	dir := t.TempDir()
+	file := filepath.Join(dir, "plan.jpg")
+	if err := os.WriteFile(file, jpegContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		// A file is collected like a selected file, so a name outside the
+		// flat naming convention fails collection instead of the walk.
+		{name: "file", path: file, wantErr: "error collecting documents: "},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: "documents directory does not exist: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ProcessDocuments("token", models.DirectoryRun{DocumentsDir: tt.path}, nil)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("ProcessDocuments(%s) error = %v, want %q...", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCollectDocumentsSetsContentType(t *testing.T) {
+	dir := t.TempDir()
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{"bl_front.jpg": jpegContent, "bl_plan.pdf": pdfContent}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(building, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	documents, err := collectDocuments(dir, nil, logging.GetLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"bl_front.jpg": config.ContentTypeImage, "bl_plan.pdf": config.ContentTypePDF}
+	if len(documents) != len(want) {
+		t.Fatalf("collected %d documents, want %d", len(documents), len(want))
+	}
+
+	// The attachment record must use the type detected at collection, not
+	// read the file again.
+	for name := range files {
+		if err := os.Remove(filepath.Join(building, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, doc := range documents {
+		name := filepath.Base(doc.FilePath)
+		if doc.ContentType != want[name] {
+			t.Errorf("%s ContentType = %q, want %q", name, doc.ContentType, want[name])
+		}
+		record := buildAttachmentRecord(doc, "a0B000000000001", "")
+		if record["Content_Type__c"] != want[name] {
+			t.Errorf("%s Content_Type__c = %v, want %q", name, record["Content_Type__c"], want[name])
+		}
+	}
+}
+
+// TestCollectDocumentsLargeTree collects a synthetic tree big enough to
+// report progress, and checks individual files are not logged at info level.
+// The walker's own progress is covered by TestWalkReportsProgress.
+func TestCollectDocumentsLargeTree(t *testing.T) {
+	const buildings, perBuilding = 100, 101
+	dir := t.TempDir()
+	for b := 0; b < buildings; b++ {
+		building := filepath.Join(dir, "Tower", "P1", "Z1", fmt.Sprintf("B%03d", b))
+		if err := os.MkdirAll(building, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < perBuilding; i++ {
+			if err := os.WriteFile(filepath.Join(building, fmt.Sprintf("bl_%03d.jpg", i)), jpegContent, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	logger := logging.GetLogger()
+	documents, err := collectDocuments(dir, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != buildings*perBuilding {
+		t.Fatalf("collected %d documents, want %d", len(documents), buildings*perBuilding)
+	}
+
+	var progress []string
+	for _, line := range logger.RecentLines(50) {
+		if strings.Contains(line, "[INFO]") && strings.Contains(line, "bl_") {
+			t.Errorf("file logged at info level: %s", line)
+		}
+		if strings.Contains(line, "[INFO]") && strings.Contains(line, "Checked the content") {
+			progress = append(progress, line[strings.Index(line, "[INFO] ")+len("[INFO] "):])
+		}
+	}
+	want := []string{
+		"Checked the content of 5000 of 10100 files...",
+		"Checked the content of 10000 of 10100 files...",
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress messages = %q, want %q", progress, want)
+	}
+}
@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// checkDuplicateTitles looks for files already shared with the entity a
+// document will be attached to under the same title, so a rerun over a
+// folder does not pile up copies of the same file. Depending on the policy
+// the duplicates are flagged or left out of the run. This works on the files
+// themselves, independently of the attachment records resolveCollisions
+// looks at. If the check cannot run the documents are uploaded as they are.
+func checkDuplicateTitles(accessToken string, documents []models.DocumentInfo, policy string, logger *logging.Logger) []models.DocumentInfo {
+	if policy != config.DuplicateTitleWarn && policy != config.DuplicateTitleSkip {
+		return documents
+	}
+
+	var entityIds []string
+	seen := make(map[string]bool)
+	for _, doc := range documents {
+		if id := attachmentEntityID(doc); id != "" && !seen[id] {
+			seen[id] = true
+			entityIds = append(entityIds, id)
+		}
+	}
+	if len(entityIds) == 0 {
+		return documents
+	}
+
+	existing, err := queryLinkedTitles(accessToken, entityIds)
+	if err != nil {
+		logger.Warning("Skipping duplicate title check: %v", err)
+		return documents
+	}
+
+	kept := make([]models.DocumentInfo, 0, len(documents))
+	duplicates := 0
+	for _, doc := range documents {
+		entityId := attachmentEntityID(doc)
+		if !existing[duplicateTitleKey(entityId, contentTitle(doc))] {
+			kept = append(kept, doc)
+			continue
+		}
+
+		duplicates++
+		message := fmt.Sprintf("%s %s already has a file titled %q", doc.EntityType, generateFullPath(doc), contentTitle(doc))
+		if policy == config.DuplicateTitleSkip {
+			logger.Warning("Skipping %s: %s", doc.RelativePath, message)
+			continue
+		}
+		doc.Warnings = append(doc.Warnings, message)
+		logger.Warning("%s: %s", doc.RelativePath, message)
+		kept = append(kept, doc)
+	}
+
+	if duplicates > 0 {
+		logger.Warning("Found %d file(s) already in Salesforce under the same title", duplicates)
+	}
+	return kept
+}
+
+// queryLinkedTitles returns the titles of the files shared with each entity,
+// keyed by duplicateTitleKey.
+func queryLinkedTitles(accessToken string, entityIds []string) (map[string]bool, error) {
+	const batchSize = 100
+
+	titles := make(map[string]bool)
+	for i := 0; i < len(entityIds); i += batchSize {
+		end := min(i+batchSize, len(entityIds))
+		query := fmt.Sprintf("SELECT LinkedEntityId, ContentDocument.Title FROM ContentDocumentLink WHERE LinkedEntityId IN ('%s')",
+			strings.Join(entityIds[i:end], "','"))
+
+		next := config.DataURL("/query?q=" + url.QueryEscape(query))
+		for next != "" {
+			req, err := http.NewRequest("GET", next, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error creating ContentDocumentLink query: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("ContentDocumentLink query failed: %v", err)
+			}
+
+			var result struct {
+				Records []struct {
+					LinkedEntityId  string
+					ContentDocument struct {
+						Title string
+					}
+				} `json:"records"`
+				NextRecordsURL string `json:"nextRecordsUrl"`
+			}
+			status := resp.StatusCode
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			resp.Body.Close()
+			if status != http.StatusOK {
+				return nil, fmt.Errorf("ContentDocumentLink query failed: status %d", status)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error decoding ContentDocumentLink query response: %v", err)
+			}
+
+			for _, record := range result.Records {
+				titles[duplicateTitleKey(record.LinkedEntityId, record.ContentDocument.Title)] = true
+			}
+
+			next = ""
+			if result.NextRecordsURL != "" {
+				next = config.SFInstanceURL + result.NextRecordsURL
+			}
+		}
+	}
+
+	return titles, nil
+}
+
+// duplicateTitleKey matches titles case-insensitively, as Salesforce search
+// does, and entity IDs regardless of their 15 or 18 character form.
+func duplicateTitleKey(entityId, title string) string {
+	return salesforceID15(entityId) + "|" + strings.ToLower(title)
+}
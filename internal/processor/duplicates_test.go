@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func buildingDocuments(n int) []models.DocumentInfo {
+	var documents []models.DocumentInfo
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("bl_%03d.jpg", i)
+		documents = append(documents, models.DocumentInfo{
+			FilePath:      name,
+			RelativePath:  name,
+			EntityType:    "BUILDING",
+			NamePath:      map[string]string{"building": fmt.Sprintf("B%d", i)},
+			SalesforceIds: map[string]string{"building": fmt.Sprintf("a0B%012d", i)},
+		})
+	}
+	return documents
+}
+
+func TestCheckDuplicateTitles(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		documents   int
+		failQuery   bool
+		wantKept    []string
+		wantWarned  []string
+		wantQueries int32
+	}{
+		{name: "upload anyway", policy: config.DuplicateTitleUploadAnyway, documents: 3, wantKept: []string{"bl_000.jpg", "bl_001.jpg", "bl_002.jpg"}},
+		{name: "warn", policy: config.DuplicateTitleWarn, documents: 3, wantKept: []string{"bl_000.jpg", "bl_001.jpg", "bl_002.jpg"}, wantWarned: []string{"bl_000.jpg", "bl_002.jpg"}, wantQueries: 2},
+		{name: "skip", policy: config.DuplicateTitleSkip, documents: 3, wantKept: []string{"bl_001.jpg"}, wantQueries: 2},
+		{name: "query fails", policy: config.DuplicateTitleSkip, documents: 3, failQuery: true, wantKept: []string{"bl_000.jpg", "bl_001.jpg", "bl_002.jpg"}, wantQueries: 1},
+		{name: "batched", policy: config.DuplicateTitleSkip, documents: 150, wantQueries: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries atomic.Int32
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				queries.Add(1)
+				if tt.failQuery {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				// Each query answers over two pages: bl_000.jpg is already
+				// linked to its building, under another case and the 18
+				// character ID, and bl_002.jpg to its building on page two.
+				if r.URL.Path == "/next" {
+					json.NewEncoder(w).Encode(map[string]any{"records": []map[string]any{
+						{"LinkedEntityId": "a0B000000000002", "ContentDocument": map[string]any{"Title": "bl_002.jpg"}},
+					}})
+					return
+				}
+				if !strings.Contains(r.URL.Query().Get("q"), "FROM ContentDocumentLink") {
+					t.Errorf("unexpected query %s", r.URL.Query().Get("q"))
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"records": []map[string]any{
+						{"LinkedEntityId": "a0B000000000000AAA", "ContentDocument": map[string]any{"Title": "BL_000.JPG"}},
+						{"LinkedEntityId": "a0B000000000001", "ContentDocument": map[string]any{"Title": "other.jpg"}},
+					},
+					"nextRecordsUrl": "/next",
+				})
+			})
+
+			documents := buildingDocuments(tt.documents)
+			kept := checkDuplicateTitles("token", documents, tt.policy, logging.GetLogger())
+
+			if n := queries.Load(); n != tt.wantQueries {
+				t.Errorf("%d query requests, want %d", n, tt.wantQueries)
+			}
+			if tt.wantKept == nil {
+				if len(kept) != tt.documents-2 {
+					t.Errorf("kept %d documents, want %d", len(kept), tt.documents-2)
+				}
+				return
+			}
+			var keptNames, warned []string
+			for _, doc := range kept {
+				keptNames = append(keptNames, doc.RelativePath)
+				if len(doc.Warnings) > 0 {
+					warned = append(warned, doc.RelativePath)
+				}
+			}
+			if !reflect.DeepEqual(keptNames, tt.wantKept) {
+				t.Errorf("kept %v, want %v", keptNames, tt.wantKept)
+			}
+			if !reflect.DeepEqual(warned, tt.wantWarned) {
+				t.Errorf("warned about %v, want %v", warned, tt.wantWarned)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// specialNamePath names every level with spaces and URL-unsafe characters.
+var specialNamePath = map[string]string{"project": "Smith & Sons", "phase": "Phase 1?", "zone": "Zone #2", "building": "Block B&C / East"}
+
+func TestLookupNamesWithSpecialCharacters(t *testing.T) {
+	inTempDir(t)
+	lookup := &fakeLookup{}
+	useFakeLookup(t, lookup)
+
+	documents := []models.DocumentInfo{{
+		FilePath:      "bl_front.jpg",
+		RelativePath:  "Smith & Sons/Phase 1?/Zone #2/Block B&C _ East/bl_front.jpg",
+		EntityType:    "BUILDING",
+		NamePath:      specialNamePath,
+		SalesforceIds: make(map[string]string),
+	}}
+	if err := bulkLookupEntities(context.Background(), "token", documents, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sent := range lookup.lookups {
+		for key, name := range sent.NamePath {
+			if name != specialNamePath[key] {
+				t.Errorf("%s lookup sent %s %q, want %q", sent.EntityType, key, name, specialNamePath[key])
+			}
+		}
+	}
+	if got, want := documents[0].SalesforceIds["building"], entityPathKey("BUILDING", specialNamePath); got != want {
+		t.Errorf("building resolved to %q, want %q", got, want)
+	}
+}
+
+func TestRecordQueryWithSpecialCharacters(t *testing.T) {
+	const value = "Smith & Sons + Co #1"
+	var query string
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`{"records":[{"Id":"001000000000001","Name":"Smith & Sons + Co #1"}]}`))
+	})
+
+	ids, err := queryRecordIds("token", "Account", "Name", []string{value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT Id, Name FROM Account WHERE Name IN ('" + value + "')"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if ids["smith & sons + co #1"] != "001000000000001" {
+		t.Errorf("ids = %v", ids)
+	}
+}
+
+func TestViewURLsWithSpecialCharacters(t *testing.T) {
+	previousField, previousReport := config.RunIDField, config.ResultsReportID
+	config.RunIDField, config.ResultsReportID = "Upload_Run_Id__c", "00O000000000001"
+	defer func() { config.RunIDField, config.ResultsReportID = previousField, previousReport }()
+
+	result := &RunResult{RunID: "Smith & Sons #1", SObject: attachmentSObject, RecordIDs: []string{"a1", "a2"}}
+	parsed, err := url.Parse(result.ViewURL("https://acme.my.salesforce.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Query().Get("fv0"); got != result.RunID {
+		t.Errorf("fv0 = %q, want %q", got, result.RunID)
+	}
+
+	report := &RunResult{RunID: "r1", SObject: "ContentDocument", RecordIDs: []string{"069a", "069b"}, ReportPath: "/home/u/Smith & Sons #1/run ?.json"}
+	parsed, err = url.Parse(report.ViewURL("https://acme.my.salesforce.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Scheme != "file" || parsed.Path != report.ReportPath || parsed.RawQuery != "" || parsed.Fragment != "" {
+		t.Errorf("report URL = %s, want a file URL for %q", parsed, report.ReportPath)
+	}
+}
+
+func TestAttachmentFallbackURL(t *testing.T) {
+	dir := inTempDir(t)
+	sent := useFakeOrg(t, newFakeOrg())
+
+	documents := writeDocuments(t, dir, 1)
+	documents[0].NamePath = specialNamePath
+	documents[0].ContentDocumentId = "069000000000001"
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkCreateAttachmentUploaders(context.Background(), "token", "test", documents, nil, nil, nil, checkpoint, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	subrequests := sent()
+	if len(subrequests) != 1 {
+		t.Fatalf("sent %d subrequests, want 1", len(subrequests))
+	}
+	parsed, err := url.Parse(subrequests[0].Body["Attachment_Url__c"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/lightning/r/ContentDocument/069000000000001/view" {
+		t.Errorf("Attachment_Url__c path = %q, want the ContentDocument view", parsed.Path)
+	}
+}
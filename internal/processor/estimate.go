@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// bandwidthProbeSize is how much data the bandwidth probe sends. It is small
+// enough to finish in a moment on a slow link.
+const bandwidthProbeSize = 256 << 10
+
+// UploadEstimate summarizes what a directory run will send before it starts.
+// PayloadBytes is what goes over the wire: base64 inflates files sent inside
+// composite batches by a third, while large files go as raw binary.
+type UploadEstimate struct {
+	Files        int
+	TotalBytes   int64
+	PayloadBytes int64
+	// Bandwidth is the measured upload rate in bytes per second, or 0 when
+	// it could not be measured.
+	Bandwidth float64
+}
+
+// Duration is the rough time the payload takes at the measured bandwidth,
+// or 0 when the bandwidth is unknown.
+func (e *UploadEstimate) Duration() time.Duration {
+	if e.Bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(e.PayloadBytes) / e.Bandwidth * float64(time.Second))
+}
+
+func (e *UploadEstimate) Summary() string {
+	summary := fmt.Sprintf("%d file(s), %s on disk, about %s to upload.",
+		e.Files, formatBytes(e.TotalBytes), formatBytes(e.PayloadBytes))
+	if duration := e.Duration(); duration > 0 {
+		summary += fmt.Sprintf("\nAt the measured %s/s this takes roughly %s.",
+			formatBytes(int64(e.Bandwidth)), duration.Round(time.Second))
+	}
+	return summary
+}
+
+// EstimateUpload collects the documents a directory run would upload and
+// estimates the size and duration of the upload without sending any files.
+func EstimateUpload(run models.DirectoryRun) (*UploadEstimate, error) {
+	logger := logging.GetLogger()
+
+	exclude, err := filestructure.ParseExcludePatterns(run.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := collectDocuments(run.DocumentsDir, exclude, logger)
+	if err != nil {
+		return nil, err
+	}
+	documents, err = filterSubtree(documents, run.SubtreeFilter, logger)
+	if err != nil {
+		return nil, err
+	}
+	documents, err = filterDocumentType(documents, run.DocumentType, logger)
+	if err != nil {
+		return nil, err
+	}
+	documents = dropRejected(documents, logger)
+
+	estimate := estimateUpload(run.DocumentsDir, documents, config.BinaryUploadThreshold)
+	estimate.Bandwidth = probeBandwidth(logger)
+	return estimate, nil
+}
+
+func estimateUpload(documentsDir string, documents []models.DocumentInfo, binaryThreshold int64) *UploadEstimate {
+	estimate := &UploadEstimate{}
+	for _, doc := range documents {
+		info, err := os.Stat(filepath.Join(documentsDir, doc.RelativePath))
+		if err != nil {
+			continue
+		}
+		estimate.Files++
+		estimate.TotalBytes += info.Size()
+		if useBinaryUpload(info.Size(), binaryThreshold) {
+			estimate.PayloadBytes += info.Size()
+		} else {
+			estimate.PayloadBytes += base64Size(info.Size())
+		}
+	}
+	return estimate
+}
+
+// base64Size is the length of n bytes once base64 encoded with padding.
+func base64Size(n int64) int64 {
+	return (n + 2) / 3 * 4
+}
+
+// probeBandwidth times a small unauthenticated upload to the instance. The
+// request is rejected, but only after the body has been sent, so the elapsed
+// time gives a rough upload rate.
+func probeBandwidth(logger *logging.Logger) float64 {
+	body := bytes.NewReader(make([]byte, bandwidthProbeSize))
+	req, err := http.NewRequest("POST", config.SFInstanceURL+"/services/data/", body)
+	if err != nil {
+		logger.Debug("Bandwidth probe skipped: %v", err)
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		logger.Debug("Bandwidth probe failed: %v", err)
+		return 0
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return bandwidthProbeSize / elapsed.Seconds()
+}
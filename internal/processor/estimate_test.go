@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestEstimateUpload(t *testing.T) {
+	dir := t.TempDir()
+	sizes := map[string]int{"small.jpg": 3, "padded.jpg": 4, "large.pdf": 3000}
+	var documents []models.DocumentInfo
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		documents = append(documents, models.DocumentInfo{RelativePath: name})
+	}
+	documents = append(documents, models.DocumentInfo{RelativePath: "missing.jpg"})
+
+	// small.jpg and padded.jpg are base64 encoded to 4 and 8 bytes, while
+	// large.pdf is over the binary threshold and sent as is.
+	estimate := estimateUpload(dir, documents, 1000)
+	want := UploadEstimate{Files: 3, TotalBytes: 3007, PayloadBytes: 3012}
+	if *estimate != want {
+		t.Fatalf("estimateUpload() = %+v, want %+v", *estimate, want)
+	}
+	if d := estimate.Duration(); d != 0 {
+		t.Errorf("Duration() = %v without a bandwidth, want 0", d)
+	}
+	if got, want := estimate.Summary(), "3 file(s), 2.9 KB on disk, about 2.9 KB to upload."; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	// Without a binary threshold every file is base64 encoded.
+	if got := estimateUpload(dir, documents, 0).PayloadBytes; got != 4+8+4000 {
+		t.Errorf("PayloadBytes without binary uploads = %d, want %d", got, 4+8+4000)
+	}
+
+	estimate.Bandwidth = 1004
+	if d := estimate.Duration(); d != 3*time.Second {
+		t.Errorf("Duration() = %v, want 3s", d)
+	}
+	if got, want := estimate.Summary(), "3 file(s), 2.9 KB on disk, about 2.9 KB to upload.\nAt the measured 1004 B/s this takes roughly 3s."; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestBase64Size(t *testing.T) {
+	for n, want := range map[int64]int64{0: 0, 1: 4, 2: 4, 3: 4, 4: 8, 300: 400} {
+		if got := base64Size(n); got != want {
+			t.Errorf("base64Size(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+var attachmentCSVColumns = []string{
+	"Name",
+	"Attachment_Type__c",
+	"Content_Type__c",
+	"ContentDocumentId__c",
+	"Attachment_Url__c",
+	"Display_Value__c",
+	"Display_Value_Arabic__c",
+	"Phase__c",
+	"Zone__c",
+	"Building__c",
+	"Unit__c",
+	"Design_Type__c",
+	"RelativePath",
+}
+
+var contentVersionCSVColumns = []string{
+	"Title",
+	"PathOnClient",
+	"VersionData",
+	"FirstPublishLocationId",
+	"RelativePath",
+}
+
+// exportDataLoaderCSV writes the Attachments_Uploader__c records and the list
+// of files to upload as Data Loader ready CSV files. ContentDocumentId__c and
+// Attachment_Url__c are left blank since nothing has been uploaded yet.
+func exportDataLoaderCSV(documentsDir string, documents []models.DocumentInfo, logger *logging.Logger) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %v", err)
+	}
+
+	exportDir := filepath.Join(cwd, "exports", time.Now().Format("2006-01-02_150405"))
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %v", err)
+	}
+
+	var attachmentRows, fileRows [][]string
+	for _, doc := range documents {
+		entityId := attachmentEntityID(doc)
+		if entityId == "" {
+			logger.Error("Missing %s ID for document: %s", doc.EntityType, doc.FilePath)
+			continue
+		}
+
+		record := buildAttachmentRecord(doc, entityId, "")
+		row := make([]string, len(attachmentCSVColumns))
+		for i, column := range attachmentCSVColumns {
+			if value, ok := record[column]; ok {
+				row[i] = fmt.Sprint(value)
+			}
+		}
+		row[len(row)-1] = doc.RelativePath
+		attachmentRows = append(attachmentRows, row)
+
+		fullPath, err := filepath.Abs(filepath.Join(documentsDir, doc.RelativePath))
+		if err != nil {
+			return "", fmt.Errorf("error resolving path for %s: %v", doc.RelativePath, err)
+		}
+		fileRows = append(fileRows, []string{
+			contentTitle(doc),
+			contentTitle(doc),
+			fullPath,
+			entityId,
+			doc.RelativePath,
+		})
+	}
+
+	if err := writeCSV(filepath.Join(exportDir, "attachments_uploader.csv"), attachmentCSVColumns, attachmentRows); err != nil {
+		return "", err
+	}
+	if err := writeCSV(filepath.Join(exportDir, "content_versions.csv"), contentVersionCSVColumns, fileRows); err != nil {
+		return "", err
+	}
+
+	logger.Info("Exported %d attachment records and %d files", len(attachmentRows), len(fileRows))
+	return exportDir, nil
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExportDataLoaderCSV(t *testing.T) {
+	inTempDir(t)
+	documentsDir := t.TempDir()
+
+	documents := []models.DocumentInfo{
+		{
+			FilePath:      "up_A1.jpg",
+			RelativePath:  filepath.Join("Tower", "P1", "Z1", "B1", "units", "up_A1.jpg"),
+			EntityType:    "UNIT",
+			DocumentType:  config.DocTypeUnitPlan,
+			ContentType:   config.ContentTypeImage,
+			NamePath:      map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1", "unit": "A1"},
+			SalesforceIds: map[string]string{"unit": "a0U000000000001"},
+		},
+		{
+			FilePath:      "pp_master.pdf",
+			RelativePath:  filepath.Join("Tower", "P1", "pp_master.pdf"),
+			EntityType:    "PHASE",
+			DocumentType:  config.DocTypeProjectPlan,
+			ContentType:   config.ContentTypePDF,
+			NamePath:      map[string]string{"project": "Tower", "phase": "P1"},
+			SalesforceIds: map[string]string{"phase": "a0P000000000001"},
+		},
+		{
+			FilePath:      "bl_front.jpg",
+			RelativePath:  filepath.Join("Tower", "P1", "Z1", "B2", "bl_front.jpg"),
+			EntityType:    "BUILDING",
+			DocumentType:  config.DocTypeBuildingLocation,
+			NamePath:      map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B2"},
+			SalesforceIds: map[string]string{},
+		},
+	}
+
+	exportDir, err := exportDataLoaderCSV(documentsDir, documents, logging.GetLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachments := readCSV(t, filepath.Join(exportDir, "attachments_uploader.csv"))
+	wantAttachments := [][]string{
+		attachmentCSVColumns,
+		{"a0U000000000001", config.DocTypeUnitPlan, config.ContentTypeImage, "", "",
+			"Unit Plan for Unit A1 of Building B1 in Phase P1 of Tower", "Unit Plan for Unit A1 of Building B1 in Phase P1 of Tower",
+			"", "", "", "a0U000000000001", "", documents[0].RelativePath},
+		{"a0P000000000001", config.DocTypeProjectPlan, config.ContentTypePDF, "", "",
+			"Project Plan for Phase P1 of Tower", "Project Plan for Phase P1 of Tower",
+			"a0P000000000001", "", "", "", "", documents[1].RelativePath},
+	}
+	if !reflect.DeepEqual(attachments, wantAttachments) {
+		t.Errorf("attachments_uploader.csv =\n%q\nwant\n%q", attachments, wantAttachments)
+	}
+
+	files := readCSV(t, filepath.Join(exportDir, "content_versions.csv"))
+	if !reflect.DeepEqual(files[0], contentVersionCSVColumns) {
+		t.Errorf("content_versions.csv header = %q, want %q", files[0], contentVersionCSVColumns)
+	}
+	if len(files) != 3 {
+		t.Fatalf("content_versions.csv has %d files, want 2", len(files)-1)
+	}
+	for i, entityID := range []string{"a0U000000000001", "a0P000000000001"} {
+		doc := documents[i]
+		want := []string{contentTitle(doc), contentTitle(doc), filepath.Join(documentsDir, doc.RelativePath), entityID, doc.RelativePath}
+		if !reflect.DeepEqual(files[i+1], want) {
+			t.Errorf("content_versions.csv row %d = %q, want %q", i+1, files[i+1], want)
+		}
+	}
+}
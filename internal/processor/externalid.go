@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// contentVersionExternalID derives the value of the ContentVersion external
+// ID field from what identifies an upload: the entity, the document type
+// and the file's content. Re-running the same file for the same entity
+// yields the same value, wherever the documents folder lives.
+func contentVersionExternalID(doc models.DocumentInfo, checksum string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		doc.EntityType, generateFullPath(doc), doc.DocumentType, checksum,
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// assignExternalIDs computes the external ID of every document still to be
+// uploaded and reuses the ContentVersions that already carry one. Salesforce
+// cannot replace VersionData on an existing version, so instead of a real
+// upsert a found version counts as uploaded and only missing ones are
+// created. A retried batch that did commit is therefore not duplicated.
+// It returns the external IDs by relative path. Identical files for the
+// same entity and type share an ID, so only the first of them carries it.
+func assignExternalIDs(accessToken, documentsDir string, documents []models.DocumentInfo, logger *logging.Logger) (map[string]string, error) {
+	field := config.ContentVersionExternalIDField
+	if field == "" {
+		return nil, nil
+	}
+	if err := validateExternalIDField(accessToken, "ContentVersion", field, logger); err != nil {
+		return nil, err
+	}
+
+	externalIDs := make(map[string]string)
+	indexes := make(map[string][]int)
+	for i, doc := range documents {
+		if doc.SalesforceIds["contentVersionId"] != "" {
+			continue
+		}
+		checksum, err := fileChecksum(filepath.Join(documentsDir, doc.RelativePath))
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %v", doc.RelativePath, err)
+		}
+		externalID := contentVersionExternalID(doc, checksum)
+		if len(indexes[externalID]) == 0 {
+			externalIDs[doc.RelativePath] = externalID
+		}
+		indexes[externalID] = append(indexes[externalID], i)
+	}
+	if len(indexes) == 0 {
+		return externalIDs, nil
+	}
+
+	values := make([]string, 0, len(indexes))
+	for externalID := range indexes {
+		values = append(values, externalID)
+	}
+	existing, err := queryContentVersionsByExternalID(accessToken, field, values)
+	if err != nil {
+		return nil, err
+	}
+
+	for externalID, version := range existing {
+		for _, i := range indexes[externalID] {
+			documents[i].SalesforceIds["contentVersionId"] = version.id
+			documents[i].ContentDocumentId = version.contentDocumentID
+			logger.Debug("%s is already uploaded as ContentVersion %s", documents[i].RelativePath, version.id)
+		}
+	}
+	return externalIDs, nil
+}
+
+type existingVersion struct {
+	id                string
+	contentDocumentID string
+}
+
+// queryContentVersionsByExternalID returns the ContentVersions whose field
+// holds one of values, keyed by that value.
+func queryContentVersionsByExternalID(accessToken, field string, values []string) (map[string]existingVersion, error) {
+	const batchSize = 100
+
+	found := make(map[string]existingVersion)
+	for i := 0; i < len(values); i += batchSize {
+		end := min(i+batchSize, len(values))
+		query := fmt.Sprintf("SELECT Id, ContentDocumentId, %s FROM ContentVersion WHERE %s IN ('%s')",
+			field, field, strings.Join(values[i:end], "','"))
+
+		req, err := http.NewRequest("GET", config.DataURL("/query?q="+url.QueryEscape(query)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating ContentVersion query: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("ContentVersion query failed: %v", err)
+		}
+
+		var result struct {
+			Records []map[string]any `json:"records"`
+		}
+		status := resp.StatusCode
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("ContentVersion query failed: status %d", status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding ContentVersion query response: %v", err)
+		}
+
+		for _, record := range result.Records {
+			value, _ := fieldValue(record, field).(string)
+			id, _ := record["Id"].(string)
+			contentDocumentID, _ := record["ContentDocumentId"].(string)
+			if _, ok := found[value]; !ok && value != "" {
+				found[value] = existingVersion{id: id, contentDocumentID: contentDocumentID}
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// committedContentVersions returns a committedCheck that finds the
+// ContentVersions of a batch by their external ID, or nil when no external
+// ID field is configured. Batches are sent all or none, so finding any of
+// them means the whole batch was saved. Previews carry no external ID and
+// cannot be found again; they stay in the org without being linked.
+func committedContentVersions(accessToken string, logger *logging.Logger) committedCheck {
+	field := config.ContentVersionExternalIDField
+	if field == "" {
+		return nil
+	}
+
+	return func(ctx context.Context, subrequests []map[string]any) ([]CompositeResult, error) {
+		var refs, values []string
+		previews := 0
+		for _, subrequest := range subrequests {
+			ref, _ := subrequest["referenceId"].(string)
+			body, _ := subrequest["body"].(map[string]any)
+			if value, _ := body[field].(string); value != "" {
+				refs = append(refs, ref)
+				values = append(values, value)
+			} else if strings.HasPrefix(ref, previewRefPrefix) {
+				previews++
+			}
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+
+		found, err := queryContentVersionsByExternalID(accessToken, field, values)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, nil
+		}
+
+		saved := true
+		results := make([]CompositeResult, 0, len(refs))
+		for i, value := range values {
+			version, ok := found[value]
+			if !ok {
+				return nil, fmt.Errorf("%d of %d ContentVersions of the batch are in Salesforce, %s is not", len(found), len(values), refs[i])
+			}
+			body, err := json.Marshal(saveResult{ID: version.id, Success: &saved, Errors: []salesforceError{}})
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, CompositeResult{ReferenceID: refs[i], HTTPStatusCode: http.StatusCreated, Body: body})
+		}
+		if previews > 0 {
+			logger.Warning("%d preview(s) of the batch were saved but cannot be found again and are not linked", previews)
+		}
+		return results, nil
+	}
+}
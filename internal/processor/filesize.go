@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// checkFileSizes finds files larger than the org accepts for a ContentVersion.
+// They are either dropped from the run with a warning or reported together as
+// a single error before anything is uploaded.
+func checkFileSizes(documentsDir string, documents []models.DocumentInfo, maxBytes int64, skip bool, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	if maxBytes <= 0 {
+		return documents, nil
+	}
+
+	var kept []models.DocumentInfo
+	var oversized []string
+	for _, doc := range documents {
+		info, err := os.Stat(filepath.Join(documentsDir, doc.RelativePath))
+		if err != nil || info.Size() <= maxBytes {
+			kept = append(kept, doc)
+			continue
+		}
+
+		problem := fmt.Sprintf("%s is %s, the limit is %s", doc.RelativePath, formatBytes(info.Size()), formatBytes(maxBytes))
+		if skip {
+			logger.Warning("Skipping oversized file: %s", problem)
+			continue
+		}
+		oversized = append(oversized, problem)
+	}
+
+	if len(oversized) > 0 {
+		for _, problem := range oversized {
+			logger.Error("Oversized file: %s", problem)
+		}
+		return nil, fmt.Errorf("%d file(s) exceed the maximum file size:\n- %s\nRemove them or set SKIP_OVERSIZED_FILES=true to continue without them",
+			len(oversized), strings.Join(oversized, "\n- "))
+	}
+
+	return kept, nil
+}
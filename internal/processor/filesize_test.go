@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestCheckFileSizes(t *testing.T) {
+	dir := t.TempDir()
+	var documents []models.DocumentInfo
+	for name, size := range map[string]int{"under.pdf": 99, "at.pdf": 100, "over.pdf": 101} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"under.pdf", "at.pdf", "over.pdf"} {
+		documents = append(documents, models.DocumentInfo{RelativePath: name})
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		skip     bool
+		want     []string
+		wantErr  bool
+	}{
+		{name: "no limit", maxBytes: 0, want: []string{"under.pdf", "at.pdf", "over.pdf"}},
+		{name: "limit above all", maxBytes: 101, want: []string{"under.pdf", "at.pdf", "over.pdf"}},
+		{name: "oversized fails", maxBytes: 100, wantErr: true},
+		{name: "oversized skipped", maxBytes: 100, skip: true, want: []string{"under.pdf", "at.pdf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := checkFileSizes(dir, documents, tt.maxBytes, tt.skip, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkFileSizes() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "over.pdf is 101 B, the limit is 100 B") {
+				t.Errorf("checkFileSizes() error = %v, want the oversized file with its size", err)
+			}
+			var names []string
+			for _, doc := range kept {
+				names = append(names, doc.RelativePath)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("checkFileSizes() kept %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 512, want: "512 B"},
+		{size: 1536, want: "1.5 KB"},
+		{size: 2 << 30, want: "2.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.size); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// useTestServer points the processor's requests at handler for one test.
+func useTestServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	previousClient, previousURL := httpClient, config.SFInstanceURL
+	httpClient, config.SFInstanceURL = server.Client(), server.URL
+	t.Cleanup(func() {
+		server.Close()
+		httpClient, config.SFInstanceURL = previousClient, previousURL
+	})
+}
+
+// inTempDir runs the rest of the test in a temporary working directory, so
+// the run reports and audit logs it writes are cleaned up.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+	return dir
+}
+
+// writeDocuments creates n small files under dir and returns them as
+// building documents ready to upload.
+func writeDocuments(t *testing.T, dir string, n int) []models.DocumentInfo {
+	t.Helper()
+	var documents []models.DocumentInfo
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("bl_%03d.jpg", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		documents = append(documents, models.DocumentInfo{
+			FilePath:      filepath.Join(dir, name),
+			RelativePath:  name,
+			EntityType:    "BUILDING",
+			DocumentType:  config.DocTypeBuildingLocation,
+			NamePath:      map[string]string{"building": fmt.Sprintf("B%d", i)},
+			SalesforceIds: map[string]string{"building": fmt.Sprintf("a0B%012d", i)},
+		})
+	}
+	return documents
+}
+
+var queryIDs = regexp.MustCompile(`'([^']+)'`)
+
+// fakeOrg answers the composite creates, ContentVersion queries,
+// ContentDistribution reads and rollback deletes of an upload, creating
+// every record it is asked for.
+type fakeOrg struct {
+	mutex     sync.Mutex
+	created   map[string][]string
+	deleted   []string
+	nextID    atomic.Int64
+	composite atomic.Int32
+	// fail, when set, decides whether a composite request is rejected.
+	fail func(call int32) bool
+}
+
+func newFakeOrg() *fakeOrg {
+	return &fakeOrg{created: make(map[string][]string)}
+}
+
+func (o *fakeOrg) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite"):
+		call := o.composite.Add(1)
+		if o.fail != nil && o.fail(call) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`[{"errorCode":"SERVER_UNAVAILABLE","message":"down"}]`))
+			return
+		}
+		var request struct {
+			CompositeRequest []struct {
+				ReferenceID string `json:"referenceId"`
+				URL         string `json:"url"`
+			} `json:"compositeRequest"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		var results []map[string]any
+		for _, subrequest := range request.CompositeRequest {
+			sobject := subrequest.URL[strings.LastIndex(subrequest.URL, "/")+1:]
+			id := fmt.Sprintf("%s%012d", sobjectPrefix(sobject), o.nextID.Add(1))
+			o.mutex.Lock()
+			o.created[sobject] = append(o.created[sobject], id)
+			o.mutex.Unlock()
+			results = append(results, map[string]any{
+				"referenceId":    subrequest.ReferenceID,
+				"httpStatusCode": http.StatusCreated,
+				"body":           map[string]any{"id": id, "success": true},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"compositeResponse": results})
+
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/query"):
+		var records []map[string]any
+		for _, match := range queryIDs.FindAllStringSubmatch(r.URL.Query().Get("q"), -1) {
+			records = append(records, map[string]any{"Id": match[1], "ContentDocumentId": "069" + match[1][3:]})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"records": records})
+
+	case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/composite/sobjects"):
+		var results []map[string]any
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			o.mutex.Lock()
+			o.deleted = append(o.deleted, id)
+			o.mutex.Unlock()
+			results = append(results, map[string]any{"id": id, "success": true})
+		}
+		json.NewEncoder(w).Encode(results)
+
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/sobjects/ContentDistribution/"):
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		json.NewEncoder(w).Encode(map[string]any{"ContentDownloadUrl": "https://acme.file.force.com/" + id})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (o *fakeOrg) count(sobject string) int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return len(o.created[sobject])
+}
+
+func sobjectPrefix(sobject string) string {
+	switch sobject {
+	case "ContentVersion":
+		return "068"
+	case "ContentDistribution":
+		return "05D"
+	default:
+		return "a0X"
+	}
+}
+
+func filepathInReports(t *testing.T, name string) string {
+	t.Helper()
+	dir, err := reportsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, name)
+}
+
+// subrequest is a composite subrequest sent to the fake org.
+type subrequest struct {
+	SObject string
+	Body    map[string]any
+}
+
+// useFakeOrg serves org for one test and returns a function listing every
+// composite subrequest it has received so far.
+func useFakeOrg(t *testing.T, org http.Handler) func() []subrequest {
+	t.Helper()
+	var mutex sync.Mutex
+	var sent []subrequest
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite") {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var request struct {
+				CompositeRequest []struct {
+					URL  string         `json:"url"`
+					Body map[string]any `json:"body"`
+				} `json:"compositeRequest"`
+			}
+			json.Unmarshal(body, &request)
+			mutex.Lock()
+			for _, sub := range request.CompositeRequest {
+				sent = append(sent, subrequest{SObject: sub.URL[strings.LastIndex(sub.URL, "/")+1:], Body: sub.Body})
+			}
+			mutex.Unlock()
+		}
+		org.ServeHTTP(w, r)
+	})
+	return func() []subrequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]subrequest(nil), sent...)
+	}
+}
+
+// fakeLookup answers bulk lookups, giving each entity its entity path key as
+// its ID, and records every lookup it was asked for. Paths in missing are
+// reported as not found.
+type fakeLookup struct {
+	mutex   sync.Mutex
+	lookups []models.EntityLookup
+	missing map[string]bool
+}
+
+func (l *fakeLookup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request models.BulkLookupRequest
+	json.NewDecoder(r.Body).Decode(&request)
+
+	response := make(map[string]string)
+	for _, lookup := range request.Lookups {
+		key, _ := json.Marshal(lookup)
+		id := entityPathKey(lookup.EntityType, lookup.NamePath)
+		if l.missing[id] {
+			id = "ERROR: not found"
+		}
+		response[string(key)] = id
+	}
+	l.mutex.Lock()
+	l.lookups = append(l.lookups, request.Lookups...)
+	l.mutex.Unlock()
+	json.NewEncoder(w).Encode(response)
+}
+
+// count returns how many lookups of entityType were sent.
+func (l *fakeLookup) count(entityType string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	n := 0
+	for _, lookup := range l.lookups {
+		if lookup.EntityType == entityType {
+			n++
+		}
+	}
+	return n
+}
+
+// useFakeLookup serves lookup as the bulk-lookup endpoint for one test.
+func useFakeLookup(t *testing.T, lookup *fakeLookup) {
+	t.Helper()
+	useTestServer(t, lookup.ServeHTTP)
+	previous := config.BulkLookupURL
+	config.BulkLookupURL = config.SFInstanceURL + "/services/apexrest/admin/bulk-lookup"
+	t.Cleanup(func() { config.BulkLookupURL = previous })
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+type APILimit struct {
+	Max       int `json:"Max"`
+	Remaining int `json:"Remaining"`
+}
+
+type APILimits struct {
+	DailyApiRequests APILimit `json:"DailyApiRequests"`
+}
+
+func fetchAPILimits(accessToken string) (*APILimits, error) {
+	req, err := http.NewRequest("GET", config.DataURL("/limits"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating limits request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("limits request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("limits request failed: status %d", resp.StatusCode)
+	}
+
+	var limits APILimits
+	if err := json.NewDecoder(resp.Body).Decode(&limits); err != nil {
+		return nil, fmt.Errorf("error decoding limits response: %v", err)
+	}
+	return &limits, nil
+}
+
+func snapshotAPILimits(accessToken string, logger *logging.Logger) *APILimits {
+	limits, err := fetchAPILimits(accessToken)
+	if err != nil {
+		logger.Warning("Could not read API limits: %v", err)
+		return nil
+	}
+	logger.Info("Daily API requests remaining: %d of %d",
+		limits.DailyApiRequests.Remaining, limits.DailyApiRequests.Max)
+	return limits
+}
+
+func reportAPIUsage(accessToken string, before *APILimits, logger *logging.Logger) {
+	if before == nil {
+		return
+	}
+
+	after, err := fetchAPILimits(accessToken)
+	if err != nil {
+		logger.Warning("Could not read API limits after run: %v", err)
+		return
+	}
+
+	consumed := before.DailyApiRequests.Remaining - after.DailyApiRequests.Remaining
+	if consumed < 0 {
+		// The daily allowance rolled over during the run.
+		consumed = 0
+	}
+	logger.Info("API usage: %d requests consumed by this run, %d of %d remaining today",
+		consumed, after.DailyApiRequests.Remaining, after.DailyApiRequests.Max)
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestReportAPIUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining []int
+		want      string
+	}{
+		{name: "consumed", remaining: []int{14000, 13963}, want: "API usage: 37 requests consumed by this run, 13963 of 15000 remaining today"},
+		{name: "allowance rolled over", remaining: []int{20, 14990}, want: "API usage: 0 requests consumed by this run, 14990 of 15000 remaining today"},
+		{name: "limits unavailable after the run", remaining: []int{14000}, want: "Could not read API limits after run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/limits") || calls >= len(tt.remaining) {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"DailyApiRequests": map[string]int{"Max": 15000, "Remaining": tt.remaining[calls]},
+				})
+				calls++
+			})
+
+			logger := logging.GetLogger()
+			before := snapshotAPILimits("token", logger)
+			if before == nil || before.DailyApiRequests.Remaining != tt.remaining[0] {
+				t.Fatalf("snapshot = %+v, want %d remaining", before, tt.remaining[0])
+			}
+			reportAPIUsage("token", before, logger)
+
+			if lines := logger.RecentLines(1); len(lines) != 1 || !strings.Contains(lines[0], tt.want) {
+				t.Errorf("last log line = %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/salesforce"
+)
+
+func createContentDocumentLinks(ctx context.Context, accessToken string, documents []models.DocumentInfo, audit *auditLog, checkpoint *checkpointer, logger *logging.Logger) error {
+	logger.Info("Linking library content to entities")
+
+	var allRequests []map[string]any
+	refs := make(referenceMap)
+	for i, doc := range documents {
+		if doc.SalesforceIds["contentDocumentLinkId"] != "" {
+			continue
+		}
+		entityId := attachmentEntityID(doc)
+		if doc.ContentDocumentId == "" || entityId == "" {
+			logger.Warning("Cannot link %s: missing ContentDocument or %s ID", doc.FilePath, doc.EntityType)
+			continue
+		}
+
+		ref, err := refs.add(fmt.Sprintf("linkRef%d", i), &documents[i])
+		if err != nil {
+			return err
+		}
+		allRequests = append(allRequests, contentDocumentLinkRequest(ref, doc.ContentDocumentId, entityId))
+		if previewId := doc.SalesforceIds["previewContentDocumentId"]; previewId != "" {
+			ref, err := refs.add(fmt.Sprintf("previewLinkRef%d", i), &documents[i])
+			if err != nil {
+				return err
+			}
+			allRequests = append(allRequests, contentDocumentLinkRequest(ref, previewId, entityId))
+		}
+	}
+
+	client := salesforce.NewClient(accessToken, httpClient)
+	for i := 0; i < len(allRequests); i += compositeBatchSize {
+		end := min(i+compositeBatchSize, len(allRequests))
+
+		results, err := sendComposite(ctx, client, allRequests[i:end], true)
+		if err != nil {
+			return fmt.Errorf("link request failed: %v", err)
+		}
+		if err := compositeError(results); err != nil {
+			err = withAccessContext(err, "ContentDocumentLink", refs)
+			return fmt.Errorf("failed to create ContentDocumentLink %w", err)
+		}
+
+		checkpoint.apply(func() {
+			for _, result := range results {
+				doc := refs[result.ReferenceID]
+				if doc != nil && strings.HasPrefix(result.ReferenceID, "linkRef") {
+					doc.SalesforceIds["contentDocumentLinkId"] = result.ID()
+				}
+				audit.record("create", "ContentDocumentLink", result.ID(), doc)
+			}
+		})
+	}
+
+	logger.Info("Created %d ContentDocumentLinks", len(allRequests))
+	return nil
+}
+
+func contentDocumentLinkRequest(referenceId, contentDocumentId, entityId string) map[string]any {
+	return map[string]any{
+		"method":      "POST",
+		"url":         config.DataPath("/sobjects/ContentDocumentLink"),
+		"referenceId": referenceId,
+		"body": map[string]any{
+			"ContentDocumentId": contentDocumentId,
+			"LinkedEntityId":    entityId,
+			"ShareType":         config.LinkShareType,
+			"Visibility":        config.LinkVisibility,
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+// TestPublishingModes checks where new content is first published and
+// which entity links are created, with and without a content library.
+func TestPublishingModes(t *testing.T) {
+	const libraryID = "058000000000001"
+
+	tests := []struct {
+		name      string
+		library   string
+		publishTo func(entityID string) string
+		links     bool
+	}{
+		{name: "entity record", publishTo: func(entityID string) string { return entityID }},
+		{name: "content library", library: libraryID, publishTo: func(string) string { return libraryID }, links: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTempDir(t)
+			sent := useFakeOrg(t, newFakeOrg())
+
+			previousLibrary, previousMode := config.ContentLibraryID, config.AttachmentMode
+			config.ContentLibraryID, config.AttachmentMode = tt.library, config.AttachmentModeUploader
+			defer func() { config.ContentLibraryID, config.AttachmentMode = previousLibrary, previousMode }()
+
+			documents := writeDocuments(t, dir, 3)
+			logger := logging.GetLogger()
+			checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+			err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			publishedTo := make(map[string]string)
+			linked := make(map[string]bool)
+			for _, sub := range sent() {
+				switch sub.SObject {
+				case "ContentVersion":
+					location, _ := sub.Body["FirstPublishLocationId"].(string)
+					publishedTo[sub.Body["Title"].(string)] = location
+				case "ContentDocumentLink":
+					linked[sub.Body["LinkedEntityId"].(string)] = true
+				}
+			}
+			if len(publishedTo) != len(documents) {
+				t.Fatalf("%d ContentVersions created for %d documents", len(publishedTo), len(documents))
+			}
+			for _, doc := range documents {
+				entityID := doc.SalesforceIds["building"]
+				if got, want := publishedTo[contentTitle(doc)], tt.publishTo(entityID); got != want {
+					t.Errorf("%s published to %q, want %q", doc.RelativePath, got, want)
+				}
+				if linked[entityID] != tt.links {
+					t.Errorf("%s linked to its building = %v, want %v", doc.RelativePath, linked[entityID], tt.links)
+				}
+			}
+		})
+	}
+}
+
+func TestLinkModePayloads(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	sent := useFakeOrg(t, org)
+
+	previousMode, previousShare, previousVisibility := config.AttachmentMode, config.LinkShareType, config.LinkVisibility
+	config.AttachmentMode, config.LinkShareType, config.LinkVisibility = config.AttachmentModeLink, "I", "InternalUsers"
+	defer func() {
+		config.AttachmentMode, config.LinkShareType, config.LinkVisibility = previousMode, previousShare, previousVisibility
+	}()
+
+	documents := writeDocuments(t, dir, 2)
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var links []map[string]any
+	for _, sub := range sent() {
+		switch sub.SObject {
+		case "ContentVersion":
+			if location, ok := sub.Body["FirstPublishLocationId"]; ok && location != "" {
+				t.Errorf("ContentVersion published to %v in link mode, want the explicit link only", location)
+			}
+		case "ContentDocumentLink":
+			links = append(links, sub.Body)
+		}
+	}
+	if len(links) != len(documents) {
+		t.Fatalf("%d ContentDocumentLinks created for %d documents", len(links), len(documents))
+	}
+	for i, doc := range documents {
+		want := map[string]any{
+			"ContentDocumentId": doc.ContentDocumentId,
+			"LinkedEntityId":    doc.SalesforceIds["building"],
+			"ShareType":         "I",
+			"Visibility":        "InternalUsers",
+		}
+		if !reflect.DeepEqual(links[i], want) {
+			t.Errorf("link %d = %v, want %v", i, links[i], want)
+		}
+		if doc.SalesforceIds["contentDocumentLinkId"] == "" {
+			t.Errorf("%s has no contentDocumentLinkId recorded", doc.RelativePath)
+		}
+	}
+	if n := org.count("ContentDistribution"); n != 0 {
+		t.Errorf("%d ContentDistributions created in link mode, want none", n)
+	}
+}
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// designTypeDocument returns a document for design type name in phase.
+func designTypeDocument(phase, name string) models.DocumentInfo {
+	return models.DocumentInfo{
+		FilePath:      "fp_" + name + ".jpg",
+		RelativePath:  "Tower/" + phase + "/design_types/fp_" + name + ".jpg",
+		EntityType:    "DESIGN_TYPE",
+		NamePath:      map[string]string{"project": "Tower", "phase": phase, "designType": name},
+		SalesforceIds: make(map[string]string),
+	}
+}
+
+func TestBulkLookupSameNameInDifferentPhases(t *testing.T) {
+	inTempDir(t)
+	lookup := &fakeLookup{}
+	useFakeLookup(t, lookup)
+
+	documents := []models.DocumentInfo{
+		designTypeDocument("P1", "Loft"),
+		designTypeDocument("P2", "Loft"),
+		designTypeDocument("P2", "Loft"),
+	}
+	if err := bulkLookupEntities(context.Background(), "token", documents, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := lookup.count("PHASE"); n != 2 {
+		t.Errorf("%d phase lookups sent, want 2", n)
+	}
+	if n := lookup.count("DESIGN_TYPE"); n != 2 {
+		t.Errorf("%d design type lookups sent, want one per phase", n)
+	}
+	for _, doc := range documents {
+		want := entityPathKey("DESIGN_TYPE", doc.NamePath)
+		if got := doc.SalesforceIds["design_type"]; got != want {
+			t.Errorf("%s resolved to %q, want %q", doc.RelativePath, got, want)
+		}
+	}
+}
+
+func TestBulkLookupReusedNamesAcrossBranches(t *testing.T) {
+	inTempDir(t)
+	lookup := &fakeLookup{}
+	useFakeLookup(t, lookup)
+
+	var documents []models.DocumentInfo
+	for _, path := range [][3]string{{"P1", "Z1", "B1"}, {"P2", "Z1", "B1"}, {"P1", "Z2", "B1"}, {"P1", "Z1", "B1"}} {
+		documents = append(documents, models.DocumentInfo{
+			FilePath:      "bl_front.jpg",
+			RelativePath:  "Tower/" + path[0] + "/" + path[1] + "/" + path[2] + "/bl_front.jpg",
+			EntityType:    "BUILDING",
+			NamePath:      map[string]string{"project": "Tower", "phase": path[0], "zone": path[1], "building": path[2]},
+			SalesforceIds: make(map[string]string),
+		})
+	}
+	if err := bulkLookupEntities(context.Background(), "token", documents, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	for entityType, want := range map[string]int{"PHASE": 2, "ZONE": 3, "BUILDING": 3} {
+		if n := lookup.count(entityType); n != want {
+			t.Errorf("%d %s lookups sent, want %d", n, entityType, want)
+		}
+	}
+	for _, doc := range documents {
+		if got, want := doc.SalesforceIds["building"], entityPathKey("BUILDING", doc.NamePath); got != want {
+			t.Errorf("%s resolved to %q, want %q", doc.RelativePath, got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+type lookupCacheEntry struct {
+	ID         string    `json:"id"`
+	ResolvedAt time.Time `json:"resolvedAt"`
+}
+
+// lookupCache persists resolved entity IDs between runs so unchanged entities
+// skip the bulk lookup call. Entries are scoped to the org instance and expire
+// after the configured TTL. A nil *lookupCache is valid and caches nothing.
+type lookupCache struct {
+	path    string
+	ttl     time.Duration
+	entries map[string]lookupCacheEntry
+	dirty   bool
+}
+
+func lookupCachePath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %v", err)
+	}
+	return filepath.Join(cwd, "cache", "lookup_cache.json"), nil
+}
+
+func openLookupCache(ttl time.Duration, refresh bool, logger *logging.Logger) *lookupCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	path, err := lookupCachePath()
+	if err != nil {
+		logger.Warning("Lookup cache disabled: %v", err)
+		return nil
+	}
+
+	cache := newLookupCache(path, ttl)
+	if refresh {
+		logger.Info("Refreshing lookup cache, cached IDs will be resolved again")
+		cache.dirty = true
+		return cache
+	}
+
+	if err := cache.load(); err != nil {
+		logger.Warning("Ignoring unreadable lookup cache: %v", err)
+	}
+	return cache
+}
+
+func newLookupCache(path string, ttl time.Duration) *lookupCache {
+	return &lookupCache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]lookupCacheEntry),
+	}
+}
+
+func (c *lookupCache) load() error {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.entries)
+}
+
+func (c *lookupCache) cacheKey(key string) string {
+	return config.SFInstanceURL + "|" + key
+}
+
+func (c *lookupCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	entry, ok := c.entries[c.cacheKey(key)]
+	if !ok || c.expired(entry) {
+		return "", false
+	}
+	return entry.ID, true
+}
+
+func (c *lookupCache) put(key, id string) {
+	if c == nil {
+		return
+	}
+	c.entries[c.cacheKey(key)] = lookupCacheEntry{ID: id, ResolvedAt: time.Now().UTC()}
+	c.dirty = true
+}
+
+func (c *lookupCache) expired(entry lookupCacheEntry) bool {
+	return time.Now().Sub(entry.ResolvedAt) > c.ttl
+}
+
+func (c *lookupCache) save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+
+	for key, entry := range c.entries {
+		if c.expired(entry) {
+			delete(c.entries, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lookup cache: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lookup cache: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write lookup cache: %v", err)
+	}
+
+	c.dirty = false
+	return nil
+}
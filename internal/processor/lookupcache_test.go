@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestLookupCache(t *testing.T) {
+	inTempDir(t)
+	path, err := lookupCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := newLookupCache(path, time.Hour)
+	cache.put("BUILDING|Tower/P1/Z1/B1", "a0B000000000001")
+	cache.entries[cache.cacheKey("BUILDING|Tower/P1/Z1/B2")] = lookupCacheEntry{
+		ID:         "a0B000000000002",
+		ResolvedAt: time.Now().Add(-2 * time.Hour),
+	}
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.GetLogger()
+	tests := []struct {
+		name    string
+		refresh bool
+		key     string
+		wantID  string
+		wantHit bool
+	}{
+		{name: "hit", key: "BUILDING|Tower/P1/Z1/B1", wantID: "a0B000000000001", wantHit: true},
+		{name: "miss", key: "BUILDING|Tower/P1/Z1/B3"},
+		{name: "expired", key: "BUILDING|Tower/P1/Z1/B2"},
+		{name: "refresh", refresh: true, key: "BUILDING|Tower/P1/Z1/B1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reopened := openLookupCache(time.Hour, tt.refresh, logger)
+			id, hit := reopened.get(tt.key)
+			if id != tt.wantID || hit != tt.wantHit {
+				t.Errorf("get(%q) = %q, %v, want %q, %v", tt.key, id, hit, tt.wantID, tt.wantHit)
+			}
+		})
+	}
+
+	if openLookupCache(0, false, logger) != nil {
+		t.Error("openLookupCache() with no TTL returned a cache")
+	}
+}
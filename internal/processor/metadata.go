@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// loadMetadataCSV reads extra Attachments_Uploader__c field values from a
+// central CSV. The first column names the document, by relative path or bare
+// file name, and every other column header is a field API name. Empty cells
+// are ignored.
+func loadMetadataCSV(path string) (map[string]map[string]any, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata CSV: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata CSV %s: %v", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	metadata := make(map[string]map[string]any)
+	for _, row := range rows[1:] {
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		fields := make(map[string]any)
+		for i := 1; i < len(row) && i < len(header); i++ {
+			if value := strings.TrimSpace(row[i]); value != "" {
+				fields[strings.TrimSpace(header[i])] = value
+			}
+		}
+		metadata[filepath.ToSlash(strings.TrimSpace(row[0]))] = fields
+	}
+	return metadata, nil
+}
+
+func loadSidecar(fullPath string) (map[string]any, error) {
+	data, err := os.ReadFile(filestructure.SidecarPath(fullPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid sidecar %s: %v", filepath.Base(filestructure.SidecarPath(fullPath)), err)
+	}
+	return fields, nil
+}
+
+// loadDocumentMetadata attaches extra field values to each document. Values
+// from a document's own sidecar take precedence over the central CSV.
+func loadDocumentMetadata(documentsDir, metadataCSV string, documents []models.DocumentInfo, logger *logging.Logger) error {
+	var central map[string]map[string]any
+	if metadataCSV != "" {
+		var err error
+		if central, err = loadMetadataCSV(metadataCSV); err != nil {
+			return err
+		}
+	}
+
+	for i := range documents {
+		fields := make(map[string]any)
+
+		csvFields, ok := central[filepath.ToSlash(documents[i].RelativePath)]
+		if !ok {
+			csvFields = central[filepath.Base(documents[i].RelativePath)]
+		}
+		for name, value := range csvFields {
+			fields[name] = value
+		}
+
+		sidecar, err := loadSidecar(filepath.Join(documentsDir, documents[i].RelativePath))
+		if err != nil {
+			return err
+		}
+		for name, value := range sidecar {
+			fields[name] = value
+		}
+
+		if len(fields) > 0 {
+			documents[i].ExtraFields = fields
+			logger.Debug("Extra fields for %s: %v", documents[i].RelativePath, fields)
+		}
+	}
+	return nil
+}
+
+func extraFieldNames(documents []models.DocumentInfo) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, doc := range documents {
+		for name := range doc.ExtraFields {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestLoadDocumentMetadata(t *testing.T) {
+	dir := t.TempDir()
+	building := filepath.Join(dir, "Tower", "P1", "Z1", "B1")
+	if err := os.MkdirAll(building, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"bl_front.jpg":     "jpg",
+		"bl_back.jpg":      "jpg",
+		"bl_side.jpg":      "jpg",
+		"bl_back.jpg.json": `{"Category__c": "Exterior", "Sort_Order__c": 2}`,
+		"bl_side.jpg.json": `{"Category__c": `,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(building, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadataCSV := filepath.Join(dir, "metadata.csv")
+	csv := "file,Category__c,Sort_Order__c\n" +
+		"Tower/P1/Z1/B1/bl_front.jpg,Facade,1\n" +
+		"bl_back.jpg,Interior,\n"
+	if err := os.WriteFile(metadataCSV, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	relative := func(name string) string { return filepath.Join("Tower", "P1", "Z1", "B1", name) }
+	documents := []models.DocumentInfo{
+		{RelativePath: relative("bl_front.jpg")},
+		{RelativePath: relative("bl_back.jpg")},
+	}
+	if err := loadDocumentMetadata(dir, metadataCSV, documents, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]any{
+		// Matched by relative path in the CSV.
+		{"Category__c": "Facade", "Sort_Order__c": "1"},
+		// Matched by file name in the CSV, with the sidecar taking precedence.
+		{"Category__c": "Exterior", "Sort_Order__c": float64(2)},
+	}
+	for i, doc := range documents {
+		if !reflect.DeepEqual(doc.ExtraFields, want[i]) {
+			t.Errorf("%s ExtraFields = %v, want %v", doc.RelativePath, doc.ExtraFields, want[i])
+		}
+	}
+	if names := extraFieldNames(documents); !reflect.DeepEqual(names, []string{"Category__c", "Sort_Order__c"}) {
+		t.Errorf("extraFieldNames() = %v", names)
+	}
+
+	invalid := []models.DocumentInfo{{RelativePath: relative("bl_side.jpg")}}
+	if err := loadDocumentMetadata(dir, "", invalid, logging.GetLogger()); err == nil {
+		t.Error("loadDocumentMetadata() accepted an invalid sidecar")
+	}
+}
+
+func TestExtraFieldsMergedIntoRecord(t *testing.T) {
+	doc := models.DocumentInfo{
+		RelativePath: "bl_front.jpg",
+		EntityType:   "BUILDING",
+		NamePath:     map[string]string{"building": "B1"},
+		ExtraFields:  map[string]any{"Category__c": "Facade", "Building__c": "a0B000000000009"},
+	}
+	record := buildAttachmentRecord(doc, "a0B000000000001", "")
+	if record["Category__c"] != "Facade" {
+		t.Errorf("Category__c = %v, want Facade", record["Category__c"])
+	}
+	// Metadata cannot redirect the record to another entity.
+	if record["Building__c"] != "a0B000000000001" {
+		t.Errorf("Building__c = %v, want the looked-up building", record["Building__c"])
+	}
+}
+
+func TestValidateMetadataFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []string
+		describes bool
+		wantErr   bool
+	}{
+		{name: "createable fields", fields: []string{"Category__c", "sort_order__c"}, describes: true},
+		{name: "unknown field", fields: []string{"Category__c", "Colour__c"}, describes: true, wantErr: true},
+		{name: "read-only field", fields: []string{"Formula__c"}, describes: true, wantErr: true},
+		{name: "describe unavailable", fields: []string{"Colour__c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if !tt.describes {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"fields": []map[string]any{
+					{"name": "Category__c", "createable": true},
+					{"name": "Sort_Order__c", "createable": true},
+					{"name": "Formula__c", "createable": false},
+				}})
+			})
+
+			err := validateCreateableFields("token", attachmentSObject, tt.fields, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCreateableFields(%v) error = %v, wantErr %v", tt.fields, err, tt.wantErr)
+			}
+		})
+	}
+}
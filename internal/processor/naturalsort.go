@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// sortDocuments puts documents in natural order of their relative paths, so
+// reference IDs, upload order and reports are the same from run to run
+// whatever order the filesystem lists files in.
+func sortDocuments(documents []models.DocumentInfo) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		return naturalLess(filepath.ToSlash(documents[i].RelativePath), filepath.ToSlash(documents[j].RelativePath))
+	})
+}
+
+// naturalLess compares strings with runs of digits taken as numbers, so
+// unit_2 sorts before unit_10. Text is compared case-insensitively, with the
+// exact bytes as a tie-break.
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			continue
+		}
+
+		ca, cb := lowerASCII(a[i]), lowerASCII(b[j])
+		if ca != cb {
+			return ca < cb
+		}
+		i++
+		j++
+	}
+
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"unit_2", "unit_10", true},
+		{"unit_10", "unit_2", false},
+		{"unit_02", "unit_10", true},
+		{"unit_2", "unit_02", false},
+		{"Unit_a", "unit_b", true},
+		{"B", "a", false},
+		{"a", "A", false},
+		{"A", "a", true},
+		{"unit", "unit_1", true},
+		{"same", "same", false},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortDocuments(t *testing.T) {
+	documents := []models.DocumentInfo{
+		{RelativePath: "P/Z/B10/bl.jpg"},
+		{RelativePath: "P/Z/B2/units/up_A10.jpg"},
+		{RelativePath: "P/Z/B2/units/up_A9.jpg"},
+		{RelativePath: "P/Z/B2/bl.jpg"},
+	}
+	sortDocuments(documents)
+
+	var got []string
+	for _, doc := range documents {
+		got = append(got, doc.RelativePath)
+	}
+	want := []string{"P/Z/B2/bl.jpg", "P/Z/B2/units/up_A9.jpg", "P/Z/B2/units/up_A10.jpg", "P/Z/B10/bl.jpg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortDocuments() order = %q, want %q", got, want)
+	}
+}
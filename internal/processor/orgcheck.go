@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+const attachmentSObject = "Attachments_Uploader__c"
+
+// OrgCompatibility collects everything the target org is missing for an
+// upload to succeed, so misconfiguration is reported before any write.
+type OrgCompatibility struct {
+	Problems []string
+}
+
+func (c *OrgCompatibility) Compatible() bool {
+	return len(c.Problems) == 0
+}
+
+func (c *OrgCompatibility) Summary() string {
+	if c.Compatible() {
+		return "Org is compatible"
+	}
+	return fmt.Sprintf("Org is not compatible:\n- %s", strings.Join(c.Problems, "\n- "))
+}
+
+type requiredField struct {
+	Name string
+	Type string
+}
+
+func requiredAttachmentFields() []requiredField {
+	fields := []requiredField{
+		{Name: "Name"},
+		{Name: "Attachment_Type__c"},
+		{Name: "Content_Type__c"},
+		{Name: "ContentDocumentId__c"},
+		{Name: "Attachment_Url__c"},
+		{Name: "Display_Value__c"},
+		{Name: "Display_Value_Arabic__c"},
+	}
+	for _, entity := range filestructure.Hierarchy {
+		if field := attachmentLookupField(entity.Type); field != "" {
+			fields = append(fields, requiredField{Name: field, Type: "reference"})
+		}
+	}
+	return fields
+}
+
+func CheckOrgCompatibility(accessToken string) *OrgCompatibility {
+	result := &OrgCompatibility{}
+
+	if config.AttachmentMode != config.AttachmentModeLink {
+		fields, err := describeSObject(accessToken, attachmentSObject)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s cannot be described: %v", attachmentSObject, err))
+		} else {
+			result.Problems = append(result.Problems, checkRequiredFields(attachmentSObject, fields, requiredAttachmentFields())...)
+		}
+	}
+
+	if err := checkBulkLookupEndpoint(accessToken); err != nil {
+		result.Problems = append(result.Problems, err.Error())
+	}
+
+	return result
+}
+
+func checkRequiredFields(sobject string, fields []SObjectField, required []requiredField) []string {
+	described := make(map[string]SObjectField, len(fields))
+	for _, field := range fields {
+		described[strings.ToLower(field.Name)] = field
+	}
+
+	var problems []string
+	for _, want := range required {
+		field, ok := described[strings.ToLower(want.Name)]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s.%s is missing", sobject, want.Name))
+		case want.Type != "" && field.Type != want.Type:
+			problems = append(problems, fmt.Sprintf("%s.%s is of type %s, expected %s", sobject, want.Name, field.Type, want.Type))
+		case !field.Createable:
+			problems = append(problems, fmt.Sprintf("%s.%s is not createable", sobject, want.Name))
+		}
+	}
+	return problems
+}
+
+// checkBulkLookupEndpoint posts an empty lookup request to confirm the Apex
+// REST resource is deployed and accessible to the user.
+func checkBulkLookupEndpoint(accessToken string) error {
+	body, err := json.Marshal(models.BulkLookupRequest{Lookups: []models.EntityLookup{}})
+	if err != nil {
+		return fmt.Errorf("error encoding bulk lookup probe: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", config.BulkLookupURL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("error creating bulk lookup probe: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bulk lookup endpoint unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("bulk lookup endpoint not found at %s, is the Apex class deployed?", config.BulkLookupURL)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("bulk lookup endpoint refused access: status %d", resp.StatusCode)
+	case resp.StatusCode >= 300:
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bulk lookup endpoint returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestCheckOrgCompatibility(t *testing.T) {
+	tests := []struct {
+		name         string
+		change       func(fields []map[string]any) []map[string]any
+		describeCode int
+		lookupCode   int
+		want         []string
+	}{
+		{name: "compatible"},
+		{
+			name: "missing field",
+			change: func(fields []map[string]any) []map[string]any {
+				var kept []map[string]any
+				for _, field := range fields {
+					if field["name"] != "ContentDocumentId__c" {
+						kept = append(kept, field)
+					}
+				}
+				return kept
+			},
+			want: []string{"Attachments_Uploader__c.ContentDocumentId__c is missing"},
+		},
+		{
+			name: "wrong lookup type",
+			change: func(fields []map[string]any) []map[string]any {
+				for _, field := range fields {
+					if field["name"] == "Building__c" {
+						field["type"] = "string"
+					}
+				}
+				return fields
+			},
+			want: []string{"Attachments_Uploader__c.Building__c is of type string, expected reference"},
+		},
+		{
+			name: "read-only field",
+			change: func(fields []map[string]any) []map[string]any {
+				for _, field := range fields {
+					if field["name"] == "Attachment_Type__c" {
+						field["createable"] = false
+					}
+				}
+				return fields
+			},
+			want: []string{"Attachments_Uploader__c.Attachment_Type__c is not createable"},
+		},
+		{name: "object not described", describeCode: http.StatusNotFound, want: []string{"Attachments_Uploader__c cannot be described"}},
+		{name: "bulk lookup not deployed", lookupCode: http.StatusNotFound, want: []string{"bulk lookup endpoint not found"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/sobjects/"+attachmentSObject+"/describe"):
+					if tt.describeCode != 0 {
+						w.WriteHeader(tt.describeCode)
+						return
+					}
+					var fields []map[string]any
+					for _, required := range requiredAttachmentFields() {
+						fieldType := required.Type
+						if fieldType == "" {
+							fieldType = "string"
+						}
+						fields = append(fields, map[string]any{"name": required.Name, "type": fieldType, "createable": true})
+					}
+					if tt.change != nil {
+						fields = tt.change(fields)
+					}
+					json.NewEncoder(w).Encode(map[string]any{"fields": fields})
+				case strings.HasSuffix(r.URL.Path, "/bulk-lookup"):
+					if tt.lookupCode != 0 {
+						w.WriteHeader(tt.lookupCode)
+						return
+					}
+					w.Write([]byte(`{}`))
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			previousURL, previousMode := config.BulkLookupURL, config.AttachmentMode
+			config.BulkLookupURL = config.SFInstanceURL + "/services/apexrest/admin/bulk-lookup"
+			config.AttachmentMode = config.AttachmentModeUploader
+			defer func() { config.BulkLookupURL, config.AttachmentMode = previousURL, previousMode }()
+
+			result := CheckOrgCompatibility("token")
+			if len(result.Problems) != len(tt.want) {
+				t.Fatalf("Problems = %q, want %d matching %q", result.Problems, len(tt.want), tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(result.Problems[i], want) {
+					t.Errorf("Problems[%d] = %q, want %q...", i, result.Problems[i], want)
+				}
+			}
+			if result.Compatible() != (len(tt.want) == 0) {
+				t.Errorf("Compatible() = %v with problems %q", result.Compatible(), result.Problems)
+			}
+		})
+	}
+}
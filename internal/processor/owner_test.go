@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestValidateOwnerID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{id: "005000000000001"},
+		{id: "005000000000001AAA"},
+		{id: "00500000000001", wantErr: true},
+		{id: "001000000000001", wantErr: true},
+		{id: "00500000000000-AAA", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateOwnerID(tt.id); (err != nil) != tt.wantErr {
+			t.Errorf("validateOwnerID(%q) = %v, wantErr %v", tt.id, err, tt.wantErr)
+		}
+	}
+}
+
+// TestRecordOwnerID checks that a configured owner is set on every
+// ContentVersion and attachment record a run creates, and on none otherwise.
+func TestRecordOwnerID(t *testing.T) {
+	for name, owner := range map[string]string{"signed-in user": "", "integration user": "005000000000001AAA"} {
+		t.Run(name, func(t *testing.T) {
+			dir := inTempDir(t)
+			sent := useFakeOrg(t, newFakeOrg())
+
+			previous := config.RecordOwnerID
+			config.RecordOwnerID = owner
+			defer func() { config.RecordOwnerID = previous }()
+
+			documents := writeDocuments(t, dir, 3)
+			logger := logging.GetLogger()
+			checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+			if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := bulkCreateAttachmentUploaders(context.Background(), "token", "test", documents, nil, nil, nil, checkpoint, logger); err != nil {
+				t.Fatal(err)
+			}
+
+			checked := make(map[string]int)
+			for _, sub := range sent() {
+				if sub.SObject != "ContentVersion" && sub.SObject != attachmentSObject {
+					continue
+				}
+				checked[sub.SObject]++
+				got, ok := sub.Body["OwnerId"]
+				if owner == "" && ok {
+					t.Errorf("%s has OwnerId %v without an owner configured", sub.SObject, got)
+				}
+				if owner != "" && got != owner {
+					t.Errorf("%s OwnerId = %v, want %s", sub.SObject, got, owner)
+				}
+			}
+			if checked["ContentVersion"] != len(documents) || checked[attachmentSObject] != len(documents) {
+				t.Errorf("checked %v records, want %d of each", checked, len(documents))
+			}
+		})
+	}
+}
+
+func TestRecordOwnerIDInvalid(t *testing.T) {
+	dir := inTempDir(t)
+	sent := useFakeOrg(t, newFakeOrg())
+
+	previous := config.RecordOwnerID
+	config.RecordOwnerID = "001000000000001"
+	defer func() { config.RecordOwnerID = previous }()
+
+	documents := writeDocuments(t, dir, 1)
+	documents[0].ContentDocumentId = "069000000000001"
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	err := bulkCreateAttachmentUploaders(context.Background(), "token", "test", documents, nil, nil, nil, checkpoint, logger)
+	if err == nil || !strings.Contains(err.Error(), `invalid owner ID "001000000000001"`) {
+		t.Fatalf("bulkCreateAttachmentUploaders() = %v, want an invalid owner error", err)
+	}
+	if n := len(sent()); n != 0 {
+		t.Errorf("%d subrequests sent with an invalid owner", n)
+	}
+}
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// parallelMap calls fn for every item with at most concurrency calls in
+// flight and returns the results in item order. After the first error, or
+// once ctx is cancelled, no new calls start; the calls in flight finish and
+// all their errors are returned joined. Items that were not attempted have
+// zero results.
+func parallelMap[T, R any](ctx context.Context, items []T, concurrency int, fn func(context.Context, T) (R, error)) ([]R, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// stop only holds back new calls. The calls in flight keep ctx, so an
+	// all-or-none batch is not cut off with its outcome unknown.
+	stop, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		if stop.Err() == nil {
+			select {
+			case <-stop.Done():
+			case slots <- struct{}{}:
+			}
+		}
+		if stop.Err() != nil {
+			wg.Wait()
+			if err := ctx.Err(); err != nil {
+				return results, errors.Join(append(errs, err)...)
+			}
+			return results, errors.Join(errs...)
+		}
+
+		wg.Add(1)
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = fn(ctx, item)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, item)
+	}
+
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapKeepsItemOrder(t *testing.T) {
+	items := []int{5, 1, 4, 2, 3}
+	results, err := parallelMap(context.Background(), items, 3, func(ctx context.Context, item int) (string, error) {
+		// Later items finish first.
+		time.Sleep(time.Duration(item) * time.Millisecond)
+		return fmt.Sprint(item * 10), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range items {
+		if want := fmt.Sprint(item * 10); results[i] != want {
+			t.Errorf("results[%d] = %s, want %s", i, results[i], want)
+		}
+	}
+}
+
+func TestParallelMapLimitsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	_, err := parallelMap(context.Background(), make([]int, 20), 4, func(ctx context.Context, _ int) (int, error) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		inFlight.Add(-1)
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 4 {
+		t.Errorf("%d calls in flight, limit is 4", peak.Load())
+	}
+}
+
+func TestParallelMapStopsAfterFirstError(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	var started atomic.Int32
+	release := make(chan struct{})
+
+	_, err := parallelMap(context.Background(), make([]int, 10), 2, func(ctx context.Context, _ int) (int, error) {
+		switch started.Add(1) {
+		case 1:
+			<-release
+			return 0, errSecond
+		case 2:
+			defer close(release)
+			return 0, errFirst
+		}
+		return 0, nil
+	})
+
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("error = %v, want both in-flight errors joined", err)
+	}
+	if n := started.Load(); n != 2 {
+		t.Errorf("%d calls started, want none after the first error", n)
+	}
+}
+
+func TestParallelMapKeepsInFlightContextAfterError(t *testing.T) {
+	release := make(chan struct{})
+	var inFlightErr atomic.Value
+	var started atomic.Int32
+
+	parallelMap(context.Background(), make([]int, 2), 2, func(ctx context.Context, _ int) (int, error) {
+		if started.Add(1) == 1 {
+			<-release
+			if ctx.Err() != nil {
+				inFlightErr.Store(ctx.Err())
+			}
+			return 0, nil
+		}
+		defer close(release)
+		return 0, errors.New("failed")
+	})
+
+	if err := inFlightErr.Load(); err != nil {
+		t.Errorf("in-flight call was cancelled: %v", err)
+	}
+}
+
+func TestParallelMapCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+
+	_, err := parallelMap(ctx, make([]int, 10), 1, func(ctx context.Context, _ int) (int, error) {
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		return 0, nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("%d calls made, want 3", n)
+	}
+}
@@ -2,13 +2,39 @@ package processor
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
 	"github.com/ORAITApps/document-uploader/internal/models"
 )
 
+// ParseFile parses a standalone file using the flat naming convention. The
+// absolute path is kept as RelativePath so it resolves without a base directory.
+func ParseFile(path string) (*models.DocumentInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving path %s: %v", path, err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %v", path, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("expected a file but got a directory: %s", path)
+	}
+
+	doc, err := ParseFileName(filepath.Base(absPath))
+	if err != nil {
+		return nil, err
+	}
+	doc.RelativePath = absPath
+	return doc, nil
+}
+
 func ParseFileName(filename string) (*models.DocumentInfo, error) {
 	parts := strings.Split(filename, "_")
 	if len(parts) < 3 {
@@ -47,63 +73,35 @@ func parseDocumentType(prefix string, info *models.DocumentInfo) error {
 		info.DocumentType = docType
 		return nil
 	}
+	if config.LenientDocumentTypes {
+		info.DocumentType = config.DocTypeGeneric
+		info.Warnings = append(info.Warnings, fmt.Sprintf("unknown document type prefix: %s, uploading as %s", prefix, config.DocTypeGeneric))
+		return nil
+	}
 	return fmt.Errorf("unknown document type prefix: %s", prefix)
 }
 
+// parseEntityType reads "<code>_<project>_..._<name>" where the code selects
+// a hierarchy level and one name follows for each level down to it.
 func parseEntityType(parts []string, info *models.DocumentInfo) error {
-	entityType := parts[0]
+	level, ok := filestructure.LevelForFileCode(parts[0])
+	if !ok {
+		return fmt.Errorf("unknown entity type identifier: %s", parts[0])
+	}
+
+	chain := filestructure.Ancestry(level.Type)
 	remaining := parts[1:]
+	if len(remaining) != len(chain) {
+		return fmt.Errorf("invalid %s filename format: expected %d parts, got %d",
+			strings.ToLower(level.Label), len(chain), len(remaining))
+	}
 
-	switch entityType {
-	case "u": // Unit
-		if len(remaining) != 5 {
-			return fmt.Errorf("invalid unit filename format: expected 5 parts, got %d", len(remaining))
-		}
-		info.EntityType = "UNIT"
-		info.NamePath["project"] = remaining[0]
-		info.NamePath["phase"] = remaining[1]
-		info.NamePath["building"] = remaining[3]
-		info.NamePath["unit"] = strings.TrimSuffix(remaining[4], filepath.Ext(remaining[4]))
-
-	case "p": // Phase
-		if len(remaining) != 2 {
-			return fmt.Errorf("invalid phase filename format: expected 2 parts, got %d", len(remaining))
-		}
-		info.EntityType = "PHASE"
-		info.NamePath["project"] = remaining[0]
-		info.NamePath["phase"] = strings.TrimSuffix(remaining[1], filepath.Ext(remaining[1]))
-
-	case "z": // Zone
-		if len(remaining) != 3 {
-			return fmt.Errorf("invalid zone filename format: expected 3 parts, got %d", len(remaining))
-		}
-		info.EntityType = "ZONE"
-		info.NamePath["project"] = remaining[0]
-		info.NamePath["phase"] = remaining[1]
-		info.NamePath["zone"] = strings.TrimSuffix(remaining[2], filepath.Ext(remaining[2]))
-
-	case "b": // Building
-		if len(remaining) != 4 {
-			return fmt.Errorf("invalid building filename format: expected 4 parts, got %d", len(remaining))
-		}
-		info.EntityType = "BUILDING"
-		info.NamePath["project"] = remaining[0]
-		info.NamePath["phase"] = remaining[1]
-		info.NamePath["zone"] = remaining[2]
-		info.NamePath["building"] = strings.TrimSuffix(remaining[3], filepath.Ext(remaining[3]))
-
-	case "dt": // Design Type
-		if len(remaining) != 3 {
-			return fmt.Errorf("invalid design type filename format: expected 3 parts, got %d", len(remaining))
-		}
-		info.EntityType = "DESIGN_TYPE"
-		info.NamePath["project"] = remaining[0]
-		info.NamePath["phase"] = remaining[1]
-		info.NamePath["designType"] = strings.TrimSuffix(remaining[2], filepath.Ext(remaining[2]))
-
-	default:
-		return fmt.Errorf("unknown entity type identifier: %s", entityType)
+	info.EntityType = level.Type
+	for i, ancestor := range chain {
+		info.NamePath[ancestor.NameKey] = remaining[i]
 	}
+	last := remaining[len(remaining)-1]
+	info.NamePath[level.NameKey] = strings.TrimSuffix(last, filepath.Ext(last))
 
 	return nil
 }
@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"up_u_Tower_P1_Z1_B1_A101.jpg", "bl_b_Tower_P1_Z1_B1.jpg", "up_u_Tower_B1_A101.jpg", "xx_b_Tower_P1_Z1_B1.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), jpegContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "bl_b_Tower_P1_Z1_B2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		entityType string
+		namePath   map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "unit file",
+			file:       "up_u_Tower_P1_Z1_B1_A101.jpg",
+			entityType: "UNIT",
+			namePath:   map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1", "unit": "A101"},
+		},
+		{
+			name:       "building file",
+			file:       "bl_b_Tower_P1_Z1_B1.jpg",
+			entityType: "BUILDING",
+			namePath:   map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1"},
+		},
+		{name: "missing names", file: "up_u_Tower_B1_A101.jpg", wantErr: true},
+		{name: "unknown document type", file: "xx_b_Tower_P1_Z1_B1.jpg", wantErr: true},
+		{name: "folder", file: "bl_b_Tower_P1_Z1_B2", wantErr: true},
+		{name: "missing file", file: "bl_b_Tower_P1_Z1_B3.jpg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			doc, err := ParseFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if doc.EntityType != tt.entityType {
+				t.Errorf("EntityType = %s, want %s", doc.EntityType, tt.entityType)
+			}
+			if !reflect.DeepEqual(doc.NamePath, tt.namePath) {
+				t.Errorf("NamePath = %v, want %v", doc.NamePath, tt.namePath)
+			}
+			if doc.RelativePath != path {
+				t.Errorf("RelativePath = %q, want the absolute path %q", doc.RelativePath, path)
+			}
+		})
+	}
+}
+
+func TestParseDocumentTypeLenient(t *testing.T) {
+	tests := []struct {
+		name     string
+		lenient  bool
+		prefix   string
+		want     string
+		wantErr  bool
+		warnings int
+	}{
+		{name: "known prefix", prefix: "fp", want: config.DocTypeFloorPlan},
+		{name: "unknown prefix strict", prefix: "xx", wantErr: true},
+		{name: "unknown prefix lenient", lenient: true, prefix: "xx", want: config.DocTypeGeneric, warnings: 1},
+		{name: "known prefix lenient", lenient: true, prefix: "g", want: config.DocTypeGallery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.LenientDocumentTypes
+			config.LenientDocumentTypes = tt.lenient
+			defer func() { config.LenientDocumentTypes = previous }()
+
+			var info models.DocumentInfo
+			err := parseDocumentType(tt.prefix, &info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDocumentType(%q) error = %v, wantErr %v", tt.prefix, err, tt.wantErr)
+			}
+			if info.DocumentType != tt.want {
+				t.Errorf("DocumentType = %q, want %q", info.DocumentType, tt.want)
+			}
+			if len(info.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %q, want %d", info.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+// TestCollectFiles checks that selected files are read from where they are,
+// without a documents folder, and fail together on the first bad name.
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	unit := filepath.Join(dir, "up_u_Tower_P1_Z1_B1_A101.jpg")
+	building := filepath.Join(dir, "bl_b_Tower_P1_Z1_B1.pdf")
+	badName := filepath.Join(dir, "plan.jpg")
+	for _, path := range []string{unit, building, badName} {
+		if err := os.WriteFile(path, jpegContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	documents, err := collectFiles([]string{unit, building}, logging.GetLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("collected %d documents, want 2", len(documents))
+	}
+	for _, doc := range documents {
+		if doc.ContentType != config.ContentTypeImage {
+			t.Errorf("%s ContentType = %q, want %q", doc.FilePath, doc.ContentType, config.ContentTypeImage)
+		}
+	}
+	if len(documents[1].Warnings) != 1 {
+		t.Errorf("%s warnings = %q, want an extension mismatch", documents[1].FilePath, documents[1].Warnings)
+	}
+
+	if _, err := collectFiles([]string{unit, badName}, logging.GetLogger()); err == nil {
+		t.Error("collectFiles() accepted a file without the flat naming convention")
+	}
+}
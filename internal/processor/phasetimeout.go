@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PhaseTimeoutError is returned when a phase of a run takes longer than its
+// configured timeout. The requests in flight are cancelled, so a stuck phase
+// ends the run instead of freezing it.
+type PhaseTimeoutError struct {
+	Phase   string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase timed out after %s: %v", e.Phase, e.Timeout, e.Err)
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// withPhaseTimeout runs one phase under its own deadline. A timeout of 0
+// leaves the phase unbounded, limited only by the run's context.
+func withPhaseTimeout(ctx context.Context, phase string, timeout time.Duration, run func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return run(ctx)
+	}
+
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := run(phaseCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+		return &PhaseTimeoutError{Phase: phase, Timeout: timeout, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithPhaseTimeout(t *testing.T) {
+	errFailed := errors.New("failed")
+	waitForCancel := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		cancelRun   bool
+		run         func(ctx context.Context) error
+		wantTimeout bool
+		wantErr     error
+	}{
+		{name: "finishes in time", timeout: time.Second, run: func(context.Context) error { return nil }},
+		{name: "fails in time", timeout: time.Second, run: func(context.Context) error { return errFailed }, wantErr: errFailed},
+		{name: "times out", timeout: 10 * time.Millisecond, run: waitForCancel, wantTimeout: true, wantErr: context.DeadlineExceeded},
+		{name: "no timeout", run: func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				return errors.New("unexpected deadline")
+			}
+			return nil
+		}},
+		{name: "run cancelled", timeout: time.Second, cancelRun: true, run: waitForCancel, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelRun {
+				cancel()
+			}
+
+			err := withPhaseTimeout(ctx, "upload", tt.timeout, tt.run)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("withPhaseTimeout() = %v, want %v", err, tt.wantErr)
+			}
+			var timeoutErr *PhaseTimeoutError
+			if errors.As(err, &timeoutErr) != tt.wantTimeout {
+				t.Fatalf("withPhaseTimeout() = %v, want a PhaseTimeoutError %v", err, tt.wantTimeout)
+			}
+			if tt.wantTimeout && (timeoutErr.Phase != "upload" || timeoutErr.Timeout != tt.timeout) {
+				t.Errorf("PhaseTimeoutError = %+v", timeoutErr)
+			}
+		})
+	}
+}
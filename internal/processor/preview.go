@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+const previewRefPrefix = "previewRef"
+
+// buildPreviewRequest returns a ContentVersion subrequest for a downsized copy
+// of the image, or nil when the image is already small enough or cannot be
+// decoded. The preview shares every field with the original except its data
+// and a distinguishing title.
+func buildPreviewRequest(referenceId string, doc models.DocumentInfo, fileBytes []byte, originalBody map[string]any, logger *logging.Logger) map[string]any {
+	previewBytes, ext, err := generatePreview(fileBytes, config.PreviewMaxDimension)
+	if err != nil {
+		logger.Debug("No preview for %s: %v", doc.FilePath, err)
+		return nil
+	}
+	if previewBytes == nil {
+		return nil
+	}
+
+	name := contentTitle(doc)
+	previewName := strings.TrimSuffix(name, filepath.Ext(name)) + " (preview)" + ext
+
+	body := make(map[string]any, len(originalBody))
+	for key, value := range originalBody {
+		body[key] = value
+	}
+	// The preview is a separate file, so it must not claim the original's
+	// external ID.
+	delete(body, config.ContentVersionExternalIDField)
+	body["Title"] = previewName
+	body["PathOnClient"] = previewName
+	body["VersionData"] = base64.StdEncoding.EncodeToString(previewBytes)
+
+	logger.Debug("Prepared %d byte preview for %s", len(previewBytes), doc.FilePath)
+
+	return map[string]any{
+		"method":      "POST",
+		"url":         config.DataPath("/sobjects/ContentVersion"),
+		"referenceId": referenceId,
+		"body":        body,
+	}
+}
+
+func generatePreview(fileBytes []byte, maxDimension int) ([]byte, string, error) {
+	src, format, err := image.Decode(bytes.NewReader(fileBytes))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return nil, "", nil
+	}
+
+	dstWidth, dstHeight := maxDimension, height*maxDimension/width
+	if height > width {
+		dstWidth, dstHeight = width*maxDimension/height, maxDimension
+	}
+	dstWidth, dstHeight = max(dstWidth, 1), max(dstHeight, 1)
+
+	dst := scaleImage(src, dstWidth, dstHeight)
+
+	var buf bytes.Buffer
+	if format == "png" || format == "gif" {
+		err = png.Encode(&buf, dst)
+		return buf.Bytes(), ".png", err
+	}
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), ".jpg", err
+}
+
+// scaleImage downsizes by averaging the source pixels covered by each
+// destination pixel.
+func scaleImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func writeImage(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPreviewUploadedWithOriginal(t *testing.T) {
+	dir := inTempDir(t)
+	sent := useFakeOrg(t, newFakeOrg())
+
+	previous := config.PreviewMaxDimension
+	config.PreviewMaxDimension = 16
+	defer func() { config.PreviewMaxDimension = previous }()
+
+	documents := writeDocuments(t, dir, 3)
+	writeImage(t, filepath.Join(dir, documents[0].RelativePath), 64, 32)
+	writeImage(t, filepath.Join(dir, documents[1].RelativePath), 8, 8)
+	documents[0].ContentType = config.ContentTypeImage
+	documents[1].ContentType = config.ContentTypeImage
+	documents[2].ContentType = config.ContentTypePDF
+
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := make(map[string]map[string]any)
+	for _, sub := range sent() {
+		if sub.SObject == "ContentVersion" {
+			versions[sub.Body["Title"].(string)] = sub.Body
+		}
+	}
+	if len(versions) != 4 {
+		t.Fatalf("%d ContentVersions created, want 3 originals and 1 preview", len(versions))
+	}
+
+	large := documents[0]
+	preview, ok := versions["bl_000 (preview).png"]
+	if !ok {
+		t.Fatalf("no preview for %s among %v", large.RelativePath, versions)
+	}
+	if got, want := preview["FirstPublishLocationId"], large.SalesforceIds["building"]; got != want {
+		t.Errorf("preview published to %v, want the building %s", got, want)
+	}
+	if large.SalesforceIds["previewContentVersionId"] == "" || large.SalesforceIds["previewContentDocumentId"] == "" {
+		t.Errorf("%s has no preview IDs: %v", large.RelativePath, large.SalesforceIds)
+	}
+	for _, doc := range documents[1:] {
+		if doc.SalesforceIds["previewContentVersionId"] != "" {
+			t.Errorf("%s has a preview, want none", doc.RelativePath)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// Reconciliation compares the attachment records a run believes it created
+// with what Salesforce returns for the run's files afterwards.
+type Reconciliation struct {
+	RunID   string `json:"runId"`
+	Checked int    `json:"checked"`
+	// Missing lists documents reported as attached whose record cannot be
+	// found.
+	Missing []string `json:"missing,omitempty"`
+	// Unexpected lists documents reported as not attached that have a
+	// record anyway, e.g. from a batch that committed after timing out.
+	Unexpected []string `json:"unexpected,omitempty"`
+	// Duplicated lists documents with more than one record.
+	Duplicated []string `json:"duplicated,omitempty"`
+}
+
+func (r *Reconciliation) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0 && len(r.Duplicated) == 0
+}
+
+// verifyIfEnabled reconciles the run's attachment records when
+// VERIFY_AFTER_UPLOAD is set. Discrepancies are reported, not fixed; a
+// failed check only warns since the upload itself is already done.
+func verifyIfEnabled(accessToken, runID string, documents []models.DocumentInfo, logger *logging.Logger) {
+	if !config.VerifyAfterUpload {
+		return
+	}
+
+	var contentDocumentIds []string
+	for _, doc := range documents {
+		if doc.ContentDocumentId != "" {
+			contentDocumentIds = append(contentDocumentIds, doc.ContentDocumentId)
+		}
+	}
+	if len(contentDocumentIds) == 0 {
+		return
+	}
+
+	found, err := queryAttachmentRecords(accessToken, contentDocumentIds)
+	if err != nil {
+		logger.Warning("Skipping upload verification: %v", err)
+		return
+	}
+
+	reconciliation := reconcileAttachments(runID, documents, found)
+	if path, err := saveReconciliation(reconciliation); err != nil {
+		logger.Warning("Verification report not written: %v", err)
+	} else {
+		logger.Info("Verification report written to %s", path)
+	}
+
+	if reconciliation.Consistent() {
+		logger.Success("Verified %d attachment record(s) in Salesforce", reconciliation.Checked)
+		return
+	}
+	for _, path := range reconciliation.Missing {
+		logger.Error("Verification: no attachment record found for %s", path)
+	}
+	for _, path := range reconciliation.Unexpected {
+		logger.Warning("Verification: %s has an attachment record although the run did not create one", path)
+	}
+	for _, path := range reconciliation.Duplicated {
+		logger.Warning("Verification: %s has more than one attachment record", path)
+	}
+}
+
+// reconcileAttachments matches documents against the attachment record IDs
+// found for each content document, keyed by 15-character ContentDocument ID.
+func reconcileAttachments(runID string, documents []models.DocumentInfo, found map[string][]string) *Reconciliation {
+	reconciliation := &Reconciliation{RunID: runID}
+	for _, doc := range documents {
+		if doc.ContentDocumentId == "" {
+			continue
+		}
+		reconciliation.Checked++
+
+		records := found[salesforceID15(doc.ContentDocumentId)]
+		created := doc.SalesforceIds["attachmentUploaderId"]
+		switch {
+		case created == "" && len(records) > 0:
+			reconciliation.Unexpected = append(reconciliation.Unexpected, doc.RelativePath)
+		case created != "" && !containsID(records, created):
+			reconciliation.Missing = append(reconciliation.Missing, doc.RelativePath)
+		case len(records) > 1:
+			reconciliation.Duplicated = append(reconciliation.Duplicated, doc.RelativePath)
+		}
+	}
+
+	sort.Strings(reconciliation.Missing)
+	sort.Strings(reconciliation.Unexpected)
+	sort.Strings(reconciliation.Duplicated)
+	return reconciliation
+}
+
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if salesforceID15(candidate) == salesforceID15(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryAttachmentRecords returns the IDs of the attachment records pointing
+// at each content document, keyed by 15-character ContentDocument ID.
+func queryAttachmentRecords(accessToken string, contentDocumentIds []string) (map[string][]string, error) {
+	const batchSize = 100
+
+	found := make(map[string][]string)
+	for i := 0; i < len(contentDocumentIds); i += batchSize {
+		end := min(i+batchSize, len(contentDocumentIds))
+		query := fmt.Sprintf("SELECT Id, ContentDocumentId__c FROM %s WHERE ContentDocumentId__c IN ('%s')",
+			attachmentSObject, strings.Join(contentDocumentIds[i:end], "','"))
+
+		next := config.DataURL("/query?q=" + url.QueryEscape(query))
+		for next != "" {
+			req, err := http.NewRequest("GET", next, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error creating %s query: %v", attachmentSObject, err)
+			}
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("%s query failed: %v", attachmentSObject, err)
+			}
+
+			var result struct {
+				Records []struct {
+					Id                string `json:"Id"`
+					ContentDocumentId string `json:"ContentDocumentId__c"`
+				} `json:"records"`
+				NextRecordsUrl string `json:"nextRecordsUrl"`
+			}
+			status := resp.StatusCode
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			resp.Body.Close()
+			if status != http.StatusOK {
+				return nil, fmt.Errorf("%s query failed: status %d", attachmentSObject, status)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error decoding %s query response: %v", attachmentSObject, err)
+			}
+
+			for _, record := range result.Records {
+				key := salesforceID15(record.ContentDocumentId)
+				found[key] = append(found[key], record.Id)
+			}
+
+			next = ""
+			if result.NextRecordsUrl != "" {
+				next = config.SFInstanceURL + result.NextRecordsUrl
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func saveReconciliation(reconciliation *Reconciliation) (string, error) {
+	dir, err := reportsDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(reconciliation, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("verify_%s.json", reconciliation.RunID))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestVerifyAfterUpload(t *testing.T) {
+	inTempDir(t)
+	// Attachment records in the org by ContentDocument ID. Salesforce
+	// returns 18-character IDs, while the run may hold 15-character ones.
+	records := map[string][]string{
+		"069000000000001AAA": {"a01000000000001AAA"},
+		"069000000000003AAA": {"a01000000000003AAA"},
+		"069000000000004AAA": {"a01000000000004AAA", "a01000000000005AAA"},
+	}
+	var queries int
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var result []map[string]string
+		for _, match := range queryIDs.FindAllStringSubmatch(r.URL.Query().Get("q"), -1) {
+			for id, ids := range records {
+				if id[:15] != match[1][:15] {
+					continue
+				}
+				for _, recordID := range ids {
+					result = append(result, map[string]string{"Id": recordID, "ContentDocumentId__c": id})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"records": result})
+	})
+
+	previous := config.VerifyAfterUpload
+	config.VerifyAfterUpload = true
+	defer func() { config.VerifyAfterUpload = previous }()
+
+	document := func(path, contentDocumentID, attachmentID string) models.DocumentInfo {
+		return models.DocumentInfo{
+			RelativePath:      path,
+			ContentDocumentId: contentDocumentID,
+			SalesforceIds:     map[string]string{"attachmentUploaderId": attachmentID},
+		}
+	}
+	documents := []models.DocumentInfo{
+		document("found.jpg", "069000000000001", "a01000000000001"),
+		document("missing.jpg", "069000000000002AAA", "a01000000000002AAA"),
+		document("unexpected.jpg", "069000000000003AAA", ""),
+		document("duplicated.jpg", "069000000000004AAA", "a01000000000004AAA"),
+		document("not-uploaded.jpg", "", ""),
+	}
+	verifyIfEnabled("token", "run1", documents, logging.GetLogger())
+
+	data, err := os.ReadFile(filepathInReports(t, "verify_run1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Reconciliation
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Reconciliation{
+		RunID:      "run1",
+		Checked:    4,
+		Missing:    []string{"missing.jpg"},
+		Unexpected: []string{"unexpected.jpg"},
+		Duplicated: []string{"duplicated.jpg"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reconciliation = %+v, want %+v", got, want)
+	}
+	if queries != 1 {
+		t.Errorf("%d queries sent, want 1", queries)
+	}
+}
+
+func TestReconcileAttachmentsConsistent(t *testing.T) {
+	documents := []models.DocumentInfo{{
+		RelativePath:      "front.jpg",
+		ContentDocumentId: "069000000000001AAA",
+		SalesforceIds:     map[string]string{"attachmentUploaderId": "a01000000000001"},
+	}}
+	found := map[string][]string{"069000000000001": {"a01000000000001AAA"}}
+
+	reconciliation := reconcileAttachments("run1", documents, found)
+	if !reconciliation.Consistent() || reconciliation.Checked != 1 {
+		t.Errorf("reconciliation = %+v, want one consistent document", reconciliation)
+	}
+}
+
+func TestQueryAttachmentRecordsPaginates(t *testing.T) {
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/services/data/next" {
+			json.NewEncoder(w).Encode(map[string]any{"records": []map[string]string{{"Id": "a02", "ContentDocumentId__c": "069000000000001AAA"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"records":        []map[string]string{{"Id": "a01", "ContentDocumentId__c": "069000000000001AAA"}},
+			"nextRecordsUrl": "/services/data/next",
+		})
+	})
+
+	found, err := queryAttachmentRecords("token", []string{"069000000000001AAA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"069000000000001": {"a01", "a02"}}; !reflect.DeepEqual(found, want) {
+		t.Errorf("found = %v, want %v", found, want)
+	}
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/httpclient"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/ORAITApps/document-uploader/internal/salesforce"
+)
+
+// connectionPollInterval is how often a lost connection is checked. It is
+// a variable so tests need not wait for it.
+var connectionPollInterval = 10 * time.Second
+
+var errConnectionLost = errors.New("connection to Salesforce lost")
+
+// reconnector lets a batch that failed because the network went away wait
+// for the connection to come back and be sent again, instead of failing the
+// whole run. A nil *reconnector never waits.
+type reconnector struct {
+	timeout    time.Duration
+	checkpoint func()
+	logger     *logging.Logger
+	reporter   *progress.Reporter
+}
+
+// committedCheck looks up whether a batch whose response was lost was saved
+// anyway. It returns the results to use instead of sending the batch again,
+// or nil when the batch was not saved and must be resent.
+type committedCheck func(ctx context.Context, subrequests []map[string]any) ([]CompositeResult, error)
+
+// isConnectionError reports whether err means Salesforce could not be
+// reached, as opposed to Salesforce rejecting the request.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, httpclient.ErrSalesforceUnavailable) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sendComposite sends a batch like the package-level sendComposite. On a
+// connection error it saves a checkpoint, waits for Salesforce to become
+// reachable and sends the same batch again. Salesforce may have committed
+// the batch before its response was lost, so committed, when given, is
+// asked first and the batch is only resent if it was not saved. Without it
+// such a batch is created twice; the run report and audit log show which
+// records to check.
+func (r *reconnector) sendComposite(ctx context.Context, client *salesforce.Client, subrequests []map[string]any, allOrNone bool, committed committedCheck) ([]CompositeResult, error) {
+	for {
+		results, err := sendComposite(ctx, client, subrequests, allOrNone)
+		// A cancelled or timed out phase is not a lost connection.
+		if r == nil || ctx.Err() != nil || !isConnectionError(err) {
+			return results, err
+		}
+
+		r.logger.Warning("Lost connection to Salesforce: %v", err)
+		if r.checkpoint != nil {
+			r.checkpoint()
+		}
+		if waitErr := r.waitForConnection(ctx); waitErr != nil {
+			return nil, fmt.Errorf("%v (%v)", err, waitErr)
+		}
+		if committed != nil {
+			results, err := committed(ctx, subrequests)
+			if err != nil {
+				return nil, fmt.Errorf("could not check whether the batch sent before the connection was lost was saved: %v", err)
+			}
+			if results != nil {
+				r.logger.Info("Connection restored; the failed batch was saved before the connection was lost and is not sent again")
+				return results, nil
+			}
+		}
+		r.logger.Info("Connection restored, resuming from the failed batch")
+	}
+}
+
+// waitForConnection polls Salesforce until it answers or the timeout passes.
+func (r *reconnector) waitForConnection(ctx context.Context) error {
+	previous := r.reporter.Status("Waiting for connection…")
+	deadline := time.Now().Add(r.timeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(connectionPollInterval):
+		}
+
+		err := pingSalesforce(ctx)
+		if err == nil {
+			r.reporter.Status(previous)
+			return nil
+		}
+		r.logger.Debug("Salesforce still unreachable: %v", err)
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no connection after waiting %s", r.timeout)
+		}
+	}
+}
+
+// pingSalesforce requests the unauthenticated API version list, which is
+// cheap and does not count against the org's API limits.
+func pingSalesforce(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", config.SFInstanceURL+"/services/data/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
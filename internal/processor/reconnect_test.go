@@ -0,0 +1,248 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/ORAITApps/document-uploader/internal/salesforce"
+)
+
+const testExternalIDField = "Upload_Key__c"
+
+// useExternalIDField configures the ContentVersion external ID field and a
+// short connection poll for one test.
+func useExternalIDField(t *testing.T) {
+	t.Helper()
+	previousField, previousInterval := config.ContentVersionExternalIDField, connectionPollInterval
+	config.ContentVersionExternalIDField, connectionPollInterval = testExternalIDField, time.Millisecond
+	t.Cleanup(func() {
+		config.ContentVersionExternalIDField, connectionPollInterval = previousField, previousInterval
+	})
+}
+
+// lostResponseOrg drops the connection of the first composite request and
+// answers external ID queries with the versions it has saved.
+type lostResponseOrg struct {
+	composite atomic.Int32
+	// saved is whether the first composite request was committed before its
+	// connection dropped.
+	saved bool
+}
+
+func (o *lostResponseOrg) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite"):
+		var request struct {
+			CompositeRequest []struct {
+				ReferenceID string `json:"referenceId"`
+			} `json:"compositeRequest"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if o.composite.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		var results []map[string]any
+		for i, subrequest := range request.CompositeRequest {
+			results = append(results, map[string]any{
+				"referenceId":    subrequest.ReferenceID,
+				"httpStatusCode": http.StatusCreated,
+				"body":           map[string]any{"id": fmt.Sprintf("068resent%06d", i), "success": true},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"compositeResponse": results})
+
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/query"):
+		var records []map[string]any
+		if o.saved {
+			for i, match := range queryIDs.FindAllStringSubmatch(r.URL.Query().Get("q"), -1) {
+				records = append(records, map[string]any{
+					"Id":                fmt.Sprintf("068saved%07d", i),
+					"ContentDocumentId": fmt.Sprintf("069saved%07d", i),
+					testExternalIDField: match[1],
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"records": records})
+
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/services/data/"):
+		w.Write([]byte(`[]`))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestReconnectorChecksLostBatchBeforeResending(t *testing.T) {
+	tests := []struct {
+		name      string
+		saved     bool
+		wantSends int32
+		wantIDs   []string
+	}{
+		{"saved before the connection dropped", true, 1, []string{"068saved0000000", "068saved0000001"}},
+		{"not saved", false, 2, []string{"068resent000000", "068resent000001"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useExternalIDField(t)
+			org := &lostResponseOrg{saved: tt.saved}
+			useTestServer(t, org.ServeHTTP)
+
+			logger := logging.GetLogger()
+			reconnect := &reconnector{timeout: time.Second, logger: logger}
+			subrequests := []map[string]any{
+				{"method": "POST", "url": "/ContentVersion", "referenceId": "ref0", "body": map[string]any{testExternalIDField: "key0"}},
+				{"method": "POST", "url": "/ContentVersion", "referenceId": "ref1", "body": map[string]any{testExternalIDField: "key1"}},
+			}
+
+			client := salesforce.NewClient("token", httpClient)
+			results, err := reconnect.sendComposite(context.Background(), client, subrequests, true, committedContentVersions("token", logger))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := org.composite.Load(); n != tt.wantSends {
+				t.Errorf("batch sent %d times, want %d", n, tt.wantSends)
+			}
+			if err := compositeError(results); err != nil {
+				t.Fatalf("results report a failure: %v", err)
+			}
+			if len(results) != len(tt.wantIDs) {
+				t.Fatalf("%d results, want %d", len(results), len(tt.wantIDs))
+			}
+			for i, result := range results {
+				if result.ReferenceID != fmt.Sprintf("ref%d", i) || result.ID() != tt.wantIDs[i] {
+					t.Errorf("result %d = %s %s, want ref%d %s", i, result.ReferenceID, result.ID(), i, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCommittedContentVersionsWithoutExternalIDField(t *testing.T) {
+	previous := config.ContentVersionExternalIDField
+	config.ContentVersionExternalIDField = ""
+	t.Cleanup(func() { config.ContentVersionExternalIDField = previous })
+
+	if committedContentVersions("token", logging.GetLogger()) != nil {
+		t.Fatal("expected no check without an external ID field")
+	}
+}
+
+// flakyOrg drops the connection of the first composite request, then stays
+// unreachable for a number of connection checks before it recovers.
+type flakyOrg struct {
+	composite atomic.Int32
+	pings     atomic.Int32
+	downPings int32
+	// reject makes every composite request fail with a data error instead.
+	reject bool
+}
+
+func (o *flakyOrg) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite"):
+		if o.reject {
+			o.composite.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`[{"errorCode":"INVALID_FIELD","message":"No such column"}]`))
+			return
+		}
+		if o.composite.Add(1) == 1 {
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"compositeResponse": []map[string]any{
+			{"referenceId": "ref0", "httpStatusCode": http.StatusCreated, "body": map[string]any{"id": "068000000000001", "success": true}},
+		}})
+
+	case strings.HasSuffix(r.URL.Path, "/services/data/"):
+		if o.pings.Add(1) <= o.downPings {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestReconnectorResumesAfterConnectionDrop(t *testing.T) {
+	tests := []struct {
+		name        string
+		org         *flakyOrg
+		timeout     time.Duration
+		wantSends   int32
+		wantErr     bool
+		checkpoints int
+	}{
+		{name: "connection recovers", org: &flakyOrg{downPings: 3}, timeout: time.Minute, wantSends: 2, checkpoints: 1},
+		{name: "data error is not retried", org: &flakyOrg{reject: true}, timeout: time.Minute, wantSends: 1, wantErr: true},
+		{name: "connection stays down", org: &flakyOrg{downPings: 1 << 30}, timeout: 20 * time.Millisecond, wantSends: 1, wantErr: true, checkpoints: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := connectionPollInterval
+			connectionPollInterval = time.Millisecond
+			defer func() { connectionPollInterval = previous }()
+			useTestServer(t, tt.org.ServeHTTP)
+
+			events := make(chan progress.Event, 16)
+			reporter := progress.NewReporter(events)
+			reporter.Phase(progress.PhaseUpload, "Uploading content...")
+			<-events
+
+			checkpoints := 0
+			reconnect := &reconnector{
+				timeout:    tt.timeout,
+				checkpoint: func() { checkpoints++ },
+				logger:     logging.GetLogger(),
+				reporter:   reporter,
+			}
+			subrequests := []map[string]any{{"method": "POST", "url": "/ContentVersion", "referenceId": "ref0", "body": map[string]any{}}}
+
+			client := salesforce.NewClient("token", httpClient)
+			results, err := reconnect.sendComposite(context.Background(), client, subrequests, true, nil)
+			if err == nil {
+				err = compositeError(results)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendComposite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n := tt.org.composite.Load(); n != tt.wantSends {
+				t.Errorf("batch sent %d times, want %d", n, tt.wantSends)
+			}
+			if checkpoints != tt.checkpoints {
+				t.Errorf("%d checkpoints saved, want %d", checkpoints, tt.checkpoints)
+			}
+
+			close(events)
+			var messages []string
+			for event := range events {
+				messages = append(messages, event.Message)
+			}
+			if tt.checkpoints > 0 && (len(messages) == 0 || messages[0] != "Waiting for connection…") {
+				t.Errorf("status messages = %q, want the wait shown", messages)
+			}
+			if !tt.wantErr && messages[len(messages)-1] != "Uploading content..." {
+				t.Errorf("status messages = %q, want the upload status restored", messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// recordIDKey is where a record folder's resolved ID is kept in
+// SalesforceIds.
+const recordIDKey = "record"
+
+// resolveRecordEntities finds the records named by record folders, such as
+// @Account/AccountNumber=A-1001, with one query per sObject and field. These
+// records are outside the project hierarchy, so the bulk lookup endpoint
+// cannot resolve them.
+func resolveRecordEntities(accessToken string, documents []models.DocumentInfo, logger *logging.Logger) error {
+	type target struct {
+		sobject string
+		field   string
+	}
+	values := make(map[target]map[string]bool)
+	for _, doc := range documents {
+		if doc.EntityType != filestructure.RecordEntityType {
+			continue
+		}
+		key := target{doc.NamePath["sobject"], doc.NamePath["field"]}
+		if values[key] == nil {
+			values[key] = make(map[string]bool)
+		}
+		values[key][doc.NamePath["value"]] = true
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for key, set := range values {
+		ids, err := queryRecordIds(accessToken, key.sobject, key.field, sortedKeys(set))
+		if err != nil {
+			return err
+		}
+		logger.Info("Resolved %d of %d %s record(s) by %s", len(ids), len(set), key.sobject, key.field)
+
+		for i := range documents {
+			doc := &documents[i]
+			if doc.EntityType != filestructure.RecordEntityType ||
+				doc.NamePath["sobject"] != key.sobject || doc.NamePath["field"] != key.field {
+				continue
+			}
+			id, ok := ids[strings.ToLower(doc.NamePath["value"])]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("%s (%s)", generateFullPath(*doc), doc.RelativePath))
+				continue
+			}
+			doc.SalesforceIds[recordIDKey] = id
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("the following records were not found:\n- %s", strings.Join(missing, "\n- "))
+	}
+	return nil
+}
+
+// queryRecordIds returns the IDs of the records whose field matches one of
+// values, keyed by the lower-cased value since SOQL compares text without
+// regard to case. A value matching several records is an error, since
+// the file could not be attached unambiguously.
+func queryRecordIds(accessToken, sobject, field string, values []string) (map[string]string, error) {
+	const batchSize = 100
+
+	ids := make(map[string]string)
+	for i := 0; i < len(values); i += batchSize {
+		end := min(i+batchSize, len(values))
+		quoted := make([]string, 0, end-i)
+		for _, value := range values[i:end] {
+			quoted = append(quoted, "'"+escapeSOQL(value)+"'")
+		}
+		query := fmt.Sprintf("SELECT Id, %s FROM %s WHERE %s IN (%s)", field, sobject, field, strings.Join(quoted, ","))
+
+		req, err := http.NewRequest("GET", config.DataURL("/query?q="+url.QueryEscape(query)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s query: %v", sobject, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s query failed: %v", sobject, err)
+		}
+
+		var result struct {
+			Records []map[string]any `json:"records"`
+		}
+		status := resp.StatusCode
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("%s query failed: status %d", sobject, status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s query response: %v", sobject, err)
+		}
+
+		for _, record := range result.Records {
+			id, _ := record["Id"].(string)
+			value := strings.ToLower(fmt.Sprint(fieldValue(record, field)))
+			if existing, ok := ids[value]; ok && existing != id {
+				return nil, fmt.Errorf("%s %s=%v matches more than one record", sobject, field, fieldValue(record, field))
+			}
+			ids[value] = id
+		}
+	}
+
+	return ids, nil
+}
+
+// fieldValue reads a field from a query result, whose keys use the field's
+// own capitalization rather than the one written in the folder name.
+func fieldValue(record map[string]any, field string) any {
+	for name, value := range record {
+		if strings.EqualFold(name, field) {
+			return value
+		}
+	}
+	return nil
+}
+
+// escapeSOQL quotes a value for use inside a SOQL string literal.
+func escapeSOQL(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
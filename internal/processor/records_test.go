@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func recordDocument(path, sobject, field, value string) models.DocumentInfo {
+	return models.DocumentInfo{
+		RelativePath:  path,
+		FilePath:      path,
+		EntityType:    filestructure.RecordEntityType,
+		NamePath:      map[string]string{"sobject": sobject, "field": field, "value": value},
+		SalesforceIds: make(map[string]string),
+	}
+}
+
+// useRecordQueries answers record queries from records, keyed by sObject,
+// and returns a function listing the queries received.
+func useRecordQueries(t *testing.T, records map[string][]map[string]any) func() []string {
+	t.Helper()
+	var mutex sync.Mutex
+	var queries []string
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		mutex.Lock()
+		queries = append(queries, query)
+		mutex.Unlock()
+		sobject := strings.Fields(query[strings.Index(query, " FROM ")+len(" FROM "):])[0]
+		json.NewEncoder(w).Encode(map[string]any{"records": records[sobject]})
+	})
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestResolveRecordEntities(t *testing.T) {
+	queries := useRecordQueries(t, map[string][]map[string]any{
+		"Account": {
+			{"Id": "001000000000001", "AccountNumber": "A-1001"},
+			{"Id": "001000000000002", "AccountNumber": "O'Brien"},
+		},
+		"Contact": {{"Id": "003000000000001", "Email": "ada@example.com"}},
+	})
+
+	documents := []models.DocumentInfo{
+		recordDocument("contract.pdf", "Account", "accountNumber", "a-1001"),
+		recordDocument("terms.pdf", "Account", "accountNumber", "O'Brien"),
+		recordDocument("id.jpg", "Contact", "Email", "ada@example.com"),
+		{RelativePath: "bl_front.jpg", EntityType: "BUILDING", SalesforceIds: make(map[string]string)},
+	}
+	if err := resolveRecordEntities("token", documents, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"001000000000001", "001000000000002", "003000000000001", ""}
+	for i, doc := range documents {
+		if got := attachmentEntityID(doc); got != want[i] {
+			t.Errorf("%s attaches to %q, want %q", doc.RelativePath, got, want[i])
+		}
+	}
+
+	sent := queries()
+	if len(sent) != 2 {
+		t.Fatalf("queries = %q, want one per sObject and field", sent)
+	}
+	for _, query := range sent {
+		if strings.Contains(query, "FROM Account") && !strings.Contains(query, `'O\'Brien'`) {
+			t.Errorf("query %q does not escape the quote in O'Brien", query)
+		}
+	}
+}
+
+func TestResolveRecordEntitiesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []map[string]any
+		want    string
+	}{
+		{"not found", nil, "the following records were not found:\n- Account AccountNumber=A-1001 (contract.pdf)"},
+		{"ambiguous", []map[string]any{
+			{"Id": "001000000000001", "AccountNumber": "A-1001"},
+			{"Id": "001000000000002", "AccountNumber": "a-1001"},
+		}, "Account AccountNumber=a-1001 matches more than one record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRecordQueries(t, map[string][]map[string]any{"Account": tt.records})
+			documents := []models.DocumentInfo{recordDocument("contract.pdf", "Account", "AccountNumber", "A-1001")}
+
+			err := resolveRecordEntities("token", documents, logging.GetLogger())
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("resolveRecordEntities() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkRecordDocuments(t *testing.T) {
+	dir := inTempDir(t)
+	sent := useFakeOrg(t, newFakeOrg())
+
+	documents := []models.DocumentInfo{recordDocument("contract.pdf", "Account", "AccountNumber", "A-1001")}
+	documents[0].ContentDocumentId = "069000000000001"
+	documents[0].SalesforceIds[recordIDKey] = "001000000000001"
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+	if err := createContentDocumentLinks(context.Background(), "token", documents, nil, checkpoint, logger); err != nil {
+		t.Fatal(err)
+	}
+	subrequests := sent()
+	if len(subrequests) != 1 || subrequests[0].SObject != "ContentDocumentLink" {
+		t.Fatalf("subrequests = %+v, want one ContentDocumentLink", subrequests)
+	}
+	if got := subrequests[0].Body["LinkedEntityId"]; got != "001000000000001" {
+		t.Errorf("LinkedEntityId = %v, want the Account", got)
+	}
+	if documents[0].SalesforceIds["contentDocumentLinkId"] == "" {
+		t.Error("the link ID was not recorded")
+	}
+}
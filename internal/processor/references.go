@@ -0,0 +1,24 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// referenceMap records the document behind each composite subrequest's
+// referenceId, so results are matched by lookup instead of by parsing an
+// index back out of the ID. That keeps working when the requests are built
+// from a filtered subset of the documents.
+type referenceMap map[string]*models.DocumentInfo
+
+// add registers referenceId for doc and returns it. Composite requests fail
+// as a whole on a repeated referenceId, so a collision is reported before
+// anything is sent.
+func (r referenceMap) add(referenceId string, doc *models.DocumentInfo) (string, error) {
+	if _, exists := r[referenceId]; exists {
+		return "", fmt.Errorf("duplicate composite referenceId %s for %s", referenceId, doc.FilePath)
+	}
+	r[referenceId] = doc
+	return referenceId, nil
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestReferenceMapAdd(t *testing.T) {
+	first := &models.DocumentInfo{FilePath: "a.jpg"}
+	second := &models.DocumentInfo{FilePath: "b.jpg"}
+	refs := make(referenceMap)
+
+	if ref, err := refs.add("ref0", first); err != nil || ref != "ref0" {
+		t.Fatalf("add() = %q, %v, want ref0", ref, err)
+	}
+	if _, err := refs.add("ref0", second); err == nil {
+		t.Fatal("add() accepted a duplicate referenceId")
+	}
+	if refs["ref0"] != first {
+		t.Error("a rejected duplicate replaced the first document")
+	}
+}
+
+func TestApplyContentVersionResults(t *testing.T) {
+	// Only some documents are sent, as when a resumed run skips the files
+	// already uploaded, so reference numbers do not match slice positions.
+	documents := []models.DocumentInfo{
+		{FilePath: "uploaded.jpg", SalesforceIds: map[string]string{"contentVersionId": "068000000000000"}},
+		{FilePath: "one.jpg", SalesforceIds: map[string]string{}},
+		{FilePath: "two.jpg", SalesforceIds: map[string]string{}},
+	}
+	refs := make(referenceMap)
+	for _, i := range []int{2, 1} {
+		if _, err := refs.add(fmt.Sprintf("ref%d", i), &documents[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := refs.add(previewRefPrefix+"2", &documents[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []CompositeResult{
+		compositeResult(previewRefPrefix+"2", 201, `{"id":"068000000000003","success":true}`),
+		compositeResult("ref1", 400, `[{"errorCode":"PROCESSING_HALTED","message":"rolled back"}]`),
+		compositeResult("ref2", 201, `{"id":"068000000000002","success":true}`),
+		compositeResult("unknown", 201, `{"id":"068000000000009","success":true}`),
+	}
+	applyContentVersionResults(refs, results, nil, nil, logging.GetLogger())
+
+	tests := []struct {
+		doc         int
+		wantVersion string
+		wantPreview string
+	}{
+		{0, "068000000000000", ""},
+		{1, "", ""},
+		{2, "068000000000002", "068000000000003"},
+	}
+	for _, tt := range tests {
+		ids := documents[tt.doc].SalesforceIds
+		if ids["contentVersionId"] != tt.wantVersion || ids["previewContentVersionId"] != tt.wantPreview {
+			t.Errorf("%s: contentVersionId %q previewContentVersionId %q, want %q %q",
+				documents[tt.doc].FilePath, ids["contentVersionId"], ids["previewContentVersionId"], tt.wantVersion, tt.wantPreview)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+type ParseWarning struct {
+	RelativePath string
+	Message      string
+}
+
+type ParseReport struct {
+	TotalDocuments int
+	Warnings       []ParseWarning
+	// Rejected lists files left out of the run, such as ones whose path
+	// fits more than one entity rule.
+	Rejected []ParseWarning
+}
+
+func buildParseReport(documents []models.DocumentInfo) *ParseReport {
+	report := &ParseReport{TotalDocuments: len(documents)}
+	for _, doc := range documents {
+		for _, warning := range doc.Warnings {
+			report.Warnings = append(report.Warnings, ParseWarning{
+				RelativePath: doc.RelativePath,
+				Message:      warning,
+			})
+		}
+	}
+	return report
+}
+
+// rejectedWarnings lists the rejected documents for the parse report.
+func rejectedWarnings(documents []models.DocumentInfo) []ParseWarning {
+	var rejected []ParseWarning
+	for _, doc := range documents {
+		if doc.Rejected != "" {
+			rejected = append(rejected, ParseWarning{RelativePath: doc.RelativePath, Message: doc.Rejected})
+		}
+	}
+	return rejected
+}
+
+func logParseReport(report *ParseReport, logger *logging.Logger) {
+	logger.Info("Parse report: %d documents, %d warnings, %d rejected",
+		report.TotalDocuments, len(report.Warnings), len(report.Rejected))
+	for _, warning := range report.Warnings {
+		logger.Warning("%s: %s", warning.RelativePath, warning.Message)
+	}
+}
+
+// detectExtensionMismatch returns a warning when the file extension implies a
+// different type than the one detected from the file content.
+func detectExtensionMismatch(fullPath string, detected *mimetype.MIME) string {
+	ext := strings.ToLower(filepath.Ext(fullPath))
+	if ext == "" {
+		return ""
+	}
+
+	expected, _, err := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if err != nil || expected == "" {
+		return ""
+	}
+
+	if detected == nil || detected.Is(expected) {
+		return ""
+	}
+
+	return fmt.Sprintf("file extension %s suggests %s but content was detected as %s",
+		ext, expected, detected.String())
+}
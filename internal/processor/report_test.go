@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+var (
+	jpegContent = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	pdfContent  = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF\n")
+	pngContent  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+)
+
+func TestExtensionMismatchWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		content  []byte
+		warning  string
+	}{
+		{name: "jpeg named pdf", fileName: "bl_plan.pdf", content: jpegContent, warning: "file extension .pdf suggests application/pdf but content was detected as image/jpeg"},
+		{name: "pdf named jpg", fileName: "bl_plan.jpg", content: pdfContent, warning: "file extension .jpg suggests image/jpeg but content was detected as application/pdf"},
+		{name: "png named png", fileName: "bl_plan.png", content: pngContent},
+		{name: "pdf named pdf", fileName: "bl_plan.PDF", content: pdfContent},
+		{name: "no extension", fileName: "bl_plan", content: jpegContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.fileName), tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			documents := []models.DocumentInfo{{FilePath: tt.fileName, RelativePath: tt.fileName}}
+
+			if err := annotateContent(dir, documents, logging.GetLogger()); err != nil {
+				t.Fatal(err)
+			}
+			if documents[0].Rejected != "" {
+				t.Fatalf("Rejected = %q, want the file uploaded", documents[0].Rejected)
+			}
+
+			report := buildParseReport(documents)
+			var messages []string
+			for _, warning := range report.Warnings {
+				messages = append(messages, warning.Message)
+			}
+			if got := strings.Join(messages, "; "); got != tt.warning {
+				t.Errorf("warnings = %q, want %q", got, tt.warning)
+			}
+		})
+	}
+}
+
+func TestAnnotateContentUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bl_front.jpg"), jpegContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "bl_folder.jpg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	documents := []models.DocumentInfo{
+		{RelativePath: "bl_front.jpg"},
+		{RelativePath: "bl_missing.jpg"},
+		{RelativePath: "bl_folder.jpg"},
+	}
+
+	err := annotateContent(dir, documents, logging.GetLogger())
+	if err == nil {
+		t.Fatal("annotateContent() accepted unreadable files")
+	}
+	for _, name := range []string{"bl_missing.jpg", "bl_folder.jpg"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error = %v, want %s reported", err, name)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "2 file(s) could not be read") {
+		t.Errorf("error = %v, want both unreadable files counted", err)
+	}
+	if documents[1].ContentType != "" || documents[2].ContentType != "" {
+		t.Errorf("unreadable files were given content types %q and %q", documents[1].ContentType, documents[2].ContentType)
+	}
+}
+
+// TestSniffContentLargeFile sniffs a sparse file far larger than anything
+// that could be read in the test, so only its header can have been read.
+func TestSniffContentLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bl_plan.pdf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write(pdfContent); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Truncate(1 << 40); err != nil {
+		file.Close()
+		t.Skipf("sparse files unavailable: %v", err)
+	}
+	file.Close()
+
+	detected, err := sniffContent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !detected.Is("application/pdf") {
+		t.Errorf("sniffContent() = %s, want application/pdf", detected)
+	}
+}
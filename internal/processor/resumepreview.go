@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+// resumePreviewListLimit caps how many paths of each kind the summary
+// names; the counts are always complete.
+const resumePreviewListLimit = 10
+
+// ResumePreview compares a run report with the files on disk to show what
+// resuming it will do before anything is sent.
+type ResumePreview struct {
+	RunID string
+	// Retry lists the failed and skipped documents that will be uploaded.
+	Retry []string
+	// Changed lists documents uploaded by the run that were modified since.
+	// Resuming does not upload them again.
+	Changed []string
+	// New lists files that are not in the report and will not be uploaded.
+	New []string
+	// Missing lists documents to retry that are no longer on disk, which
+	// makes the resume fail.
+	Missing []string
+	// Unchanged counts uploaded documents whose size and modification time
+	// still match. Reports written before these were recorded count every
+	// uploaded document here.
+	Unchanged int
+}
+
+// PreviewResume collects the report's source again and sorts every file by
+// what resuming the report will do with it.
+func PreviewResume(reportPath string) (*ResumePreview, error) {
+	report, err := LoadRunReport(reportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	onDisk := make(map[string]os.FileInfo)
+	if report.DocumentsDir == "" {
+		for _, entry := range report.Documents {
+			if info, err := os.Stat(entry.RelativePath); err == nil {
+				onDisk[entry.RelativePath] = info
+			}
+		}
+	} else {
+		documents, err := collectDocuments(report.DocumentsDir, nil, logging.GetLogger())
+		if err != nil {
+			return nil, fmt.Errorf("error collecting documents: %v", err)
+		}
+		for _, doc := range documents {
+			if info, err := os.Stat(filepath.Join(report.DocumentsDir, doc.RelativePath)); err == nil {
+				onDisk[doc.RelativePath] = info
+			}
+		}
+	}
+
+	return report.preview(onDisk), nil
+}
+
+func (r *RunReport) preview(onDisk map[string]os.FileInfo) *ResumePreview {
+	preview := &ResumePreview{RunID: r.RunID}
+
+	reported := make(map[string]bool, len(r.Documents))
+	for _, entry := range r.Documents {
+		reported[entry.RelativePath] = true
+		info, present := onDisk[entry.RelativePath]
+
+		switch {
+		case entry.Status == StatusFailed || entry.Status == StatusSkipped:
+			if present {
+				preview.Retry = append(preview.Retry, entry.RelativePath)
+			} else {
+				preview.Missing = append(preview.Missing, entry.RelativePath)
+			}
+		case present && entry.ModTime != 0 &&
+			(info.Size() != entry.Size || info.ModTime().UnixNano() != entry.ModTime):
+			preview.Changed = append(preview.Changed, entry.RelativePath)
+		default:
+			preview.Unchanged++
+		}
+	}
+
+	for path := range onDisk {
+		if !reported[path] {
+			preview.New = append(preview.New, path)
+		}
+	}
+
+	sort.Strings(preview.Retry)
+	sort.Strings(preview.Changed)
+	sort.Strings(preview.New)
+	sort.Strings(preview.Missing)
+	return preview
+}
+
+func (p *ResumePreview) Summary() string {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Resuming run %s:\n%d file(s) will be uploaded, %d unchanged file(s) are skipped.",
+		p.RunID, len(p.Retry), p.Unchanged)
+	writePathList(&summary, "To upload", p.Retry)
+	writePathList(&summary, "Changed since the run, not uploaded again", p.Changed)
+	writePathList(&summary, "New since the run, not uploaded", p.New)
+	writePathList(&summary, "Missing, the resume will fail", p.Missing)
+	return summary.String()
+}
+
+func writePathList(summary *strings.Builder, title string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Fprintf(summary, "\n\n%s (%d):", title, len(paths))
+	for _, path := range paths[:min(len(paths), resumePreviewListLimit)] {
+		fmt.Fprintf(summary, "\n  %s", path)
+	}
+	if len(paths) > resumePreviewListLimit {
+		fmt.Fprintf(summary, "\n  ... and %d more", len(paths)-resumePreviewListLimit)
+	}
+}
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunReportPreview(t *testing.T) {
+	dir := t.TempDir()
+	onDisk := make(map[string]os.FileInfo)
+	for _, name := range []string{"uploaded.jpg", "changed.jpg", "legacy.jpg", "failed.jpg", "skipped.jpg", "new.jpg"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		onDisk[name] = info
+	}
+	recorded := func(name string) (int64, int64) {
+		return onDisk[name].Size(), onDisk[name].ModTime().UnixNano()
+	}
+
+	uploadedSize, uploadedTime := recorded("uploaded.jpg")
+	changedSize, changedTime := recorded("changed.jpg")
+	report := &RunReport{RunID: "test", Documents: []RunReportEntry{
+		{RelativePath: "uploaded.jpg", Status: StatusUploaded, Size: uploadedSize, ModTime: uploadedTime},
+		{RelativePath: "changed.jpg", Status: StatusUploaded, Size: changedSize + 1, ModTime: changedTime},
+		{RelativePath: "legacy.jpg", Status: StatusUploaded},
+		{RelativePath: "gone.jpg", Status: StatusUploaded, Size: 1, ModTime: 1},
+		{RelativePath: "failed.jpg", Status: StatusFailed},
+		{RelativePath: "skipped.jpg", Status: StatusSkipped},
+		{RelativePath: "missing.jpg", Status: StatusFailed},
+	}}
+
+	preview := report.preview(onDisk)
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"Retry", preview.Retry, []string{"failed.jpg", "skipped.jpg"}},
+		{"Changed", preview.Changed, []string{"changed.jpg"}},
+		{"New", preview.New, []string{"new.jpg"}},
+		{"Missing", preview.Missing, []string{"missing.jpg"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if preview.Unchanged != 3 {
+		t.Errorf("Unchanged = %d, want 3", preview.Unchanged)
+	}
+}
+
+func TestResumePreviewSummaryLimitsLists(t *testing.T) {
+	preview := &ResumePreview{RunID: "test"}
+	for i := 0; i < resumePreviewListLimit+2; i++ {
+		preview.Retry = append(preview.Retry, fmt.Sprintf("file%02d.jpg", i))
+	}
+
+	summary := preview.Summary()
+	if !strings.Contains(summary, fmt.Sprintf("To upload (%d):", len(preview.Retry))) {
+		t.Errorf("summary does not count every file:\n%s", summary)
+	}
+	if !strings.Contains(summary, "... and 2 more") || strings.Contains(summary, preview.Retry[resumePreviewListLimit]) {
+		t.Errorf("summary does not cut the list at %d files:\n%s", resumePreviewListLimit, summary)
+	}
+	if strings.Contains(summary, "Missing") {
+		t.Errorf("summary names an empty list:\n%s", summary)
+	}
+}
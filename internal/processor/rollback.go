@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+func newRunID() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		// The sub-second time still tells apart runs started in the same
+		// second.
+		binary.BigEndian.PutUint32(suffix, uint32(time.Now().Nanosecond()))
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// createdRecords collects the IDs of the ContentVersions and attachment
+// records a run creates. A resumed run starts with the IDs the run before it
+// created, and files found by their external ID carry theirs, so the
+// documents alone do not tell a rollback what is safe to delete. A nil
+// *createdRecords records nothing.
+type createdRecords struct {
+	mutex sync.Mutex
+	ids   map[string]bool
+}
+
+func newCreatedRecords() *createdRecords {
+	return &createdRecords{ids: make(map[string]bool)}
+}
+
+func (c *createdRecords) add(id string) {
+	if c == nil || id == "" {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ids[id] = true
+}
+
+func (c *createdRecords) has(id string) bool {
+	if c == nil || id == "" {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ids[id]
+}
+
+func rollbackIfEnabled(accessToken, runID string, documents []models.DocumentInfo, created *createdRecords, audit *auditLog, logger *logging.Logger, reporter *progress.Reporter) {
+	if !config.RollbackOnFailure {
+		return
+	}
+	reporter.Phase(progress.PhaseRollback, "Rolling back created records...")
+	rollbackRun(accessToken, runID, documents, created, audit, logger)
+}
+
+// rollbackRun deletes the records in created. Deleting the ContentDocument
+// also removes its versions, distributions and links, so only documents and
+// attachment records are deleted explicitly.
+func rollbackRun(accessToken, runID string, documents []models.DocumentInfo, created *createdRecords, audit *auditLog, logger *logging.Logger) {
+	logger.Warning("Rolling back records created by run %s", runID)
+
+	if err := fetchContentDocumentIds(accessToken, documents, logger); err != nil {
+		logger.Error("Could not resolve uploaded files for rollback: %v", err)
+	}
+
+	var attachmentIds, contentDocumentIds []string
+	for _, doc := range documents {
+		if id := doc.SalesforceIds["attachmentUploaderId"]; created.has(id) {
+			attachmentIds = append(attachmentIds, id)
+		}
+		if doc.ContentDocumentId != "" && created.has(doc.SalesforceIds["contentVersionId"]) {
+			contentDocumentIds = append(contentDocumentIds, doc.ContentDocumentId)
+		}
+		if id := doc.SalesforceIds["previewContentDocumentId"]; id != "" && created.has(doc.SalesforceIds["previewContentVersionId"]) {
+			contentDocumentIds = append(contentDocumentIds, id)
+		}
+	}
+
+	deletedAttachments, err := deleteRecords(accessToken, attachmentIds, audit, logger)
+	if err != nil {
+		logger.Error("Failed to delete attachment records: %v", err)
+	}
+
+	deletedDocuments, err := deleteRecords(accessToken, contentDocumentIds, audit, logger)
+	if err != nil {
+		logger.Error("Failed to delete uploaded files: %v", err)
+	}
+
+	logger.Warning("Rollback of run %s deleted %d of %d attachment records and %d of %d files",
+		runID, deletedAttachments, len(attachmentIds), deletedDocuments, len(contentDocumentIds))
+}
+
+func deleteRecords(accessToken string, ids []string, audit *auditLog, logger *logging.Logger) (int, error) {
+	const batchSize = 200
+
+	deleted := 0
+	for i := 0; i < len(ids); i += batchSize {
+		end := min(i+batchSize, len(ids))
+
+		query := url.Values{}
+		query.Set("ids", strings.Join(ids[i:end], ","))
+		query.Set("allOrNone", "false")
+
+		req, err := http.NewRequest("DELETE", config.DataURL("/composite/sobjects?"+query.Encode()), nil)
+		if err != nil {
+			return deleted, fmt.Errorf("error creating delete request: %v", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return deleted, fmt.Errorf("delete request failed: %v", err)
+		}
+
+		var results []struct {
+			Id      string `json:"id"`
+			Success bool   `json:"success"`
+			Errors  []struct {
+				StatusCode string `json:"statusCode"`
+				Message    string `json:"message"`
+			} `json:"errors"`
+		}
+		status := resp.StatusCode
+		err = json.NewDecoder(resp.Body).Decode(&results)
+		resp.Body.Close()
+		if status != http.StatusOK {
+			return deleted, fmt.Errorf("delete request failed: status %d", status)
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("error decoding delete response: %v", err)
+		}
+
+		for _, result := range results {
+			if result.Success {
+				deleted++
+				audit.record("delete", "", result.Id, nil)
+				continue
+			}
+			for _, e := range result.Errors {
+				logger.Error("Could not delete %s: %s - %s", result.Id, e.StatusCode, e.Message)
+			}
+		}
+	}
+
+	return deleted, nil
+}
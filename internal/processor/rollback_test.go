@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestDeleteRecords(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantDeleted int
+		wantErr     bool
+	}{
+		{
+			name:        "partial success",
+			status:      http.StatusOK,
+			body:        `[{"id":"a","success":true},{"id":"b","success":false,"errors":[{"statusCode":"ENTITY_IS_DELETED","message":"gone"}]}]`,
+			wantDeleted: 1,
+		},
+		{
+			name:    "error status",
+			status:  http.StatusBadRequest,
+			body:    `[{"errorCode":"INVALID_SESSION_ID","message":"Session expired"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			status:  http.StatusOK,
+			body:    `not json`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete {
+					t.Errorf("method = %s, want DELETE", r.Method)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			deleted, err := deleteRecords("token", []string{"a", "b"}, nil, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deleteRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted = %d, want %d", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestNewRunIDIsUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := newRunID()
+		if seen[id] {
+			t.Fatalf("duplicate run ID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+// TestRollbackKeepsRestoredRecords fails a resumed run after it uploaded the
+// files the run before it did not get to. The rollback deletes only those.
+func TestRollbackKeepsRestoredRecords(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	useTestServer(t, org.ServeHTTP)
+
+	documents := writeDocuments(t, dir, 2)
+	restoreProgress(&documents[0], map[string]string{
+		"contentVersionId":   "068restored0001",
+		contentDocumentIDKey: "069restored0001",
+	})
+	documents[0].SalesforceIds["attachmentUploaderId"] = "a0Xrestored0001"
+
+	logger := logging.GetLogger()
+	created := newCreatedRecords()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+	if err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, created, nil, checkpoint, logger, nil); err != nil {
+		t.Fatal(err)
+	}
+	uploaded := documents[1].ContentDocumentId
+	if uploaded == "" {
+		t.Fatal("the resumed run uploaded nothing")
+	}
+
+	rollbackRun("token", "test", documents, created, nil, logger)
+
+	if !reflect.DeepEqual(org.deleted, []string{uploaded}) {
+		t.Errorf("rollback deleted %v, want only %s", org.deleted, uploaded)
+	}
+}
@@ -0,0 +1,24 @@
+package processor
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrRunInProgress = errors.New("another run is already in progress")
+
+// runGuard allows one run at a time per process. Runs share the lookup
+// cache, run reports and the HTTP client's circuit breaker, so overlapping
+// runs would interfere whichever caller started them.
+var runGuard sync.Mutex
+
+// TryStartRun takes the run guard, or returns ErrRunInProgress while another
+// run holds it. Callers take it before anything a run shares, such as the
+// progress reporter or the signed-in session, and hold it for the whole
+// run, including any resume after signing in again.
+func TryStartRun() (release func(), err error) {
+	if !runGuard.TryLock() {
+		return nil, ErrRunInProgress
+	}
+	return runGuard.Unlock, nil
+}
@@ -0,0 +1,24 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTryStartRun(t *testing.T) {
+	release, err := TryStartRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := TryStartRun(); !errors.Is(err, ErrRunInProgress) {
+		t.Fatalf("second TryStartRun() = %v, want ErrRunInProgress", err)
+	}
+
+	release()
+	release, err = TryStartRun()
+	if err != nil {
+		t.Fatalf("TryStartRun() after release = %v", err)
+	}
+	release()
+}
@@ -0,0 +1,248 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+const (
+	StatusUploaded = "uploaded"
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped"
+)
+
+type RunReportEntry struct {
+	RelativePath string `json:"relativePath"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	// Progress holds the records already created for a document that did
+	// not finish, so resuming it does not create them again.
+	Progress map[string]string `json:"progress,omitempty"`
+	// Size and ModTime (in Unix nanoseconds) describe the file as it was
+	// collected, so a later resume can tell whether it changed since.
+	Size    int64 `json:"size,omitempty"`
+	ModTime int64 `json:"modTime,omitempty"`
+}
+
+// RunReport records the outcome of every collected document so a failed run
+// can be resumed later, even after the app has been restarted.
+type RunReport struct {
+	RunID        string           `json:"runId"`
+	DocumentsDir string           `json:"documentsDir"`
+	GeneratedAt  time.Time        `json:"generatedAt"`
+	Documents    []RunReportEntry `json:"documents"`
+}
+
+func buildRunReport(runID, documentsDir string, collected, processed []models.DocumentInfo, runErr error) *RunReport {
+	report := &RunReport{
+		RunID:        runID,
+		DocumentsDir: documentsDir,
+		GeneratedAt:  time.Now().UTC(),
+	}
+
+	inRun := make(map[string]models.DocumentInfo, len(processed))
+	for _, doc := range processed {
+		inRun[doc.RelativePath] = doc
+	}
+
+	for _, doc := range collected {
+		entry := RunReportEntry{RelativePath: doc.RelativePath}
+		if info, err := os.Stat(filepath.Join(documentsDir, doc.RelativePath)); err == nil {
+			entry.Size = info.Size()
+			entry.ModTime = info.ModTime().UnixNano()
+		}
+
+		processedDoc, ok := inRun[doc.RelativePath]
+		switch {
+		case doc.Rejected != "":
+			entry.Status = StatusFailed
+			entry.Error = doc.Rejected
+		case !ok:
+			entry.Status = StatusSkipped
+		case processedDoc.SalesforceIds["attachmentUploaderId"] != "",
+			config.AttachmentMode == config.AttachmentModeLink && processedDoc.SalesforceIds["contentDocumentLinkId"] != "":
+			entry.Status = StatusUploaded
+		case runErr != nil:
+			entry.Status = StatusFailed
+			entry.Error = runErr.Error()
+			entry.Progress = savedProgress(processedDoc)
+		default:
+			entry.Status = StatusSkipped
+			entry.Progress = savedProgress(processedDoc)
+		}
+
+		report.Documents = append(report.Documents, entry)
+	}
+
+	return report
+}
+
+// writeRunReport saves the report and returns its path, or an empty string
+// when it could not be written.
+func writeRunReport(report *RunReport, logger *logging.Logger) string {
+	path, err := saveRunReport(report)
+	if err != nil {
+		logger.Warning("Run report not written: %v", err)
+		return ""
+	}
+
+	logger.Info("Run report written to %s", path)
+	return path
+}
+
+// saveRunReport writes the report to a temporary file and renames it into
+// place, so a crash mid-write leaves the previous checkpoint intact.
+func saveRunReport(report *RunReport) (string, error) {
+	dir, err := reportsDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("run_%s.json", report.RunID))
+	tmp, err := os.CreateTemp(dir, ".run_*.json")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// RunError is returned by a run that failed after its report was written,
+// so the caller can resume it with ResumeFromReport.
+type RunError struct {
+	ReportPath string
+	Err        error
+}
+
+func (e *RunError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// LatestRunReportPath returns the most recently written run report, or an
+// empty path when there is none yet.
+func LatestRunReportPath() (string, error) {
+	dir, err := reportsDir()
+	if err != nil {
+		return "", err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "run_*.json"))
+	if err != nil {
+		return "", err
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = path, info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func LoadRunReport(path string) (*RunReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run report: %v", err)
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse run report: %v", err)
+	}
+	if report.RunID == "" || len(report.Documents) == 0 {
+		return nil, fmt.Errorf("%s is not a run report", filepath.Base(path))
+	}
+
+	return &report, nil
+}
+
+// retryPaths returns the documents that did not upload in the reported run.
+func (r *RunReport) retryPaths() []string {
+	var paths []string
+	for _, entry := range r.Documents {
+		if entry.Status == StatusFailed || entry.Status == StatusSkipped {
+			paths = append(paths, entry.RelativePath)
+		}
+	}
+	return paths
+}
+
+// retrySet selects the failed and skipped documents from the freshly
+// collected ones, restoring any progress they had made. Every document to
+// retry must still be present, otherwise the report does not describe this
+// directory.
+func (r *RunReport) retrySet(documents []models.DocumentInfo, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	current := make(map[string]models.DocumentInfo, len(documents))
+	for _, doc := range documents {
+		current[doc.RelativePath] = doc
+	}
+
+	reported := make(map[string]bool, len(r.Documents))
+	progress := make(map[string]map[string]string)
+	for _, entry := range r.Documents {
+		reported[entry.RelativePath] = true
+		if entry.Progress != nil {
+			progress[entry.RelativePath] = entry.Progress
+		}
+	}
+
+	var retry []models.DocumentInfo
+	var missing []string
+	for _, path := range r.retryPaths() {
+		doc, ok := current[path]
+		if !ok {
+			missing = append(missing, path)
+			continue
+		}
+		restoreProgress(&doc, progress[path])
+		retry = append(retry, doc)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("report %s does not match the directory, %d file(s) are missing, e.g. %s",
+			r.RunID, len(missing), missing[0])
+	}
+
+	for _, doc := range documents {
+		if !reported[doc.RelativePath] {
+			logger.Warning("%s is not in report %s and will not be uploaded", doc.RelativePath, r.RunID)
+		}
+	}
+
+	return retry, nil
+}
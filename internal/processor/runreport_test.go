@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+func TestLoadRunReport(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		retry   []string
+		wantErr bool
+	}{
+		{
+			name: "mixed statuses",
+			content: `{"runId":"run-1","documentsDir":"/docs","documents":[
+				{"relativePath":"a.jpg","status":"uploaded"},
+				{"relativePath":"b.jpg","status":"failed","error":"boom"},
+				{"relativePath":"c.jpg","status":"skipped"}]}`,
+			retry: []string{"b.jpg", "c.jpg"},
+		},
+		{name: "all uploaded", content: `{"runId":"run-2","documents":[{"relativePath":"a.jpg","status":"uploaded"}]}`},
+		{name: "no documents", content: `{"runId":"run-3","documents":[]}`, wantErr: true},
+		{name: "no run ID", content: `{"documents":[{"relativePath":"a.jpg","status":"failed"}]}`, wantErr: true},
+		{name: "not JSON", content: `run-4 failed`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := LoadRunReport(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadRunReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := report.retryPaths(); !reflect.DeepEqual(got, tt.retry) {
+				t.Errorf("retryPaths() = %v, want %v", got, tt.retry)
+			}
+		})
+	}
+}
+
+// TestRetrySetFromSavedReport saves a failed run's report, reloads it as a
+// restarted app would and checks which freshly collected documents it
+// retries.
+func TestRetrySetFromSavedReport(t *testing.T) {
+	dir := inTempDir(t)
+	documents := writeDocuments(t, dir, 4)
+	documents[0].SalesforceIds["attachmentUploaderId"] = "a0X000000000001"
+	documents[2].SalesforceIds["attachmentUploaderId"] = "a0X000000000003"
+
+	path, err := saveRunReport(buildRunReport("run-1", dir, documents, documents, errRunInterrupted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := LoadRunReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retry, err := report.retrySet(writeDocuments(t, dir, 5), logging.GetLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, doc := range retry {
+		paths = append(paths, doc.RelativePath)
+	}
+	if want := []string{"bl_001.jpg", "bl_003.jpg"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("retrying %v, want %v", paths, want)
+	}
+}
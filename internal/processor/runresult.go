@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// RunResult identifies the records a successful run created, so they can be
+// checked in Salesforce afterwards.
+type RunResult struct {
+	RunID     string
+	SObject   string
+	RecordIDs []string
+	// ReportPath is the run report, or empty when none was written.
+	ReportPath string
+}
+
+func newRunResult(runID string, documents []models.DocumentInfo) *RunResult {
+	result := &RunResult{RunID: runID, SObject: attachmentSObject}
+	if config.AttachmentMode == config.AttachmentModeLink {
+		result.SObject = "ContentDocument"
+	}
+
+	for _, doc := range documents {
+		id := doc.SalesforceIds["attachmentUploaderId"]
+		if config.AttachmentMode == config.AttachmentModeLink && doc.SalesforceIds["contentDocumentLinkId"] != "" {
+			id = doc.ContentDocumentId
+		}
+		if id != "" {
+			result.RecordIDs = append(result.RecordIDs, id)
+		}
+	}
+
+	return result
+}
+
+// ViewURL returns the page to verify the run's records: the record itself
+// when there is one, otherwise the RESULTS_REPORT_ID report filtered to the
+// run's ID. List views cannot be filtered from a URL, so without such a
+// report the local run report is opened instead, which lists exactly what
+// the run created. Path segments are escaped so the URL stays valid
+// whatever the IDs contain.
+func (r *RunResult) ViewURL(instanceURL string) string {
+	if r == nil || len(r.RecordIDs) == 0 {
+		return ""
+	}
+
+	base := strings.TrimSuffix(instanceURL, "/") + "/lightning"
+	switch {
+	case len(r.RecordIDs) == 1:
+		return base + "/r/" + url.PathEscape(r.SObject) + "/" + url.PathEscape(r.RecordIDs[0]) + "/view"
+	case r.SObject == attachmentSObject && config.RunIDField != "" && config.ResultsReportID != "":
+		return base + "/r/Report/" + url.PathEscape(config.ResultsReportID) + "/view?" + url.Values{"fv0": {r.RunID}}.Encode()
+	case r.ReportPath != "":
+		return fileURL(r.ReportPath)
+	default:
+		return ""
+	}
+}
+
+func fileURL(path string) string {
+	path = filepath.ToSlash(path)
+	// Windows paths start with a drive letter rather than a slash.
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestRunResultViewURL(t *testing.T) {
+	const instance = "https://acme.my.salesforce.com/"
+	tests := []struct {
+		name     string
+		result   *RunResult
+		runField string
+		reportID string
+		want     string
+	}{
+		{
+			name:   "no records",
+			result: &RunResult{RunID: "r1", SObject: attachmentSObject},
+			want:   "",
+		},
+		{
+			name:   "single record",
+			result: &RunResult{RunID: "r1", SObject: attachmentSObject, RecordIDs: []string{"a01000000000001"}},
+			want:   "https://acme.my.salesforce.com/lightning/r/Attachments_Uploader__c/a01000000000001/view",
+		},
+		{
+			name:     "report filtered on run ID",
+			result:   &RunResult{RunID: "20260101-120000-abcd", SObject: attachmentSObject, RecordIDs: []string{"a1", "a2"}, ReportPath: "/tmp/run.json"},
+			runField: "Upload_Run_Id__c",
+			reportID: "00O000000000001",
+			want:     "https://acme.my.salesforce.com/lightning/r/Report/00O000000000001/view?fv0=20260101-120000-abcd",
+		},
+		{
+			name:     "report without run ID field falls back to run report",
+			result:   &RunResult{RunID: "r1", SObject: attachmentSObject, RecordIDs: []string{"a1", "a2"}, ReportPath: "/home/u/reports/run 1.json"},
+			reportID: "00O000000000001",
+			want:     "file:///home/u/reports/run%201.json",
+		},
+		{
+			name:     "files fall back to run report",
+			result:   &RunResult{RunID: "r1", SObject: "ContentDocument", RecordIDs: []string{"069a", "069b"}, ReportPath: "/tmp/run_r1.json"},
+			runField: "Upload_Run_Id__c",
+			reportID: "00O000000000001",
+			want:     "file:///tmp/run_r1.json",
+		},
+		{
+			name:   "no report",
+			result: &RunResult{RunID: "r1", SObject: attachmentSObject, RecordIDs: []string{"a1", "a2"}},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousField, previousReport := config.RunIDField, config.ResultsReportID
+			config.RunIDField, config.ResultsReportID = tt.runField, tt.reportID
+			defer func() { config.RunIDField, config.ResultsReportID = previousField, previousReport }()
+
+			if got := tt.result.ViewURL(instance); got != tt.want {
+				t.Errorf("ViewURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
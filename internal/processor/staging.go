@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// stagingArea holds the files an upload stages on disk in a directory of its
+// own under TEMP_DIR rather than the working directory. The directory is
+// created on first use and removed when the upload ends or its context is
+// cancelled, whichever comes first.
+type stagingArea struct {
+	stop   func() bool
+	mutex  sync.Mutex
+	dir    string
+	closed bool
+}
+
+func newStagingArea(ctx context.Context) *stagingArea {
+	staging := &stagingArea{}
+	staging.stop = context.AfterFunc(ctx, staging.cleanup)
+	return staging
+}
+
+// create opens a new staged file; pattern is as for os.CreateTemp. The
+// caller closes and removes the file once it is sent, so a long run does not
+// hold on to the disk space.
+func (s *stagingArea) create(pattern string) (*os.File, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("upload already ended, nothing more can be staged")
+	}
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(config.TempDir, "document-uploader-")
+		if err != nil {
+			return nil, fmt.Errorf("error creating staging directory: %v", err)
+		}
+		s.dir = dir
+	}
+	return os.CreateTemp(s.dir, pattern)
+}
+
+// Close removes everything still staged.
+func (s *stagingArea) Close() {
+	s.stop()
+	s.cleanup()
+}
+
+func (s *stagingArea) cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+		s.dir = ""
+	}
+}
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// useTempDir points TEMP_DIR at an empty directory for one test.
+func useTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previous := config.TempDir
+	config.TempDir = dir
+	t.Cleanup(func() { config.TempDir = previous })
+	return dir
+}
+
+// stagedFiles lists the files under dir.
+func stagedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && path != dir {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestBinaryUploadCleansUpStagedFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		redirect bool
+		fail     bool
+		abort    bool
+		wantErr  bool
+	}{
+		{name: "success"},
+		{name: "redirected", redirect: true},
+		{name: "rejected", fail: true, wantErr: true},
+		{name: "aborted", abort: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := useTempDir(t)
+			source := filepath.Join(t.TempDir(), "bl_front.jpg")
+			if err := os.WriteFile(source, []byte(strings.Repeat("x", 1024)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if len(stagedFiles(t, tempDir)) == 0 {
+					t.Error("request sent without a staged file")
+				}
+				if tt.redirect && r.URL.Query().Get("moved") == "" {
+					http.Redirect(w, r, r.URL.Path+"?moved=1", http.StatusTemporaryRedirect)
+					return
+				}
+				data, _ := io.ReadAll(r.Body)
+				if r.ContentLength <= 0 || int64(len(data)) != r.ContentLength {
+					t.Errorf("read %d bytes of a %d byte request", len(data), r.ContentLength)
+				}
+				if !strings.Contains(string(data), strings.Repeat("x", 1024)) {
+					t.Error("request does not carry the file")
+				}
+				switch {
+				case tt.abort:
+					cancel()
+					<-r.Context().Done()
+				case tt.fail:
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`[{"errorCode":"STORAGE_LIMIT_EXCEEDED","message":"full"}]`))
+				default:
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id":"068000000000001","success":true}`))
+				}
+			})
+
+			staging := newStagingArea(ctx)
+			id, err := uploadContentVersionBinary(ctx, "token", source, map[string]any{"Title": "bl_front.jpg"}, staging)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadContentVersionBinary() = %q, %v, want error %v", id, err, tt.wantErr)
+			}
+			staging.Close()
+
+			if files := stagedFiles(t, tempDir); len(files) > 0 {
+				t.Errorf("staged files left behind: %v", files)
+			}
+		})
+	}
+}
+
+func TestStagingAreaRemovedWhenCancelled(t *testing.T) {
+	tempDir := useTempDir(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	staging := newStagingArea(ctx)
+
+	file, err := staging.create("staged-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for len(stagedFiles(t, tempDir)) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("staged files left after cancelling: %v", stagedFiles(t, tempDir))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := staging.create("staged-*"); err == nil {
+		t.Error("create() staged a file after the upload was cancelled")
+	}
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// namePathSegments lists a document's entity names from the project down, in
+// the same order as the folder hierarchy.
+func namePathSegments(doc models.DocumentInfo) []string {
+	var segments []string
+	for _, level := range filestructure.Ancestry(doc.EntityType) {
+		value, ok := doc.NamePath[level.NameKey]
+		if !ok {
+			break
+		}
+		segments = append(segments, value)
+	}
+	return segments
+}
+
+func parseSubtreeFilter(filter string) []string {
+	var segments []string
+	for _, part := range strings.Split(strings.ReplaceAll(filter, "\\", "/"), "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+func inSubtree(doc models.DocumentInfo, filter []string) bool {
+	segments := namePathSegments(doc)
+	if len(segments) < len(filter) {
+		return false
+	}
+	for i, name := range filter {
+		if !strings.EqualFold(segments[i], name) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterSubtree keeps the documents under the given entity path, written as
+// names separated by "/" (e.g. "Project/1/Z1"). An empty filter keeps all.
+func filterSubtree(documents []models.DocumentInfo, filter string, logger *logging.Logger) ([]models.DocumentInfo, error) {
+	segments := parseSubtreeFilter(filter)
+	if len(segments) == 0 {
+		return documents, nil
+	}
+
+	var included []models.DocumentInfo
+	for _, doc := range documents {
+		if inSubtree(doc, segments) {
+			included = append(included, doc)
+		}
+	}
+
+	logger.Info("Subtree filter %q: %d document(s) included, %d excluded",
+		strings.Join(segments, "/"), len(included), len(documents)-len(included))
+
+	if len(included) == 0 {
+		return nil, fmt.Errorf("no documents found under %s", strings.Join(segments, "/"))
+	}
+	return included, nil
+}
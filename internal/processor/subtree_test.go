@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestFilterSubtree(t *testing.T) {
+	documents := []models.DocumentInfo{
+		{RelativePath: "p1.pdf", EntityType: "PHASE", NamePath: map[string]string{"project": "Tower", "phase": "P1"}},
+		{RelativePath: "b1.pdf", EntityType: "BUILDING", NamePath: map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1"}},
+		{RelativePath: "a101.pdf", EntityType: "UNIT", NamePath: map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1", "unit": "A101"}},
+		{RelativePath: "b2.pdf", EntityType: "BUILDING", NamePath: map[string]string{"project": "Tower", "phase": "P2", "zone": "Z1", "building": "B2"}},
+	}
+
+	tests := []struct {
+		name    string
+		filter  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty keeps all", filter: "", want: []string{"p1.pdf", "b1.pdf", "a101.pdf", "b2.pdf"}},
+		{name: "phase", filter: "Tower/P1", want: []string{"p1.pdf", "b1.pdf", "a101.pdf"}},
+		{name: "building", filter: "Tower/P1/Z1/B1", want: []string{"b1.pdf", "a101.pdf"}},
+		{name: "case and separators", filter: ` tower\p2\ `, want: []string{"b2.pdf"}},
+		{name: "nothing under path", filter: "Tower/P3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterSubtree(documents, tt.filter, logging.GetLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterSubtree(%q) error = %v, want error %v", tt.filter, err, tt.wantErr)
+			}
+			var paths []string
+			for _, doc := range got {
+				paths = append(paths, doc.RelativePath)
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("filterSubtree(%q) = %v, want %v", tt.filter, paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSubtreeReportsCounts(t *testing.T) {
+	documents := []models.DocumentInfo{
+		{EntityType: "PHASE", NamePath: map[string]string{"project": "Tower", "phase": "P1"}},
+		{EntityType: "PHASE", NamePath: map[string]string{"project": "Tower", "phase": "P2"}},
+	}
+	logger := logging.GetLogger()
+	if _, err := filterSubtree(documents, "Tower/P1", logger); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "1 document(s) included, 1 excluded"
+	if lines := logger.RecentLines(1); len(lines) != 1 || !strings.Contains(lines[0], want) {
+		t.Errorf("last log line = %q, want %q", lines, want)
+	}
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+const (
+	maxTitleLength            = 255
+	maxDistributionNameLength = 100
+	maxRecordNameLength       = 80
+	invalidTitleChars         = `\/:*?"<>|`
+)
+
+func contentTitle(doc models.DocumentInfo) string {
+	if doc.Title != "" {
+		return doc.Title
+	}
+	return filepath.Base(doc.FilePath)
+}
+
+// truncateTitle shortens a title to limit characters while keeping the file
+// extension, so Salesforce still recognises the file type.
+func truncateTitle(title string, limit int) string {
+	if utf8.RuneCountInString(title) <= limit {
+		return title
+	}
+
+	ext := filepath.Ext(title)
+	if utf8.RuneCountInString(ext) >= limit {
+		ext = ""
+	}
+	base := []rune(strings.TrimSuffix(title, ext))
+	return string(base[:limit-utf8.RuneCountInString(ext)]) + ext
+}
+
+// titleProblem returns why Salesforce would reject title, or "" when it is
+// acceptable. Long titles are only a problem when they are not truncated.
+func titleProblem(title string, truncate bool) string {
+	if strings.ContainsAny(title, invalidTitleChars) || strings.IndexFunc(title, unicode.IsControl) >= 0 {
+		return "title contains characters Salesforce rejects"
+	}
+	if length := utf8.RuneCountInString(title); length > maxTitleLength && !truncate {
+		return fmt.Sprintf("title is %d characters, the limit is %d", length, maxTitleLength)
+	}
+	return ""
+}
+
+// checkTitles reports every file whose title Salesforce would reject before
+// anything is uploaded, rather than failing partway through a batch.
+func checkTitles(documents []models.DocumentInfo, truncate bool, logger *logging.Logger) error {
+	var problems []string
+
+	for i := range documents {
+		title := contentTitle(documents[i])
+
+		if problem := titleProblem(title, truncate); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", documents[i].RelativePath, problem))
+			continue
+		}
+
+		length := utf8.RuneCountInString(title)
+		if length <= maxTitleLength {
+			continue
+		}
+
+		documents[i].Title = truncateTitle(title, maxTitleLength)
+		warning := fmt.Sprintf("title truncated from %d to %d characters", length, maxTitleLength)
+		documents[i].Warnings = append(documents[i].Warnings, warning)
+		logger.Warning("%s: %s", documents[i].RelativePath, warning)
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			logger.Error(problem)
+		}
+		return fmt.Errorf("%d file(s) have titles Salesforce will reject:\n- %s",
+			len(problems), strings.Join(problems, "\n- "))
+	}
+
+	return nil
+}
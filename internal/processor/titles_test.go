@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		limit int
+		want  string
+	}{
+		{"short enough", "plan.pdf", 10, "plan.pdf"},
+		{"keeps extension", "floor_plan.pdf", 10, "floor_.pdf"},
+		{"multibyte", "ééééé.jpg", 7, "ééé.jpg"},
+		{"extension too long", "a.verylongext", 5, "a.ver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateTitle(tt.title, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateTitle(%q, %d) = %q, want %q", tt.title, tt.limit, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > tt.limit {
+				t.Errorf("truncated title has %d characters, limit %d", n, tt.limit)
+			}
+		})
+	}
+}
+
+func TestTitleProblem(t *testing.T) {
+	long := strings.Repeat("a", maxTitleLength) + ".jpg"
+	tests := []struct {
+		name     string
+		title    string
+		truncate bool
+		want     bool
+	}{
+		{"ordinary", "bl_front.jpg", false, false},
+		{"invalid character", "plan: v2.pdf", false, true},
+		{"control character", "plan\t.pdf", false, true},
+		{"too long", long, false, true},
+		{"too long but truncated", long, true, false},
+		{"invalid even when truncated", "a|b.jpg", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := titleProblem(tt.title, tt.truncate); (got != "") != tt.want {
+				t.Errorf("titleProblem(%q, %v) = %q, want a problem %v", tt.title, tt.truncate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTitlesTruncates(t *testing.T) {
+	documents := []models.DocumentInfo{{FilePath: strings.Repeat("a", 300) + ".jpg", RelativePath: "long.jpg"}}
+	if err := checkTitles(documents, true, logging.GetLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(documents[0].Title); n != maxTitleLength {
+		t.Errorf("title has %d characters, want %d", n, maxTitleLength)
+	}
+	if !strings.HasSuffix(documents[0].Title, ".jpg") || len(documents[0].Warnings) != 1 {
+		t.Errorf("title %q, warnings %q", documents[0].Title, documents[0].Warnings)
+	}
+
+	if err := checkTitles([]models.DocumentInfo{{FilePath: "a?.jpg", RelativePath: "a?.jpg"}}, true, logging.GetLogger()); err == nil {
+		t.Error("checkTitles() accepted an invalid title")
+	}
+}
+
+func TestAttachmentName(t *testing.T) {
+	doc := models.DocumentInfo{FilePath: "/docs/B1/bl_front.jpg"}
+	titled := models.DocumentInfo{FilePath: "/docs/B1/bl_front.jpg", Title: strings.Repeat("t", maxRecordNameLength) + ".jpg"}
+	long := strings.Repeat("é", maxRecordNameLength+5)
+
+	tests := []struct {
+		name         string
+		doc          models.DocumentInfo
+		displayValue string
+		source       string
+		want         string
+	}{
+		{"entity id", doc, "Tower A", config.AttachmentNameEntityID, "a0B000000000001"},
+		{"unknown source", doc, "Tower A", "other", "a0B000000000001"},
+		{"display value", doc, "Tower A", config.AttachmentNameDisplayValue, "Tower A"},
+		{"blank display value", doc, "  ", config.AttachmentNameDisplayValue, "a0B000000000001"},
+		{"long display value", doc, long, config.AttachmentNameDisplayValue, string([]rune(long)[:maxRecordNameLength])},
+		{"filename", doc, "Tower A", config.AttachmentNameFilename, "bl_front.jpg"},
+		{"long title", titled, "", config.AttachmentNameFilename, truncateTitle(titled.Title, maxRecordNameLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachmentName(tt.doc, "a0B000000000001", tt.displayValue, tt.source); got != tt.want {
+				t.Errorf("attachmentName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+// ErrInvalidToken is returned before a run starts when Salesforce does not
+// accept the access token. It wraps ErrSessionExpired so callers sign in
+// again the same way as when a session expires mid-run.
+var ErrInvalidToken = fmt.Errorf("authentication token is invalid or expired: %w", ErrSessionExpired)
+
+// checkToken makes one cheap authenticated call, so a bad token fails the
+// run before any files are collected or encoded rather than surfacing as an
+// undecodable lookup response. Only a 401 counts against the token; users
+// without access to the limits resource still get a 403.
+func checkToken(accessToken string) error {
+	if accessToken == "" {
+		return ErrInvalidToken
+	}
+
+	req, err := http.NewRequest("GET", config.DataURL("/limits"), nil)
+	if err != nil {
+		return fmt.Errorf("error creating token check request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach Salesforce to check the authentication token: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidToken
+	}
+	return nil
+}
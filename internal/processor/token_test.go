@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+func TestCheckToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		wantErr error
+	}{
+		{name: "valid", token: "good", status: http.StatusOK},
+		{name: "empty", token: "", status: http.StatusOK, wantErr: ErrInvalidToken},
+		{name: "rejected", token: "expired", status: http.StatusUnauthorized, wantErr: ErrInvalidToken},
+		{name: "no access to limits", token: "good", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			err := checkToken(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkToken() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, ErrSessionExpired) {
+				t.Errorf("checkToken() = %v, want it to wrap ErrSessionExpired", err)
+			}
+		})
+	}
+}
+
+// TestProcessDocumentsInvalidToken checks that a rejected token stops the
+// run before anything past the token check is requested.
+func TestProcessDocumentsInvalidToken(t *testing.T) {
+	dir := inTempDir(t)
+	writeDocuments(t, dir, 3)
+
+	var mutex sync.Mutex
+	var paths []string
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`[{"errorCode":"INVALID_SESSION_ID","message":"Session expired or invalid"}]`))
+	})
+
+	_, err := ProcessDocuments("expired", models.DirectoryRun{DocumentsDir: dir}, nil)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ProcessDocuments() = %v, want ErrInvalidToken", err)
+	}
+	if err.Error() != "authentication token is invalid or expired: Salesforce session expired" {
+		t.Errorf("error = %q", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("requests = %v, want only the token check", paths)
+	}
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"sort"
+
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// EntityNode is one entity in the tree built by BuildEntityTree, holding the
+// documents attached directly to it and the entities below it.
+type EntityNode struct {
+	EntityType string
+	Label      string
+	Name       string
+	Documents  []models.DocumentInfo
+	Children   []*EntityNode
+}
+
+// BuildEntityTree arranges walked documents into their entity hierarchy,
+// project → phase → zone → building → unit, with design types under their
+// phase. It works from the name paths already parsed, so the disk is not
+// read again. Projects are returned in name order, as are children of the
+// same type; documents of an unknown entity type are left out.
+func BuildEntityTree(documents []models.DocumentInfo) []*EntityNode {
+	root := &EntityNode{}
+	for _, doc := range documents {
+		chain := filestructure.Ancestry(doc.EntityType)
+		if len(chain) == 0 {
+			continue
+		}
+
+		node := root
+		for _, level := range chain {
+			node = node.child(level, doc.NamePath[level.NameKey])
+		}
+		node.Documents = append(node.Documents, doc)
+	}
+
+	root.sort()
+	return root.Children
+}
+
+// Child returns the direct child of the given type and name, or nil.
+func (n *EntityNode) Child(entityType, name string) *EntityNode {
+	for _, child := range n.Children {
+		if child.EntityType == entityType && child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// DocumentCount counts the documents attached to this entity and everything
+// below it.
+func (n *EntityNode) DocumentCount() int {
+	count := len(n.Documents)
+	for _, child := range n.Children {
+		count += child.DocumentCount()
+	}
+	return count
+}
+
+func (n *EntityNode) child(level filestructure.EntityHierarchy, name string) *EntityNode {
+	if child := n.Child(level.Type, name); child != nil {
+		return child
+	}
+	child := &EntityNode{EntityType: level.Type, Label: level.Label, Name: name}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+func (n *EntityNode) sort() {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		if n.Children[i].EntityType != n.Children[j].EntityType {
+			return levelIndex(n.Children[i].EntityType) < levelIndex(n.Children[j].EntityType)
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, child := range n.Children {
+		child.sort()
+	}
+}
+
+// levelIndex orders entity types as filestructure.Hierarchy lists them.
+func levelIndex(entityType string) int {
+	for i, level := range filestructure.Hierarchy {
+		if level.Type == entityType {
+			return i
+		}
+	}
+	return len(filestructure.Hierarchy)
+}
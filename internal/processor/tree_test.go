@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/models"
+)
+
+// treeLines renders nodes one per line, indented by depth, with the number
+// of documents attached directly to each.
+func treeLines(nodes []*EntityNode, depth int) []string {
+	var lines []string
+	for _, node := range nodes {
+		lines = append(lines, fmt.Sprintf("%s%s %s (%d)", strings.Repeat("  ", depth), node.EntityType, node.Name, len(node.Documents)))
+		lines = append(lines, treeLines(node.Children, depth+1)...)
+	}
+	return lines
+}
+
+func TestBuildEntityTree(t *testing.T) {
+	phase := map[string]string{"project": "Tower", "phase": "P1"}
+	zone := map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1"}
+	building := func(name string) map[string]string {
+		return map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": name}
+	}
+	documents := []models.DocumentInfo{
+		{RelativePath: "b2.jpg", EntityType: "BUILDING", NamePath: building("B2")},
+		{RelativePath: "a101.jpg", EntityType: "UNIT", NamePath: map[string]string{"project": "Tower", "phase": "P1", "zone": "Z1", "building": "B1", "unit": "A101"}},
+		{RelativePath: "loft.jpg", EntityType: "DESIGN_TYPE", NamePath: map[string]string{"project": "Tower", "phase": "P1", "designType": "Loft"}},
+		{RelativePath: "b1-front.jpg", EntityType: "BUILDING", NamePath: building("B1")},
+		{RelativePath: "b1-back.jpg", EntityType: "BUILDING", NamePath: building("B1")},
+		{RelativePath: "zone.jpg", EntityType: "ZONE", NamePath: zone},
+		{RelativePath: "phase.jpg", EntityType: "PHASE", NamePath: phase},
+		{RelativePath: "park.jpg", EntityType: "PHASE", NamePath: map[string]string{"project": "Park", "phase": "P1"}},
+		{RelativePath: "unknown.jpg", EntityType: "SITE", NamePath: phase},
+	}
+
+	tree := BuildEntityTree(documents)
+
+	want := []string{
+		"PROJECT Park (0)",
+		"  PHASE P1 (1)",
+		"PROJECT Tower (0)",
+		"  PHASE P1 (1)",
+		"    ZONE Z1 (1)",
+		"      BUILDING B1 (2)",
+		"        UNIT A101 (1)",
+		"      BUILDING B2 (1)",
+		"    DESIGN_TYPE Loft (1)",
+	}
+	if got := treeLines(tree, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("tree =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	tower := tree[1]
+	if n := tower.DocumentCount(); n != 7 {
+		t.Errorf("Tower DocumentCount() = %d, want 7", n)
+	}
+	b1 := tower.Child("PHASE", "P1").Child("ZONE", "Z1").Child("BUILDING", "B1")
+	if b1 == nil || b1.Documents[0].RelativePath != "b1-front.jpg" || b1.Label != "Building" {
+		t.Errorf("building B1 = %+v, want its documents in walk order", b1)
+	}
+	if tower.Child("PHASE", "P2") != nil {
+		t.Error("Child() found a phase that does not exist")
+	}
+}
@@ -0,0 +1,225 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+// TestBulkUploadContentVersionsConcurrent sends several batches at once
+// while each records its results through the checkpoint. Run it with -race
+// to check that no document is written while another batch reads it.
+func TestBulkUploadContentVersionsConcurrent(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	useTestServer(t, org.ServeHTTP)
+
+	previous := config.ParallelRequests
+	config.ParallelRequests = 4
+	defer func() { config.ParallelRequests = previous }()
+
+	documents := writeDocuments(t, dir, 4*compositeBatchSize+3)
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+	err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for _, doc := range documents {
+		id := doc.SalesforceIds["contentVersionId"]
+		if id == "" || seen[id] {
+			t.Fatalf("%s has ContentVersion %q, want a unique one", doc.RelativePath, id)
+		}
+		seen[id] = true
+		if doc.ContentDocumentId == "" {
+			t.Errorf("%s has no ContentDocument", doc.RelativePath)
+		}
+		if doc.SalesforceIds["distributionUrl"] == "" {
+			t.Errorf("%s has no distribution URL", doc.RelativePath)
+		}
+	}
+	if n := org.count("ContentVersion"); n != len(documents) {
+		t.Errorf("%d ContentVersions created for %d documents", n, len(documents))
+	}
+
+	report, err := LoadRunReport(filepathInReports(t, "run_test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range report.Documents {
+		if entry.Progress["contentVersionId"] == "" {
+			t.Errorf("checkpoint has no ContentVersion for %s", entry.RelativePath)
+		}
+	}
+}
+
+// TestBulkUploadContentVersionsStopsAfterFailedBatch checks that once a
+// batch fails, the batches not yet started are not sent.
+func TestBulkUploadContentVersionsStopsAfterFailedBatch(t *testing.T) {
+	dir := inTempDir(t)
+	org := newFakeOrg()
+	org.fail = func(call int32) bool { return call == 1 }
+	useTestServer(t, org.ServeHTTP)
+
+	previous := config.ParallelRequests
+	config.ParallelRequests = 1
+	defer func() { config.ParallelRequests = previous }()
+
+	documents := writeDocuments(t, dir, 4*compositeBatchSize)
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+	err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil)
+	if err == nil {
+		t.Fatal("expected the failed batch to fail the upload")
+	}
+	if n := org.composite.Load(); n != 1 {
+		t.Errorf("%d composite requests sent, want 1", n)
+	}
+}
+
+// TestBulkUploadContentVersionsSaveFailed checks that a create answered with
+// 201 but success false fails the upload with the error Salesforce gave.
+func TestBulkUploadContentVersionsSaveFailed(t *testing.T) {
+	dir := inTempDir(t)
+	useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			CompositeRequest []struct {
+				ReferenceID string `json:"referenceId"`
+			} `json:"compositeRequest"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		var results []map[string]any
+		for _, subrequest := range request.CompositeRequest {
+			results = append(results, map[string]any{
+				"referenceId":    subrequest.ReferenceID,
+				"httpStatusCode": http.StatusCreated,
+				"body": map[string]any{"success": false, "errors": []map[string]any{
+					{"statusCode": "STORAGE_LIMIT_EXCEEDED", "message": "storage limit exceeded"},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"compositeResponse": results})
+	})
+
+	documents := writeDocuments(t, dir, 2)
+	logger := logging.GetLogger()
+	checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+	err := bulkUploadContentVersions(context.Background(), "token", dir, documents, nil, nil, nil, checkpoint, logger, nil)
+	if err == nil || !strings.Contains(err.Error(), "STORAGE_LIMIT_EXCEEDED - storage limit exceeded") {
+		t.Fatalf("bulkUploadContentVersions() = %v, want the save error", err)
+	}
+	for _, doc := range documents {
+		if id := doc.SalesforceIds["contentVersionId"]; id != "" {
+			t.Errorf("%s has ContentVersion %q after a failed save", doc.RelativePath, id)
+		}
+	}
+}
+
+// TestBulkCreateAttachmentUploadersConcurrent sends the attachment record
+// batches in parallel, and one at a time in document order when
+// ATTACHMENT_ORDERED is set. Run it with -race to check the results are
+// recorded safely.
+func TestBulkCreateAttachmentUploadersConcurrent(t *testing.T) {
+	tests := []struct {
+		name           string
+		ordered        bool
+		maxConcurrency int32
+	}{
+		{name: "parallel", maxConcurrency: 4},
+		{name: "ordered", ordered: true, maxConcurrency: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTempDir(t)
+			org := newFakeOrg()
+			var mutex sync.Mutex
+			var inFlight, peak int32
+			var order []string
+			useTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/composite") {
+					mutex.Lock()
+					inFlight++
+					peak = max(peak, inFlight)
+					mutex.Unlock()
+					defer func() {
+						mutex.Lock()
+						inFlight--
+						mutex.Unlock()
+					}()
+					body, _ := io.ReadAll(r.Body)
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					var request struct {
+						CompositeRequest []struct {
+							ReferenceID string `json:"referenceId"`
+						} `json:"compositeRequest"`
+					}
+					json.Unmarshal(body, &request)
+					mutex.Lock()
+					for _, sub := range request.CompositeRequest {
+						order = append(order, sub.ReferenceID)
+					}
+					mutex.Unlock()
+					time.Sleep(20 * time.Millisecond)
+				}
+				org.ServeHTTP(w, r)
+			})
+
+			previousParallel, previousAttachment, previousOrdered := config.ParallelRequests, config.AttachmentParallelRequests, config.AttachmentOrdered
+			config.ParallelRequests, config.AttachmentParallelRequests, config.AttachmentOrdered = 1, 4, tt.ordered
+			defer func() {
+				config.ParallelRequests, config.AttachmentParallelRequests, config.AttachmentOrdered = previousParallel, previousAttachment, previousOrdered
+			}()
+
+			documents := writeDocuments(t, dir, 4*compositeBatchSize+3)
+			for i := range documents {
+				documents[i].ContentDocumentId = fmt.Sprintf("069%012d", i)
+				documents[i].SalesforceIds["distributionUrl"] = "https://acme.file.force.com/" + documents[i].RelativePath
+			}
+			logger := logging.GetLogger()
+			created := newCreatedRecords()
+			checkpoint := &checkpointer{runID: "test", documentsDir: dir, collected: documents, documents: &documents, logger: logger}
+
+			err := bulkCreateAttachmentUploaders(context.Background(), "token", "test", documents, nil, created, nil, checkpoint, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := make(map[string]bool)
+			for _, doc := range documents {
+				id := doc.SalesforceIds["attachmentUploaderId"]
+				if id == "" || seen[id] {
+					t.Fatalf("%s has attachment record %q, want a unique one", doc.RelativePath, id)
+				}
+				seen[id] = true
+			}
+			if len(created.ids) != len(documents) {
+				t.Errorf("%d records kept for rollback, want %d", len(created.ids), len(documents))
+			}
+			if peak != tt.maxConcurrency {
+				t.Errorf("%d batches sent at once, want %d", peak, tt.maxConcurrency)
+			}
+			if tt.ordered {
+				for i, ref := range order {
+					if want := fmt.Sprintf("attRef%d", i); ref != want {
+						t.Fatalf("subrequest %d is %s, want %s", i, ref, want)
+					}
+				}
+			}
+		})
+	}
+}
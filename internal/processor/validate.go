@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/filestructure"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+)
+
+// ValidationReport is the outcome of ValidateDirectory, shaped for CI
+// pipelines that read it as JSON.
+type ValidationReport struct {
+	DocumentsDir string                  `json:"documentsDir"`
+	Valid        bool                    `json:"valid"`
+	Documents    int                     `json:"documents"`
+	Problems     []filestructure.Problem `json:"problems"`
+	// Aborted is set when validation stopped at MAX_PARSE_ERRORS; Problems
+	// then holds only the first ones found.
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// ValidateDirectory runs the checks an upload performs before it contacts
+// Salesforce: every file must match the folder and naming conventions, and
+// titles and sizes must be acceptable. Unlike a run it reports every problem
+// instead of stopping at the first, and it never touches the network.
+func ValidateDirectory(documentsDir string) (*ValidationReport, error) {
+	logger := logging.GetLogger()
+
+	if absDir, err := filepath.Abs(documentsDir); err == nil {
+		documentsDir = absDir
+	}
+	info, err := os.Stat(documentsDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access documents directory %s: %v", documentsDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is a file, not a directory", documentsDir)
+	}
+
+	exclude, err := filestructure.ParseExcludePatterns(config.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := filestructure.NewDocumentWalker(documentsDir)
+	walker.SetFollowSymlinks(config.FollowSymlinks)
+	walker.SetLenientDocumentTypes(config.LenientDocumentTypes)
+	walker.SetSkipPaths(filestructure.AppPaths())
+	walker.SetExcludePatterns(exclude)
+	walker.SetCollectProblems(true)
+	walker.SetMaxProblems(config.MaxParseErrors)
+	documents, err := walker.Walk()
+	if errors.Is(err, filestructure.ErrTooManyProblems) {
+		problems := walker.Problems()
+		logger.Error("Validation of %s stopped after %d problem(s): %v", documentsDir, len(problems), err)
+		return &ValidationReport{
+			DocumentsDir: documentsDir,
+			Problems: append(problems, filestructure.Problem{Path: ".",
+				Message: fmt.Sprintf("%v: stopped after %d unparseable file(s)", err, len(problems))}),
+			Aborted: true,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error walking documents directory: %v", err)
+	}
+
+	problems := walker.Problems()
+	for _, doc := range documents {
+		path := filepath.ToSlash(doc.RelativePath)
+		if doc.Rejected != "" {
+			problems = append(problems, filestructure.Problem{Path: path, Message: doc.Rejected})
+			continue
+		}
+		if problem := titleProblem(contentTitle(doc), config.TruncateLongTitles); problem != "" {
+			problems = append(problems, filestructure.Problem{Path: path, Message: problem})
+		}
+
+		info, err := os.Stat(filepath.Join(documentsDir, doc.RelativePath))
+		if err != nil {
+			problems = append(problems, filestructure.Problem{Path: path, Message: err.Error()})
+			continue
+		}
+		if config.MaxFileSize > 0 && info.Size() > config.MaxFileSize && !config.SkipOversizedFiles {
+			problems = append(problems, filestructure.Problem{Path: path,
+				Message: fmt.Sprintf("file is %s, the limit is %s", formatBytes(info.Size()), formatBytes(config.MaxFileSize))})
+		}
+
+		if config.UndetectedContentPolicy == config.UndetectedContentFail {
+			detected, err := sniffContent(filepath.Join(documentsDir, doc.RelativePath))
+			if err != nil {
+				problems = append(problems, filestructure.Problem{Path: path, Message: err.Error()})
+			} else if detected.Is("application/octet-stream") {
+				problems = append(problems, filestructure.Problem{Path: path, Message: "content type could not be identified"})
+			}
+		}
+	}
+
+	if len(documents) == 0 && len(problems) == 0 {
+		problems = append(problems, filestructure.Problem{Path: ".", Message: "no documents found"})
+	}
+	if problems == nil {
+		problems = []filestructure.Problem{}
+	}
+
+	logger.Info("Validated %s: %d document(s), %d problem(s)", documentsDir, len(documents), len(problems))
+	return &ValidationReport{
+		DocumentsDir: documentsDir,
+		Valid:        len(problems) == 0,
+		Documents:    len(documents),
+		Problems:     problems,
+	}, nil
+}
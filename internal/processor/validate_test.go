@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/config"
+)
+
+func TestValidateDirectoryStopsAtMaxParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		name := filepath.Join(dir, "photo_"+strings.Repeat("x", i+1)+".jpg")
+		if err := os.WriteFile(name, jpegContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := config.MaxParseErrors
+	config.MaxParseErrors = 3
+	defer func() { config.MaxParseErrors = previous }()
+
+	report, err := ValidateDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Aborted || report.Valid {
+		t.Fatalf("report = %+v, want an aborted, invalid validation", report)
+	}
+	if len(report.Problems) != 4 {
+		t.Fatalf("%d problems reported, want the first 3 and a summary", len(report.Problems))
+	}
+	summary := report.Problems[3]
+	if want := "directory structure appears incorrect: stopped after 3 unparseable file(s)"; summary.Path != "." || summary.Message != want {
+		t.Errorf("summary = %+v, want %q", summary, want)
+	}
+}
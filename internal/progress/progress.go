@@ -0,0 +1,143 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	PhaseAuth       = "auth"
+	PhaseCollect    = "collect"
+	PhaseLookup     = "lookup"
+	PhaseExport     = "export"
+	PhaseUpload     = "upload"
+	PhaseDistribute = "distribute"
+	PhaseAttach     = "attach"
+	PhaseRollback   = "rollback"
+	PhaseDone       = "done"
+	PhaseFailed     = "failed"
+)
+
+// Event is a snapshot of a run's progress. Fraction is the overall progress
+// from 0 to 1; Current and Total count items within the phase when known.
+type Event struct {
+	Phase    string  `json:"phase"`
+	Message  string  `json:"message"`
+	Current  int     `json:"current"`
+	Total    int     `json:"total"`
+	Fraction float64 `json:"fraction"`
+}
+
+// Reporter emits progress events on a channel. It keeps the latest state so
+// every event is complete on its own. A nil *Reporter is valid and reports
+// nothing.
+type Reporter struct {
+	events chan<- Event
+	state  Event
+	mutex  sync.Mutex
+}
+
+func NewReporter(events chan<- Event) *Reporter {
+	return &Reporter{events: events}
+}
+
+// Phase starts a new phase with a status message.
+func (r *Reporter) Phase(phase, message string) {
+	r.update(func(e *Event) {
+		e.Phase = phase
+		e.Message = message
+		e.Current, e.Total = 0, 0
+	})
+}
+
+// Status replaces the message without leaving the current phase, for
+// temporary states such as waiting for the network. It returns the previous
+// message so the caller can restore it.
+func (r *Reporter) Status(message string) (previous string) {
+	r.update(func(e *Event) {
+		previous = e.Message
+		e.Message = message
+	})
+	return previous
+}
+
+// Progress sets the overall progress. It never moves backwards within a
+// run, so a run that is resumed partway, for example after signing in again,
+// does not rewind the progress bar.
+func (r *Reporter) Progress(fraction float64) {
+	r.update(func(e *Event) {
+		e.Fraction = max(e.Fraction, fraction)
+	})
+}
+
+// Step records progress through the items of the current phase.
+func (r *Reporter) Step(current, total int, fraction float64) {
+	r.update(func(e *Event) {
+		e.Current, e.Total = current, total
+		e.Fraction = max(e.Fraction, fraction)
+	})
+}
+
+// Reset starts a new run from zero progress.
+func (r *Reporter) Reset() {
+	r.update(func(e *Event) {
+		*e = Event{}
+	})
+}
+
+func (r *Reporter) update(change func(*Event)) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	change(&r.state)
+	r.events <- r.state
+}
+
+// Print writes events as console lines until the channel is closed, for runs
+// without a GUI.
+func Print(w io.Writer, events <-chan Event) {
+	for event := range events {
+		if event.Total > 0 {
+			fmt.Fprintf(w, "[%3.0f%%] %s (%d/%d)\n", event.Fraction*100, event.Message, event.Current, event.Total)
+			continue
+		}
+		fmt.Fprintf(w, "[%3.0f%%] %s\n", event.Fraction*100, event.Message)
+	}
+}
+
+// Tee copies every event to each of outs until the channel is closed, then
+// closes them, so several consumers can follow the same run.
+func Tee(events <-chan Event, outs ...chan<- Event) {
+	for event := range events {
+		for _, out := range outs {
+			out <- event
+		}
+	}
+	for _, out := range outs {
+		close(out)
+	}
+}
+
+// Collector records events in memory.
+type Collector struct {
+	events []Event
+	mutex  sync.Mutex
+}
+
+// Collect stores events until the channel is closed.
+func (c *Collector) Collect(events <-chan Event) {
+	for event := range events {
+		c.mutex.Lock()
+		c.events = append(c.events, event)
+		c.mutex.Unlock()
+	}
+}
+
+func (c *Collector) Events() []Event {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Event(nil), c.events...)
+}
@@ -0,0 +1,72 @@
+package progress
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReporterEvents(t *testing.T) {
+	events := make(chan Event)
+	var collector Collector
+	done := make(chan struct{})
+	go func() {
+		collector.Collect(events)
+		close(done)
+	}()
+
+	reporter := NewReporter(events)
+	reporter.Phase(PhaseUpload, "Uploading content...")
+	reporter.Step(1, 4, 0.5)
+	reporter.Progress(0.25)
+	previous := reporter.Status("Waiting for the network...")
+	reporter.Status(previous)
+	reporter.Phase(PhaseDone, "Done")
+	close(events)
+	<-done
+
+	want := []Event{
+		{Phase: PhaseUpload, Message: "Uploading content..."},
+		{Phase: PhaseUpload, Message: "Uploading content...", Current: 1, Total: 4, Fraction: 0.5},
+		// Progress never moves backwards.
+		{Phase: PhaseUpload, Message: "Uploading content...", Current: 1, Total: 4, Fraction: 0.5},
+		{Phase: PhaseUpload, Message: "Waiting for the network...", Current: 1, Total: 4, Fraction: 0.5},
+		{Phase: PhaseUpload, Message: "Uploading content...", Current: 1, Total: 4, Fraction: 0.5},
+		{Phase: PhaseDone, Message: "Done", Fraction: 0.5},
+	}
+	if got := collector.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var reporter *Reporter
+	reporter.Phase(PhaseCollect, "Collecting documents...")
+	reporter.Step(1, 2, 0.5)
+	if previous := reporter.Status("ignored"); previous != "" {
+		t.Errorf("Status() = %q, want empty", previous)
+	}
+}
+
+func TestPrintAndTee(t *testing.T) {
+	events := make(chan Event, 2)
+	events <- Event{Phase: PhaseCollect, Message: "Collecting documents...", Fraction: 0.1}
+	events <- Event{Phase: PhaseUpload, Message: "Uploading content...", Current: 3, Total: 10, Fraction: 0.5}
+	close(events)
+
+	console, collected := make(chan Event, 2), make(chan Event, 2)
+	Tee(events, console, collected)
+
+	var out bytes.Buffer
+	Print(&out, console)
+	want := "[ 10%] Collecting documents...\n[ 50%] Uploading content... (3/10)\n"
+	if out.String() != want {
+		t.Errorf("Print() wrote %q, want %q", out.String(), want)
+	}
+
+	var collector Collector
+	collector.Collect(collected)
+	if n := len(collector.Events()); n != 2 {
+		t.Errorf("collected %d events through Tee, want 2", n)
+	}
+}
@@ -2,12 +2,13 @@ package salesforce
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
 
-	"document-uploader/internal/config"
-	"document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/models"
 )
 
 type Client struct {
@@ -15,15 +16,19 @@ type Client struct {
 	httpClient  *http.Client
 }
 
-func NewClient(accessToken string) *Client {
+func NewClient(accessToken string, httpClient *http.Client) *Client {
 	return &Client{
 		accessToken: accessToken,
-		httpClient:  &http.Client{},
+		httpClient:  httpClient,
 	}
 }
 
 func (c *Client) MakeRequest(method, url string, body interface{}) (*http.Response, error) {
-	var bodyReader *bytes.Buffer
+	return c.MakeRequestContext(context.Background(), method, url, body)
+}
+
+func (c *Client) MakeRequestContext(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+	var bodyReader io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
@@ -32,7 +37,7 @@ func (c *Client) MakeRequest(method, url string, body interface{}) (*http.Respon
 		bodyReader = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
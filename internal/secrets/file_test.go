@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "secrets.json")
+	store := NewFileStore(path)
+
+	if _, err := store.Get(ClientSecretName); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() before any Set = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ClientSecretName); err != nil {
+		t.Fatalf("Delete() of a missing secret = %v", err)
+	}
+
+	production := RefreshTokenName("https://acme.my.salesforce.com")
+	sandbox := RefreshTokenName("https://acme--dev.sandbox.my.salesforce.com")
+	for name, value := range map[string]string{ClientSecretName: "s3cret", production: "prod-token", sandbox: "dev-token"} {
+		if err := store.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A new store reads what the first one saved.
+	reopened := NewFileStore(path)
+	for name, want := range map[string]string{ClientSecretName: "s3cret", production: "prod-token", sandbox: "dev-token"} {
+		if got, err := reopened.Get(name); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if err := reopened.Delete(production); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(production); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete = %v, want ErrNotFound", err)
+	}
+	if got, _ := store.Get(sandbox); got != "dev-token" {
+		t.Errorf("deleting one org's token removed another's: got %q", got)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("secret file mode = %o, want 600", mode)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("secret directory holds %d files, want only the secret file", len(entries))
+	}
+}
+
+func TestFileStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := os.WriteFile(path, []byte(`{"client_secret": "s3cret"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewFileStore(path).Get(ClientSecretName)
+	if err == nil {
+		t.Fatal("Get() from a corrupt file succeeded")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("error %q reveals the secret", err)
+	}
+	if err := NewFileStore(path).Set(ClientSecretName, "new"); err == nil {
+		t.Error("Set() overwrote a corrupt file")
+	}
+}
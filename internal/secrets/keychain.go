@@ -0,0 +1,106 @@
+//go:build !windows
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// macOSNotFound is the exit status of security(1) for a missing item.
+const macOSNotFound = 44
+
+// NewKeychain returns the OS credential store: the macOS Keychain through
+// security(1), or the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool(1) elsewhere. It fails when the tool is not installed.
+func NewKeychain() (Store, error) {
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil, fmt.Errorf("macOS Keychain is not available: %v", err)
+		}
+		return macKeychain{}, nil
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, fmt.Errorf("no Secret Service client found, install libsecret-tools: %v", err)
+	}
+	return secretService{}, nil
+}
+
+type macKeychain struct{}
+
+func (macKeychain) Get(name string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", name, "-w").Output()
+	if exitCode(err) == macOSNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %s from Keychain: %v", name, err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Set passes the secret on stdin in hex, so it never shows up in the
+// process list or needs quoting.
+func (macKeychain) Set(name, value string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", service, name, hex.EncodeToString([]byte(value)))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error saving %s to Keychain: %v", name, err)
+	}
+	return nil
+}
+
+func (macKeychain) Delete(name string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", service, "-a", name).Run()
+	if err != nil && exitCode(err) != macOSNotFound {
+		return fmt.Errorf("error removing %s from Keychain: %v", name, err)
+	}
+	return nil
+}
+
+type secretService struct{}
+
+func (secretService) Get(name string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "name", name)
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	// secret-tool exits with 1 and prints nothing for a missing item.
+	if exitCode(err) == 1 && stdout.Len() == 0 {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %s from the Secret Service: %v", name, err)
+	}
+	return stdout.String(), nil
+}
+
+func (secretService) Set(name, value string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", "Document Uploader "+name, "service", service, "name", name)
+	cmd.Stdin = strings.NewReader(value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error saving %s to the Secret Service: %v", name, err)
+	}
+	return nil
+}
+
+func (secretService) Delete(name string) error {
+	if err := exec.Command("secret-tool", "clear", "service", service, "name", name).Run(); err != nil && exitCode(err) != 1 {
+		return fmt.Errorf("error removing %s from the Secret Service: %v", name, err)
+	}
+	return nil
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
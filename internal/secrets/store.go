@@ -0,0 +1,26 @@
+package secrets
+
+import "errors"
+
+// ErrNotFound is returned by Get when no secret is stored under the name.
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps secrets such as the client secret and refresh tokens out of
+// the .env file. Implementations must never log or echo the values.
+type Store interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// service names this app's entries in the OS credential store.
+const service = "document-uploader"
+
+// ClientSecretName is where the connected app's client secret is kept.
+const ClientSecretName = "client_secret"
+
+// RefreshTokenName is where the refresh token for an org is kept, so that
+// tokens from different orgs do not overwrite each other.
+func RefreshTokenName(instanceURL string) string {
+	return "refresh_token:" + instanceURL
+}
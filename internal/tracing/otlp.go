@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const serviceName = "document-uploader"
+
+// OTLP span kind and status codes.
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding.
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLPExporter exports to the collector at endpoint, such as
+// http://localhost:4318, posting to its /v1/traces path.
+func NewOTLPExporter(endpoint string, client *http.Client) *OTLPExporter {
+	return &OTLPExporter{endpoint: strings.TrimSuffix(endpoint, "/") + "/v1/traces", client: client}
+}
+
+func (e *OTLPExporter) Export(spans []Span) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("OTLP export failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP export failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func otlpRequest(spans []Span) map[string]any {
+	otlpSpans := make([]map[string]any, 0, len(spans))
+	for _, span := range spans {
+		status := statusOK
+		if span.Failed {
+			status = statusError
+		}
+		otlpSpan := map[string]any{
+			"traceId":           span.TraceID,
+			"spanId":            span.SpanID,
+			"name":              span.Name,
+			"kind":              spanKindInternal,
+			"startTimeUnixNano": strconv.FormatInt(span.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(span.Attributes),
+			"status":            map[string]any{"code": status},
+		}
+		if span.ParentSpanID != "" {
+			otlpSpan["parentSpanId"] = span.ParentSpanID
+		}
+		otlpSpans = append(otlpSpans, otlpSpan)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": serviceName},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attributes map[string]any) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]otlpAttribute, 0, len(keys))
+	for _, key := range keys {
+		var value map[string]any
+		switch v := attributes[key].(type) {
+		case int:
+			// OTLP/JSON encodes 64-bit integers as strings.
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		result = append(result, otlpAttribute{Key: key, Value: value})
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+// Span is one timed operation: a whole run, or a phase within it.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	Failed       bool
+}
+
+// Exporter sends the spans of a finished run somewhere.
+type Exporter interface {
+	Export(spans []Span) error
+}
+
+// Follow turns the progress events of each run into a trace until the
+// channel is closed: a "run" span with a child span for every phase, such as
+// auth, collect, lookup, upload, distribute and attach. Item counts reported
+// by a phase are kept on its span. A trace is exported once its run is done
+// or has failed.
+func Follow(events <-chan progress.Event, exporter Exporter, logger *logging.Logger) {
+	var trace *runTrace
+	for event := range events {
+		switch {
+		case event.Phase == "":
+			// A reset before the run finished means it was abandoned.
+			if trace != nil {
+				trace.finish(time.Now(), true)
+				export(exporter, trace.spans, logger)
+				trace = nil
+			}
+		case event.Phase == progress.PhaseDone || event.Phase == progress.PhaseFailed:
+			if trace != nil {
+				trace.finish(time.Now(), event.Phase == progress.PhaseFailed)
+				export(exporter, trace.spans, logger)
+				trace = nil
+			}
+		default:
+			if trace == nil {
+				trace = newRunTrace(time.Now())
+			}
+			trace.observe(event, time.Now())
+		}
+	}
+}
+
+func export(exporter Exporter, spans []Span, logger *logging.Logger) {
+	if err := exporter.Export(spans); err != nil {
+		logger.Warning("Could not export run trace: %v", err)
+	}
+}
+
+// runTrace builds the spans of one run as its events arrive.
+type runTrace struct {
+	spans []Span
+	// phase is the index of the open phase span, or -1.
+	phase int
+}
+
+func newRunTrace(start time.Time) *runTrace {
+	return &runTrace{
+		spans: []Span{{
+			TraceID:    newID(16),
+			SpanID:     newID(8),
+			Name:       "run",
+			Start:      start,
+			Attributes: map[string]any{},
+		}},
+		phase: -1,
+	}
+}
+
+func (t *runTrace) observe(event progress.Event, now time.Time) {
+	if t.phase < 0 || t.spans[t.phase].Name != event.Phase {
+		t.endPhase(now, false)
+		root := t.spans[0]
+		t.spans = append(t.spans, Span{
+			TraceID:      root.TraceID,
+			SpanID:       newID(8),
+			ParentSpanID: root.SpanID,
+			Name:         event.Phase,
+			Start:        now,
+			Attributes:   map[string]any{},
+		})
+		t.phase = len(t.spans) - 1
+	}
+	if event.Total > 0 {
+		t.spans[t.phase].Attributes["items.completed"] = event.Current
+		t.spans[t.phase].Attributes["items.total"] = event.Total
+	}
+}
+
+func (t *runTrace) endPhase(now time.Time, failed bool) {
+	if t.phase < 0 {
+		return
+	}
+	t.spans[t.phase].End = now
+	t.spans[t.phase].Failed = failed
+	t.phase = -1
+}
+
+// finish closes the open phase and the run. A failure is put on the phase
+// it happened in as well as on the run.
+func (t *runTrace) finish(now time.Time, failed bool) {
+	t.endPhase(now, failed)
+	t.spans[0].End = now
+	t.spans[0].Failed = failed
+	t.spans[0].Attributes["phases"] = len(t.spans) - 1
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+// memoryExporter keeps the traces it is given.
+type memoryExporter struct {
+	mutex  sync.Mutex
+	traces [][]Span
+}
+
+func (e *memoryExporter) Export(spans []Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.traces = append(e.traces, spans)
+	return nil
+}
+
+// follow feeds events through Follow and returns the exported traces.
+func follow(events ...progress.Event) [][]Span {
+	exporter := &memoryExporter{}
+	channel := make(chan progress.Event, len(events))
+	for _, event := range events {
+		channel <- event
+	}
+	close(channel)
+	Follow(channel, exporter, logging.GetLogger())
+	return exporter.traces
+}
+
+func TestFollowSpanHierarchy(t *testing.T) {
+	traces := follow(
+		progress.Event{Phase: progress.PhaseAuth},
+		progress.Event{Phase: progress.PhaseCollect},
+		progress.Event{Phase: progress.PhaseLookup, Current: 1, Total: 3},
+		progress.Event{Phase: progress.PhaseLookup, Current: 3, Total: 3},
+		progress.Event{Phase: progress.PhaseUpload, Current: 10, Total: 10},
+		progress.Event{Phase: progress.PhaseDistribute},
+		progress.Event{Phase: progress.PhaseAttach, Current: 10, Total: 10},
+		progress.Event{Phase: progress.PhaseDone},
+	)
+	if len(traces) != 1 {
+		t.Fatalf("exported %d traces, want 1", len(traces))
+	}
+	spans := traces[0]
+
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	want := []string{"run", "auth", "collect", "lookup", "upload", "distribute", "attach"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+
+	run := spans[0]
+	if run.ParentSpanID != "" || len(run.TraceID) != 32 || len(run.SpanID) != 16 {
+		t.Errorf("run span = %+v, want a root span", run)
+	}
+	if run.Attributes["phases"] != 6 || run.Failed {
+		t.Errorf("run span attributes = %v, failed = %v", run.Attributes, run.Failed)
+	}
+	for _, span := range spans[1:] {
+		if span.TraceID != run.TraceID || span.ParentSpanID != run.SpanID {
+			t.Errorf("%s span is not a child of the run", span.Name)
+		}
+		if span.End.Before(span.Start) || span.Start.Before(run.Start) || span.End.After(run.End) {
+			t.Errorf("%s span runs from %v to %v, outside the run", span.Name, span.Start, span.End)
+		}
+	}
+	for i := 1; i < len(spans)-1; i++ {
+		if spans[i].End.After(spans[i+1].Start) {
+			t.Errorf("%s span ends after %s starts", spans[i].Name, spans[i+1].Name)
+		}
+	}
+	if got := spans[3].Attributes; got["items.completed"] != 3 || got["items.total"] != 3 {
+		t.Errorf("lookup span attributes = %v, want the last counts", got)
+	}
+	if got := spans[2].Attributes; len(got) != 0 {
+		t.Errorf("collect span attributes = %v, want none", got)
+	}
+}
+
+func TestFollowFailedAndAbandonedRuns(t *testing.T) {
+	traces := follow(
+		progress.Event{Phase: progress.PhaseAuth},
+		progress.Event{Phase: progress.PhaseUpload, Current: 2, Total: 10},
+		progress.Event{Phase: progress.PhaseFailed},
+		progress.Event{Phase: progress.PhaseAuth},
+		progress.Event{},
+		progress.Event{Phase: progress.PhaseDone},
+	)
+	if len(traces) != 2 {
+		t.Fatalf("exported %d traces, want the failed and the abandoned run", len(traces))
+	}
+
+	failed := traces[0]
+	if !failed[0].Failed || !failed[2].Failed || failed[1].Failed {
+		t.Errorf("failed run: run %v, auth %v, upload %v; want the run and upload failed",
+			failed[0].Failed, failed[1].Failed, failed[2].Failed)
+	}
+	abandoned := traces[1]
+	if len(abandoned) != 2 || !abandoned[0].Failed || abandoned[0].TraceID == failed[0].TraceID {
+		t.Errorf("abandoned run = %+v, want a new failed trace with one phase", abandoned)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+	}))
+	defer server.Close()
+
+	spans := follow(progress.Event{Phase: progress.PhaseUpload, Current: 4, Total: 4}, progress.Event{Phase: progress.PhaseFailed})[0]
+	if err := NewOTLPExporter(server.URL+"/", server.Client()).Export(spans); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := request["resourceSpans"].([]any)[0].(map[string]any)["scopeSpans"].([]any)[0].(map[string]any)
+	sent := scope["spans"].([]any)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d spans, want 2", len(sent))
+	}
+	upload := sent[1].(map[string]any)
+	if upload["name"] != "upload" || upload["parentSpanId"] != spans[0].SpanID {
+		t.Errorf("upload span = %v", upload)
+	}
+	if code := upload["status"].(map[string]any)["code"]; code != float64(statusError) {
+		t.Errorf("status code = %v, want %d", code, statusError)
+	}
+	wantAttributes := []any{
+		map[string]any{"key": "items.completed", "value": map[string]any{"intValue": "4"}},
+		map[string]any{"key": "items.total", "value": map[string]any{"intValue": "4"}},
+	}
+	if !reflect.DeepEqual(upload["attributes"], wantAttributes) {
+		t.Errorf("attributes = %v, want %v", upload["attributes"], wantAttributes)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	if err := NewOTLPExporter(failing.URL, failing.Client()).Export(spans); err == nil {
+		t.Error("Export() to a failing collector succeeded")
+	}
+}
@@ -2,50 +2,222 @@ package main
 
 import (
 	"embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/ORAITApps/document-uploader/internal/auth"
 	"github.com/ORAITApps/document-uploader/internal/config"
+	"github.com/ORAITApps/document-uploader/internal/control"
+	"github.com/ORAITApps/document-uploader/internal/diagnostics"
 	"github.com/ORAITApps/document-uploader/internal/gui"
+	"github.com/ORAITApps/document-uploader/internal/httpclient"
 	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
 	"github.com/ORAITApps/document-uploader/internal/processor"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+	"github.com/ORAITApps/document-uploader/internal/secrets"
+	"github.com/ORAITApps/document-uploader/internal/tracing"
 )
 
 //go:embed .env
 var env embed.FS
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
 	config.LoadEnv(env)
+	if err := config.Validate(); err != nil {
+		if len(os.Args) > 1 {
+			fmt.Fprintf(os.Stderr, "configuration: %v\n", err)
+			os.Exit(2)
+		}
+		gui.ShowStartupError("Configuration Error", err.Error())
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 {
+		code := runCLI(os.Args[1:])
+		logging.GetLogger().Close()
+		os.Exit(code)
+	}
+
 	app := gui.NewApp()
+	app.SetFileParser(processor.ParseFile)
+	app.SetDocumentTypes(processor.DocumentTypes())
+	app.SetUploadEstimator(func(run models.DirectoryRun) (string, error) {
+		estimate, err := processor.EstimateUpload(run)
+		if err != nil {
+			return "", err
+		}
+		return estimate.Summary(), nil
+	})
+	app.SetResumePreviewer(func(reportPath string) (string, error) {
+		preview, err := processor.PreviewResume(reportPath)
+		if err != nil {
+			return "", err
+		}
+		return preview.Summary(), nil
+	})
 
 	logger := logging.GetLogger()
 	defer logger.Close()
+	if err := logger.FileErr(); err != nil {
+		logger.Warning("Logging to a file is unavailable, messages are only shown here: %v", err)
+	}
 
-	app.SetProcessingHandler(func() {
-		logger.Info("Starting authentication process...")
-		app.SetStatus("Authenticating...")
-		app.SetProgress(0.1)
+	setupClients(logger)
+
+	events := make(chan progress.Event, 64)
+	reporter := progress.NewReporter(events)
+	guiEvents := make(chan progress.Event, 64)
+	go app.RenderProgress(guiEvents)
+
+	outs := []chan<- progress.Event{guiEvents}
+	if config.ControlAPIEnabled {
+		if controlEvents := startControlAPI(reporter, logger); controlEvents != nil {
+			outs = append(outs, controlEvents)
+		}
+	}
+	if config.TracingEnabled {
+		traceEvents := make(chan progress.Event, 64)
+		outs = append(outs, traceEvents)
+		// The collector is not Salesforce, so its failures must not trip the
+		// shared client's circuit breaker.
+		exporter := tracing.NewOTLPExporter(config.OTLPEndpoint, &http.Client{Timeout: 10 * time.Second})
+		go tracing.Follow(traceEvents, exporter, logger)
+		logger.Info("Tracing runs to %s", config.OTLPEndpoint)
+	}
+	go progress.Tee(events, outs...)
 
-		tokenResp, err := auth.Authenticate()
+	app.SetProcessingHandler(func() {
+		result, err := runUpload(uploadRequest{
+			InstanceURL:  app.GetInstanceURL(),
+			ResumeReport: app.GetResumeReport(),
+			Files:        app.GetSelectedFiles(),
+			Directory:    app.GetDirectoryRun(),
+		}, reporter, logger)
 		if err != nil {
-			logger.Error("Authentication failed: %v", err)
-			app.ShowError("Authentication Error", err.Error())
+			title := "Processing Error"
+			var uploadErr *uploadError
+			if errors.As(err, &uploadErr) {
+				title = uploadErr.Title
+			}
+			app.ShowError(title, err.Error())
 			app.Reset()
 			return
 		}
+		app.SetResultsURL(result.ViewURL(config.SFInstanceURL))
+		app.Complete()
+	})
 
-		logger.Success("✅ Authentication successful")
+	app.SetDiagnosticsHandler(func() (string, error) {
+		return createDiagnosticsBundle(logger)
+	})
 
-		if err := processor.ProcessDocuments(tokenResp.AccessToken, app.GetDocumentsPath(), app); err != nil {
-			logger.Error("Processing failed: %v", err)
-			app.ShowError("Processing Error", err.Error())
-			app.Reset()
+	app.SetSignOutHandler(func() {
+		if err := auth.SignOut(); err != nil {
+			logger.Warning("Signed out locally, but %v", err)
 			return
 		}
-
-		logger.Success("🎉 Processing completed successfully!")
-		app.SetProgress(1.0)
-		app.SetStatus("Completed")
+		logger.Info("Signed out")
 	})
 
 	app.Run()
 }
+
+// createDiagnosticsBundle zips today's log, the latest run report, the
+// redacted settings and basic environment details next to the log files,
+// and returns the path of the bundle.
+func createDiagnosticsBundle(logger *logging.Logger) (string, error) {
+	logPath := logger.FilePath()
+	if logPath == "" {
+		return "", errors.New("no log file to include")
+	}
+	reportPath, err := processor.LatestRunReportPath()
+	if err != nil {
+		logger.Warning("Diagnostics bundle will not include a run report: %v", err)
+	}
+
+	bundle := &diagnostics.Bundle{
+		LogPath:    logPath,
+		ReportPath: reportPath,
+		Settings:   config.RedactedSettings(),
+		Version:    version,
+	}
+	path := filepath.Join(filepath.Dir(logPath), fmt.Sprintf("diagnostics_%s.zip", time.Now().Format("20060102-150405")))
+	if err := bundle.Write(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// setupClients gives authentication and the processor the shared HTTP
+// client and the configured secret store.
+func setupClients(logger *logging.Logger) {
+	client, err := httpclient.New()
+	if err != nil {
+		logger.Error("Invalid HTTP configuration, using defaults: %v", err)
+		client = http.DefaultClient
+	}
+	auth.SetHTTPClient(client)
+	auth.SetSecretStore(openSecretStore(logger))
+	processor.SetHTTPClient(client)
+}
+
+// openSecretStore returns the store selected by SECRET_STORE, or nil when
+// secrets are only read from .env. The OS credential store falls back to
+// the secret file where there is none.
+func openSecretStore(logger *logging.Logger) secrets.Store {
+	switch config.SecretStore {
+	case "":
+		return nil
+	case config.SecretStoreKeychain:
+		store, err := secrets.NewKeychain()
+		if err == nil {
+			return store
+		}
+		logger.Warning("OS credential store unavailable, keeping secrets in %s: %v", config.SecretFile, err)
+		return secrets.NewFileStore(config.SecretFile)
+	case config.SecretStoreFile:
+		return secrets.NewFileStore(config.SecretFile)
+	default:
+		logger.Warning("Unknown SECRET_STORE %q, secrets are only read from .env", config.SecretStore)
+		return nil
+	}
+}
+
+// startControlAPI serves the local control API in the background and
+// returns the channel it follows progress on, or nil if it could not start.
+// Runs it starts report through the same reporter as the GUI.
+func startControlAPI(reporter *progress.Reporter, logger *logging.Logger) chan<- progress.Event {
+	server, err := control.NewServer(config.ControlAPIToken, func(request control.RunRequest) (string, error) {
+		result, err := runUpload(uploadRequest{Directory: models.DirectoryRun{
+			DocumentsDir:    request.Directory,
+			SubtreeFilter:   request.Subtree,
+			ExcludePatterns: request.Exclude,
+			DocumentType:    request.DocumentType,
+		}}, reporter, logger)
+		if err != nil {
+			return "", err
+		}
+		return result.ViewURL(config.SFInstanceURL), nil
+	}, processor.LatestRunReportPath)
+	if err != nil {
+		logger.Error("Control API disabled: %v", err)
+		return nil
+	}
+
+	events := make(chan progress.Event, 64)
+	go server.Track(events)
+	go func() {
+		logger.Info("Control API listening on %s", config.ControlAPIAddr)
+		if err := server.ListenAndServe(config.ControlAPIAddr); err != nil {
+			logger.Error("Control API stopped: %v", err)
+		}
+	}()
+	return events
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/ORAITApps/document-uploader/internal/auth"
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/processor"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+// uploadRequest describes one run: a report to resume, individually
+// selected files, or a documents directory, checked in that order.
+type uploadRequest struct {
+	// InstanceURL, when set, switches the target org before signing in.
+	InstanceURL  string
+	ResumeReport string
+	Files        []string
+	Directory    models.DirectoryRun
+}
+
+// uploadError is a failed run with the title to show it under.
+type uploadError struct {
+	Title string
+	Err   error
+}
+
+func (e *uploadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *uploadError) Unwrap() error {
+	return e.Err
+}
+
+// signIn returns the session's token, opening the browser when needed. It is
+// a variable so tests can sign in without one.
+var signIn = auth.Token
+
+// runUpload signs in, checks the org when configured and runs the upload.
+// Both the GUI and the control API start runs through here. The reporter is
+// shared with any run already in progress, so nothing touches it, the target
+// org or the session until the run guard is taken.
+func runUpload(request uploadRequest, reporter *progress.Reporter, logger *logging.Logger) (*processor.RunResult, error) {
+	release, err := processor.TryStartRun()
+	if err != nil {
+		logger.Warning("Not starting: %v", err)
+		return nil, &uploadError{Title: "Run In Progress", Err: err}
+	}
+	defer release()
+	reporter.Reset()
+
+	// A changed instance URL no longer matches the signed-in session, so the
+	// run signs in again against the new org.
+	if request.InstanceURL != "" {
+		previous := config.SFInstanceURL
+		if err := config.SetInstanceURL(request.InstanceURL); err != nil {
+			return nil, &uploadError{Title: "Instance URL", Err: err}
+		}
+		if config.SFInstanceURL != previous {
+			logger.Info("🌐 Target org: %s", config.SFInstanceURL)
+		}
+	}
+
+	if auth.SignedIn() {
+		logger.Info("Reusing the signed-in session")
+	} else {
+		logger.Info("Starting authentication process...")
+	}
+	reporter.Phase(progress.PhaseAuth, "Authenticating...")
+	reporter.Progress(0.1)
+
+	tokenResp, err := signIn()
+	if err != nil {
+		logger.Error("Authentication failed: %v", err)
+		reporter.Phase(progress.PhaseFailed, "Authentication failed")
+		return nil, &uploadError{Title: "Authentication Error", Err: err}
+	}
+
+	logger.Success("✅ Authentication successful")
+
+	if config.ValidateOrg {
+		reporter.Phase(progress.PhaseAuth, "Checking org compatibility...")
+		compatibility := processor.CheckOrgCompatibility(tokenResp.AccessToken)
+		if !compatibility.Compatible() {
+			logger.Error("%s", compatibility.Summary())
+			reporter.Phase(progress.PhaseFailed, "Org check failed")
+			return nil, &uploadError{Title: "Org Compatibility", Err: errors.New(compatibility.Summary())}
+		}
+		logger.Success("%s", compatibility.Summary())
+	}
+
+	start := func(accessToken string) (*processor.RunResult, error) {
+		if request.ResumeReport != "" {
+			return processor.ResumeFromReport(accessToken, request.ResumeReport, reporter)
+		}
+		if len(request.Files) > 0 {
+			return processor.ProcessFiles(accessToken, request.Files, reporter)
+		}
+		return processor.ProcessDocuments(accessToken, request.Directory, reporter)
+	}
+
+	result, err := start(tokenResp.AccessToken)
+	// An expired session is renewed once. A run that fails mid-way resumes
+	// from its report, keeping the log and progress bar as they are; one
+	// rejected before it started is simply started again.
+	if errors.Is(err, processor.ErrSessionExpired) {
+		var runErr *processor.RunError
+		resume := errors.As(err, &runErr)
+		logger.Warning("Salesforce session expired, signing in again")
+		auth.Invalidate()
+		tokenResp, err = signIn()
+		if err == nil && resume {
+			logger.Success("🔑 Re-authenticated, resuming from %s", filepath.Base(runErr.ReportPath))
+			result, err = processor.ResumeFromReport(tokenResp.AccessToken, runErr.ReportPath, reporter)
+		} else if err == nil {
+			logger.Success("🔑 Re-authenticated")
+			result, err = start(tokenResp.AccessToken)
+		}
+	}
+	if err != nil {
+		logger.Error("Processing failed: %v", err)
+		reporter.Phase(progress.PhaseFailed, "Processing failed")
+		return nil, &uploadError{Title: "Processing Error", Err: err}
+	}
+
+	logger.Success("🎉 Processing completed successfully!")
+	reporter.Phase(progress.PhaseDone, "Completed")
+	reporter.Progress(1.0)
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ORAITApps/document-uploader/internal/auth"
+	"github.com/ORAITApps/document-uploader/internal/config"
+	logging "github.com/ORAITApps/document-uploader/internal/logger"
+	"github.com/ORAITApps/document-uploader/internal/models"
+	"github.com/ORAITApps/document-uploader/internal/processor"
+	"github.com/ORAITApps/document-uploader/internal/progress"
+)
+
+// TestRunUploadLeavesActiveRunAlone starts a second run, as the control API
+// or the GUI would while the other one is uploading. It must fail before it
+// touches the progress or the target org of the active run.
+func TestRunUploadLeavesActiveRunAlone(t *testing.T) {
+	tests := []struct {
+		name    string
+		request uploadRequest
+	}{
+		{"directory", uploadRequest{Directory: models.DirectoryRun{DocumentsDir: t.TempDir()}}},
+		{"files", uploadRequest{Files: []string{"bl_front.jpg"}}},
+		{"resume", uploadRequest{ResumeReport: "run_test.json"}},
+		{"other org", uploadRequest{InstanceURL: "https://other.my.salesforce.com", Directory: models.DirectoryRun{DocumentsDir: t.TempDir()}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release, err := processor.TryStartRun()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+
+			events := make(chan progress.Event, 16)
+			reporter := progress.NewReporter(events)
+			reporter.Phase(progress.PhaseUpload, "Uploading content...")
+			reporter.Progress(0.5)
+			<-events
+			<-events
+			instanceURL := config.SFInstanceURL
+
+			_, err = runUpload(tt.request, reporter, logging.GetLogger())
+			if !errors.Is(err, processor.ErrRunInProgress) {
+				t.Fatalf("runUpload() = %v, want ErrRunInProgress", err)
+			}
+			select {
+			case event := <-events:
+				t.Errorf("second run reported %+v over the active run", event)
+			default:
+			}
+			if config.SFInstanceURL != instanceURL {
+				t.Errorf("instance URL changed to %s during the active run", config.SFInstanceURL)
+			}
+		})
+	}
+}
+
+// TestRunUploadReauthKeepsProgress has Salesforce reject the first token, so
+// the run signs in again. The run must carry on with its log and progress
+// instead of starting over.
+func TestRunUploadReauthKeepsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer expired" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	previousURL, previousSignIn := config.SFInstanceURL, signIn
+	config.SFInstanceURL = server.URL
+	processor.SetHTTPClient(server.Client())
+	tokens := []string{"expired", "renewed"}
+	signIn = func() (*models.TokenResponse, error) {
+		token := &models.TokenResponse{AccessToken: tokens[0]}
+		tokens = tokens[1:]
+		return token, nil
+	}
+	defer func() {
+		config.SFInstanceURL, signIn = previousURL, previousSignIn
+		processor.SetHTTPClient(http.DefaultClient)
+		auth.Invalidate()
+	}()
+
+	report := filepath.Join(t.TempDir(), "run_test.json")
+	content := `{"runId":"run-1","documents":[{"relativePath":"a.jpg","status":"uploaded"}]}`
+	if err := os.WriteFile(report, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan progress.Event, 64)
+	logger := logging.GetLogger()
+	if _, err := runUpload(uploadRequest{ResumeReport: report}, progress.NewReporter(events), logger); err != nil {
+		t.Fatalf("runUpload() = %v", err)
+	}
+	if len(tokens) != 0 {
+		t.Fatalf("signed in %d time(s), want 2", 2-len(tokens))
+	}
+	close(events)
+
+	var fraction float64
+	first := true
+	for event := range events {
+		if !first && event == (progress.Event{}) {
+			t.Error("progress was reset after signing in again")
+		}
+		if event.Fraction < fraction {
+			t.Errorf("progress moved back from %.2f to %.2f", fraction, event.Fraction)
+		}
+		fraction, first = event.Fraction, false
+	}
+	if fraction != 1 {
+		t.Errorf("final progress = %.2f, want 1", fraction)
+	}
+
+	reauthenticated := false
+	for _, line := range logger.RecentLines(10) {
+		reauthenticated = reauthenticated || strings.Contains(line, "Re-authenticated")
+	}
+	if !reauthenticated {
+		t.Errorf("log = %q, want a re-authentication marker", logger.RecentLines(10))
+	}
+}